@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"homework_ipl/internal/http-server/server/db"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/sightimport"
+	"homework_ipl/internal/usecase"
+)
+
+// service import [-dry-run] [-format csv|geojson] file
+// Печатает отчёт в JSON, код выхода 1 - импорт не выполнен
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report what would change")
+	format := flags.String("format", "", "csv or geojson, by default from the file extension")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: service import [-dry-run] [-format csv|geojson] file")
+		return 2
+	}
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = sightimport.FormatFromFilename(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer file.Close()
+
+	pool, err := db.GetPostgres()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect to database:", err)
+		return 1
+	}
+	defer pool.Close()
+
+	report, err := usecase.ImportSights(context.Background(), repository.NewImportRepo(pool), file, *format, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import failed:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if len(report.Errors) > 0 && !*dryRun {
+		return 1
+	}
+	return 0
+}
@@ -1,21 +1,101 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"os"
+
 	"homework_ipl/internal/config"
+	"homework_ipl/internal/geoexport"
 	"homework_ipl/internal/http-server/server"
+	"homework_ipl/internal/http-server/server/db"
+	"homework_ipl/internal/mailer"
+	"homework_ipl/internal/password"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/usecase"
 	"homework_ipl/router"
 	"homework_ipl/utils/logger"
 )
 
 func main() {
+	// пароль флагом не передаётся, чтобы не светился в списке процессов: только ADMIN_PASSWORD или конфиг
+	adminEmail := flag.String("admin-email", "", "email of the user to seed as admin, password is taken from ADMIN_PASSWORD")
+	flag.Parse()
+
+	// go run ./cmd/service import [-dry-run] sights.csv
+	if flag.Arg(0) == "import" {
+		os.Exit(runImport(flag.Args()[1:]))
+	}
+
 	logger := logger.Logger()
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		logger.Error("Failed to load config", "error", err)
 		return
 	}
+	if *adminEmail != "" {
+		cfg.Admin.Email = *adminEmail
+	}
+
+	logger.Info("Start config", "env", cfg.Env, "address", cfg.HTTPServer.Address)
+
+	// до SeedAdmin: пароль администратора хэшируется уже с параметрами из конфига
+	if err := password.Init(cfg.PasswordPolicy, cfg.PasswordHashing); err != nil {
+		logger.Error("Invalid password settings", "error", err)
+		return
+	}
+
+	pool, err := db.GetPostgres()
+	if err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		return
+	}
+	defer pool.Close()
+
+	if cfg.Admin.Email != "" {
+		if err := repository.NewUserRepo(pool).SeedAdmin(cfg.Admin.Email, cfg.Admin.Password); err != nil {
+			logger.Error("Failed to seed admin", "error", err)
+			return
+		}
+		logger.Info("Admin seeded", "email", cfg.Admin.Email)
+	}
+
+	var sessionStore usecase.SessionStore = usecase.NewMemorySessionStore()
+	if cfg.Session.Store == "postgres" {
+		sessionStore = repository.NewSessionRepo(pool)
+	}
+	if err := usecase.InitSessions(cfg, sessionStore); err != nil {
+		logger.Error("Failed to init sessions", "error", err)
+		return
+	}
+
+	var loginStore usecase.LoginAttemptStore = usecase.NewMemoryLoginAttemptStore()
+	if cfg.LoginLimit.Store == "postgres" {
+		loginStore = repository.NewLoginAttemptRepo(pool)
+	}
+	usecase.Logins = usecase.NewLoginLimiter(loginStore, cfg.LoginLimit)
+	usecase.APITokens = repository.NewAPITokenRepo(pool)
+
+	mail, err := mailer.New(cfg.Mail, cfg.Env)
+	if err != nil {
+		logger.Error("Failed to init mailer", "error", err)
+		return
+	}
+	usecase.InitMail(cfg.Mail, mail)
+	usecase.InitTwoFactor(cfg.TwoFactor)
+	usecase.AuditLog = repository.NewAuditRepo(pool)
+	usecase.InitExport(cfg.Export, cfg.FileUploadPath)
+	usecase.InitAccountDeletion(cfg.AccountDeletion)
+	usecase.InitSearch(cfg.Search)
+	geoexport.Init(cfg.GeoExport)
 
-	logger.Info("Start config", "config", cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	usecase.InitOIDC(ctx, cfg.OIDC)
+	usecase.StartSessionSweeper(ctx, cfg.Session.SweepInterval)
+	usecase.StartLoginAttemptSweeper(ctx, cfg.LoginLimit.SweepInterval)
+	usecase.StartExportSweeper(ctx, repository.NewExportRepo(pool), cfg.Export.SweepInterval)
+	usecase.StartAccountPurger(ctx, repository.NewUserRepo(pool), cfg.AccountDeletion.PurgeInterval)
 
 	router := router.SetupRouter(cfg)
 
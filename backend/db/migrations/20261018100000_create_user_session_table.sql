@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE TABLE user_session
+(
+    id         text PRIMARY KEY,
+    user_id    integer     NOT NULL REFERENCES user_data (id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL,
+    last_seen  timestamptz NOT NULL DEFAULT now(),
+    user_agent text        NOT NULL DEFAULT '',
+    ip         text        NOT NULL DEFAULT ''
+);
+
+CREATE INDEX user_session_user_id_idx ON user_session (user_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS user_session CASCADE;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE user_data
+    ADD COLUMN role text NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin'));
+
+ALTER TABLE user_session
+    ADD COLUMN role text NOT NULL DEFAULT 'user';
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE user_session DROP COLUMN IF EXISTS role;
+ALTER TABLE user_data DROP COLUMN IF EXISTS role;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE TABLE login_attempt
+(
+    key          text PRIMARY KEY,
+    failures     integer     NOT NULL DEFAULT 0,
+    last_failure timestamptz NOT NULL,
+    locked_until timestamptz NOT NULL
+);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS login_attempt CASCADE;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE user_data ADD COLUMN email_verified boolean NOT NULL DEFAULT false;
+
+-- существующие пользователи регистрировались до появления подтверждения
+UPDATE user_data SET email_verified = true;
+
+CREATE TABLE email_verification
+(
+    token_hash text PRIMARY KEY,
+    user_id    integer     NOT NULL REFERENCES user_data (id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX email_verification_user_id_idx ON email_verification (user_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS email_verification CASCADE;
+ALTER TABLE user_data DROP COLUMN IF EXISTS email_verified;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE TABLE user_totp
+(
+    user_id    integer PRIMARY KEY REFERENCES user_data (id) ON DELETE CASCADE,
+    secret     text        NOT NULL,
+    enabled    boolean     NOT NULL DEFAULT false,
+    last_step  bigint      NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE totp_recovery_code
+(
+    user_id   integer NOT NULL REFERENCES user_data (id) ON DELETE CASCADE,
+    code_hash text    NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS totp_recovery_code CASCADE;
+DROP TABLE IF EXISTS user_totp CASCADE;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE TABLE api_token
+(
+    id           integer PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id      integer     NOT NULL REFERENCES user_data (id) ON DELETE CASCADE,
+    name         text        NOT NULL,
+    scopes       text[]      NOT NULL,
+    token_hash   text        NOT NULL UNIQUE,
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    expires_at   timestamptz,
+    last_used_at timestamptz
+);
+
+CREATE INDEX api_token_user_id_idx ON api_token (user_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS api_token CASCADE;
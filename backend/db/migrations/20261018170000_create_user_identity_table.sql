@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE TABLE user_identity
+(
+    provider   text        NOT NULL,
+    subject    text        NOT NULL,
+    user_id    integer     NOT NULL REFERENCES user_data (id) ON DELETE CASCADE,
+    email      text        NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX user_identity_user_id_idx ON user_identity (user_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS user_identity CASCADE;
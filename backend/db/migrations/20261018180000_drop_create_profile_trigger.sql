@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- профиль создаётся приложением в той же транзакции, что и пользователь (UserRepo.CreateUser)
+DROP TRIGGER IF EXISTS create_profile_trigger ON user_data;
+DROP FUNCTION IF EXISTS create_profile();
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION create_profile()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO profile_data (user_id, username, bio, avatar)
+    VALUES (NEW.id, NEW.email, '', '');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER create_profile_trigger
+    AFTER INSERT
+    ON user_data
+    FOR EACH ROW
+EXECUTE FUNCTION create_profile();
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- соль хранится внутри хэша пароля (формат PHC у argon2id, у bcrypt - тоже в строке хэша),
+-- отдельная колонка никогда не заполнялась
+ALTER TABLE user_data DROP COLUMN IF EXISTS salt;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE user_data ADD COLUMN IF NOT EXISTS salt text;
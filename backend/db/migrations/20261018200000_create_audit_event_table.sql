@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- журнал аудита: входы, смена пароля, удаления, загрузка аватарок.
+-- actor_id без внешнего ключа, чтобы история не пропадала вместе с пользователем
+CREATE TABLE audit_event (
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    actor_id integer,
+    action text NOT NULL,
+    target text NOT NULL DEFAULT '',
+    ip text NOT NULL DEFAULT '',
+    request_id text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_event_created_at_idx ON audit_event(created_at);
+CREATE INDEX audit_event_actor_idx ON audit_event(actor_id, created_at);
+CREATE INDEX audit_event_target_idx ON audit_event(target, created_at);
+CREATE INDEX audit_event_action_idx ON audit_event(action, created_at);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS audit_event;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- выгрузки персональных данных: id - случайный токен из ссылки на скачивание
+CREATE TABLE data_export (
+    id text PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES user_data(id) ON DELETE CASCADE,
+    status text NOT NULL CHECK (status IN ('pending', 'ready', 'failed')),
+    file_path text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz
+);
+
+CREATE INDEX data_export_user_id_idx ON data_export(user_id, created_at);
+CREATE INDEX data_export_expires_at_idx ON data_export(expires_at);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS data_export;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- мягкое удаление: до окончания периода восстановления аккаунт возвращается входом,
+-- потом UserRepo.PurgeUser удаляет данные и передаёт отзывы анонимному пользователю
+ALTER TABLE user_data ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX user_data_deleted_at_idx ON user_data(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS user_data_deleted_at_idx;
+ALTER TABLE user_data DROP COLUMN IF EXISTS deleted_at;
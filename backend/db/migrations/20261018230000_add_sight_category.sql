@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+ALTER TABLE sight ADD COLUMN IF NOT EXISTS category text;
+
+-- индексы под сортировки и фильтры постраничного списка /sights
+CREATE INDEX sight_rating_id_idx ON sight(rating DESC, id DESC);
+CREATE INDEX sight_name_id_idx ON sight(name, id);
+CREATE INDEX sight_city_id_idx ON sight(city_id);
+CREATE INDEX sight_country_id_idx ON sight(country_id);
+CREATE INDEX sight_category_idx ON sight(category);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS sight_category_idx;
+DROP INDEX IF EXISTS sight_country_id_idx;
+DROP INDEX IF EXISTS sight_city_id_idx;
+DROP INDEX IF EXISTS sight_name_id_idx;
+DROP INDEX IF EXISTS sight_rating_id_idx;
+ALTER TABLE sight DROP COLUMN IF EXISTS category;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- полнотекстовый поиск с русской морфологией: название весомее описания
+ALTER TABLE sight ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX sight_search_vector_idx ON sight USING gin(search_vector);
+
+-- триграммы для поиска с опечатками по названиям
+CREATE INDEX sight_name_trgm_idx ON sight USING gin(name gin_trgm_ops);
+CREATE INDEX city_city_trgm_idx ON city USING gin(city gin_trgm_ops);
+CREATE INDEX country_country_trgm_idx ON country USING gin(country gin_trgm_ops);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS country_country_trgm_idx;
+DROP INDEX IF EXISTS city_city_trgm_idx;
+DROP INDEX IF EXISTS sight_name_trgm_idx;
+DROP INDEX IF EXISTS sight_search_vector_idx;
+ALTER TABLE sight DROP COLUMN IF EXISTS search_vector;
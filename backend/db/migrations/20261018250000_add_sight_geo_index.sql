@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- координаты были только в init.sql, в цепочке миграций их не было
+ALTER TABLE sight ADD COLUMN IF NOT EXISTS latitude REAL;
+ALTER TABLE sight ADD COLUMN IF NOT EXISTS longitude REAL;
+
+-- earthdistance вместо PostGIS: хватает для поиска по радиусу и не требует отдельной сборки postgres
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
+
+CREATE INDEX sight_earth_idx ON sight USING gist(ll_to_earth(latitude, longitude))
+    WHERE latitude IS NOT NULL AND longitude IS NOT NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS sight_earth_idx;
+
+ALTER TABLE sight DROP COLUMN IF EXISTS longitude;
+ALTER TABLE sight DROP COLUMN IF EXISTS latitude;
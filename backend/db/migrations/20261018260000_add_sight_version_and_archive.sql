@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- version растёт при каждом изменении и отдаётся как ETag: правка со старой версией отклоняется.
+-- Архивные достопримечательности пропадают из списков и поиска, но остаются в поездках
+ALTER TABLE sight ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+ALTER TABLE sight ADD COLUMN IF NOT EXISTS archived_at timestamptz;
+
+CREATE INDEX sight_archived_at_idx ON sight(archived_at) WHERE archived_at IS NOT NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+DROP INDEX IF EXISTS sight_archived_at_idx;
+ALTER TABLE sight DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE sight DROP COLUMN IF EXISTS version;
@@ -12,6 +12,16 @@ DROP TABLE IF EXISTS journey_sight CASCADE;
 DROP TABLE IF EXISTS image_data CASCADE;
 DROP TABLE IF EXISTS feedback CASCADE;
 DROP TABLE IF EXISTS profile_data CASCADE;
+DROP TABLE IF EXISTS user_session CASCADE;
+DROP TABLE IF EXISTS login_attempt CASCADE;
+DROP TABLE IF EXISTS email_verification CASCADE;
+DROP TABLE IF EXISTS password_reset CASCADE;
+DROP TABLE IF EXISTS user_totp CASCADE;
+DROP TABLE IF EXISTS totp_recovery_code CASCADE;
+DROP TABLE IF EXISTS api_token CASCADE;
+DROP TABLE IF EXISTS user_identity CASCADE;
+DROP TABLE IF EXISTS audit_event CASCADE;
+DROP TABLE IF EXISTS data_export CASCADE;
 
 CREATE TABLE country(
     id integer PRIMARY KEY GENERATED ALWAYS AS IDENTITY ,
@@ -27,9 +37,14 @@ CREATE TABLE city(
 CREATE TABLE user_data (
     id integer PRIMARY KEY GENERATED ALWAYS AS IDENTITY ,
     email text NOT NULL UNIQUE,
-    passwrd text NOT NULL
+    passwrd text NOT NULL,
+    role text NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin')),
+    email_verified boolean NOT NULL DEFAULT false,
+    deleted_at timestamptz
 );
 
+CREATE INDEX user_data_deleted_at_idx ON user_data(deleted_at) WHERE deleted_at IS NOT NULL;
+
 CREATE TABLE profile_data (
     user_id integer REFERENCES user_data(id),
     username text UNIQUE,
@@ -46,7 +61,14 @@ CREATE TABLE sight(
     country_id integer REFERENCES country (id),
 	UNIQUE (name, city_id),
     latitude REAL,
-    longitude REAL
+    longitude REAL,
+    category text,
+    version integer NOT NULL DEFAULT 1,
+    archived_at timestamptz,
+    search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(description, '')), 'B')
+    ) STORED
 );
 
 CREATE TABLE image_data(
@@ -299,19 +321,136 @@ INSERT INTO image_data(path, sight_id) VALUES
 ('public/18.jpg', 18);
 
 
-CREATE OR REPLACE FUNCTION create_profile()
-RETURNS TRIGGER AS $$
-BEGIN
-    INSERT INTO profile_data (user_id, username, bio, avatar)
-    VALUES (NEW.id, NEW.email, '', '');
-    RETURN NEW;
-END;
-$$ LANGUAGE plpgsql;
 
-CREATE TRIGGER create_profile_trigger
-AFTER INSERT ON user_data
-FOR EACH ROW
-EXECUTE FUNCTION create_profile();
+
+CREATE TABLE user_session(
+    id text PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES user_data(id) ON DELETE CASCADE,
+    role text NOT NULL DEFAULT 'user',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL,
+    last_seen timestamptz NOT NULL DEFAULT now(),
+    user_agent text NOT NULL DEFAULT '',
+    ip text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX user_session_user_id_idx ON user_session(user_id);
+
+
+CREATE TABLE login_attempt(
+    key text PRIMARY KEY,
+    failures integer NOT NULL DEFAULT 0,
+    last_failure timestamptz NOT NULL,
+    locked_until timestamptz NOT NULL
+);
+
+
+CREATE TABLE email_verification(
+    token_hash text PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES user_data(id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX email_verification_user_id_idx ON email_verification(user_id);
+
+
+CREATE TABLE password_reset(
+    token_hash text PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES user_data(id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX password_reset_user_id_idx ON password_reset(user_id);
+
+
+CREATE TABLE user_totp(
+    user_id integer PRIMARY KEY REFERENCES user_data(id) ON DELETE CASCADE,
+    secret text NOT NULL,
+    enabled boolean NOT NULL DEFAULT false,
+    last_step bigint NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE totp_recovery_code(
+    user_id integer NOT NULL REFERENCES user_data(id) ON DELETE CASCADE,
+    code_hash text NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+
+CREATE TABLE api_token(
+    id integer PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id integer NOT NULL REFERENCES user_data(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    scopes text[] NOT NULL,
+    token_hash text NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz,
+    last_used_at timestamptz
+);
+
+CREATE INDEX api_token_user_id_idx ON api_token(user_id);
+
+
+CREATE TABLE user_identity(
+    provider text NOT NULL,
+    subject text NOT NULL,
+    user_id integer NOT NULL REFERENCES user_data(id) ON DELETE CASCADE,
+    email text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX user_identity_user_id_idx ON user_identity(user_id);
+
+CREATE TABLE audit_event(
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    actor_id integer,
+    action text NOT NULL,
+    target text NOT NULL DEFAULT '',
+    ip text NOT NULL DEFAULT '',
+    request_id text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_event_created_at_idx ON audit_event(created_at);
+CREATE INDEX audit_event_actor_idx ON audit_event(actor_id, created_at);
+CREATE INDEX audit_event_target_idx ON audit_event(target, created_at);
+CREATE INDEX audit_event_action_idx ON audit_event(action, created_at);
+
+CREATE TABLE data_export(
+    id text PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES user_data(id) ON DELETE CASCADE,
+    status text NOT NULL CHECK (status IN ('pending', 'ready', 'failed')),
+    file_path text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz
+);
+
+CREATE INDEX data_export_user_id_idx ON data_export(user_id, created_at);
+CREATE INDEX data_export_expires_at_idx ON data_export(expires_at);
+
+CREATE INDEX sight_rating_id_idx ON sight(rating DESC, id DESC);
+CREATE INDEX sight_name_id_idx ON sight(name, id);
+CREATE INDEX sight_city_id_idx ON sight(city_id);
+CREATE INDEX sight_country_id_idx ON sight(country_id);
+CREATE INDEX sight_category_idx ON sight(category);
+CREATE INDEX sight_archived_at_idx ON sight(archived_at) WHERE archived_at IS NOT NULL;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX sight_search_vector_idx ON sight USING gin(search_vector);
+CREATE INDEX sight_name_trgm_idx ON sight USING gin(name gin_trgm_ops);
+CREATE INDEX city_city_trgm_idx ON city USING gin(city gin_trgm_ops);
+CREATE INDEX country_country_trgm_idx ON country USING gin(country gin_trgm_ops);
+
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
+
+CREATE INDEX sight_earth_idx ON sight USING gist(ll_to_earth(latitude, longitude))
+    WHERE latitude IS NOT NULL AND longitude IS NOT NULL;
 
 
 -- +goose Down
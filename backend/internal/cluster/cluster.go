@@ -0,0 +1,137 @@
+// Группировка точек на карте по сетке в проекции web mercator:
+// ячейка - часть тайла на текущем зуме, поэтому на экране кластеры
+// примерно одного размера независимо от широты
+package cluster
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// Ячеек сетки на сторону тайла 256px - ячейка около 64px
+	CellsPerTile = 4
+	// С этого зума точки не группируются
+	MaxClusterZoom = 16
+	MaxZoom        = 22
+
+	// Предел широты web mercator
+	maxMercatorLat = 85.05112878
+	// Наибольший запрашиваемый прямоугольник - 16 тайлов (4096px) по каждой стороне:
+	// больше экрана не бывает, а на крупных зумах это ограничивает число точек
+	MaxViewportTiles = 16
+)
+
+// Point - точка на карте. Weight выбирает представителя кластера
+type Point struct {
+	ID     int
+	Lat    float64
+	Lon    float64
+	Weight float64
+}
+
+// Cluster - группа точек: центроид, количество и точка с наибольшим весом
+type Cluster struct {
+	Lat            float64
+	Lon            float64
+	Count          int
+	Representative Point
+}
+
+type cellKey struct {
+	x, y int
+}
+
+type cellAcc struct {
+	sumLat float64
+	// долгота усредняется через единичные векторы, иначе кластер у 180-го меридиана
+	// получил бы центр на другой стороне земли
+	sumSin, sumCos float64
+	count          int
+	best           Point
+}
+
+// Наибольший размах прямоугольника карты в градусах на зуме zoom, по долготе и по широте.
+// На мелких зумах - весь мир
+func MaxSpan(zoom int) float64 {
+	zoom = max(0, min(zoom, MaxZoom))
+	return math.Min(360, 360*MaxViewportTiles/float64(int(1)<<zoom))
+}
+
+// Координаты точки в проекции web mercator, обе в [0, 1)
+func project(lat, lon float64) (float64, float64) {
+	lat = math.Max(-maxMercatorLat, math.Min(maxMercatorLat, lat))
+	x := (lon + 180) / 360
+	sinLat := math.Sin(lat * math.Pi / 180)
+	y := 0.5 - math.Log((1+sinLat)/(1-sinLat))/(4*math.Pi)
+	return x, y
+}
+
+// Ячейка сетки для точки на зуме zoom
+func cellOf(p Point, zoom int) cellKey {
+	cells := float64(int(1)<<zoom) * CellsPerTile
+	x, y := project(p.Lat, p.Lon)
+	maxIndex := int(cells) - 1
+	return cellKey{
+		x: min(int(x*cells), maxIndex),
+		y: min(int(y*cells), maxIndex),
+	}
+}
+
+// Группирует точки по ячейкам сетки. Начиная с MaxClusterZoom каждая точка - свой кластер.
+// Кластеры упорядочены по убыванию количества точек, при равенстве - по ID представителя
+func Build(points []Point, zoom int) []Cluster {
+	zoom = max(0, min(zoom, MaxZoom))
+
+	var clusters []Cluster
+	if zoom >= MaxClusterZoom {
+		clusters = make([]Cluster, 0, len(points))
+		for _, p := range points {
+			clusters = append(clusters, Cluster{Lat: p.Lat, Lon: p.Lon, Count: 1, Representative: p})
+		}
+	} else {
+		cells := make(map[cellKey]*cellAcc)
+		for _, p := range points {
+			key := cellOf(p, zoom)
+			acc, ok := cells[key]
+			if !ok {
+				acc = &cellAcc{best: p}
+				cells[key] = acc
+			}
+			acc.sumLat += p.Lat
+			lonRad := p.Lon * math.Pi / 180
+			acc.sumSin += math.Sin(lonRad)
+			acc.sumCos += math.Cos(lonRad)
+			acc.count++
+			if better(p, acc.best) {
+				acc.best = p
+			}
+		}
+
+		clusters = make([]Cluster, 0, len(cells))
+		for _, acc := range cells {
+			clusters = append(clusters, Cluster{
+				Lat:            acc.sumLat / float64(acc.count),
+				Lon:            math.Atan2(acc.sumSin, acc.sumCos) * 180 / math.Pi,
+				Count:          acc.count,
+				Representative: acc.best,
+			})
+		}
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		return clusters[i].Representative.ID < clusters[j].Representative.ID
+	})
+	return clusters
+}
+
+// Больший вес, при равенстве - меньший ID, чтобы результат не зависел от порядка точек
+func better(p, best Point) bool {
+	if p.Weight != best.Weight {
+		return p.Weight > best.Weight
+	}
+	return p.ID < best.ID
+}
@@ -0,0 +1,173 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var moscow = []Point{
+	{ID: 1, Lat: 55.7520, Lon: 37.6175, Weight: 4.5},
+	{ID: 2, Lat: 55.7539, Lon: 37.6208, Weight: 4.9},
+	{ID: 3, Lat: 55.7415, Lon: 37.6208, Weight: 4.9},
+}
+
+var petersburg = Point{ID: 4, Lat: 59.9398, Lon: 30.3146, Weight: 4.8}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+		zoom   int
+		// ожидаемые кластеры по порядку: количество и ID представителя
+		counts          []int
+		representatives []int
+	}{
+		{
+			name:            "nearby points are grouped",
+			points:          append(append([]Point{}, moscow...), petersburg),
+			zoom:            5,
+			counts:          []int{3, 1},
+			representatives: []int{2, 4},
+		},
+		{
+			name:            "single point per cell",
+			points:          append(append([]Point{}, moscow[:1]...), petersburg),
+			zoom:            10,
+			counts:          []int{1, 1},
+			representatives: []int{1, 4},
+		},
+		{
+			name:            "high zoom passes points through",
+			points:          moscow,
+			zoom:            MaxClusterZoom,
+			counts:          []int{1, 1, 1},
+			representatives: []int{1, 2, 3},
+		},
+		{
+			name:            "zoom above max is clamped",
+			points:          moscow,
+			zoom:            MaxZoom + 5,
+			counts:          []int{1, 1, 1},
+			representatives: []int{1, 2, 3},
+		},
+		{
+			name: "cells do not wrap around the antimeridian",
+			points: []Point{
+				{ID: 1, Lat: 65, Lon: 179.9},
+				{ID: 2, Lat: 65, Lon: -179.9},
+			},
+			zoom:            0,
+			counts:          []int{1, 1},
+			representatives: []int{1, 2},
+		},
+		{
+			name: "edge coordinates are clamped into the grid",
+			points: []Point{
+				{ID: 1, Lat: 90, Lon: 180},
+				{ID: 2, Lat: 89, Lon: 179},
+				{ID: 3, Lat: -90, Lon: -180},
+			},
+			zoom:            2,
+			counts:          []int{2, 1},
+			representatives: []int{1, 3},
+		},
+		{
+			name:   "no points",
+			points: nil,
+			zoom:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := Build(tt.points, tt.zoom)
+			require.Len(t, clusters, len(tt.counts))
+			for i, c := range clusters {
+				assert.Equal(t, tt.counts[i], c.Count, "cluster %d", i)
+				assert.Equal(t, tt.representatives[i], c.Representative.ID, "cluster %d", i)
+			}
+		})
+	}
+}
+
+func TestBuildCentroid(t *testing.T) {
+	tests := []struct {
+		name     string
+		points   []Point
+		lat, lon float64
+	}{
+		{name: "mean of coordinates", points: moscow, lat: 55.7491, lon: 37.6197},
+		{name: "single point", points: []Point{petersburg}, lat: petersburg.Lat, lon: petersburg.Lon},
+		{
+			// в одной ячейке у 180-го меридиана центр остаётся рядом с ним
+			name:   "near the antimeridian",
+			points: []Point{{ID: 1, Lat: 65, Lon: 179.9}, {ID: 2, Lat: 65, Lon: 179.7}},
+			lat:    65,
+			lon:    179.8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := Build(tt.points, 0)
+			require.Len(t, clusters, 1)
+			assert.InDelta(t, tt.lat, clusters[0].Lat, 1e-3)
+			assert.InDelta(t, tt.lon, clusters[0].Lon, 1e-3)
+		})
+	}
+}
+
+func TestBuildRepresentative(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+		want   int
+	}{
+		{name: "highest weight", points: []Point{{ID: 1, Weight: 3}, {ID: 2, Weight: 5}, {ID: 3, Weight: 4}}, want: 2},
+		{name: "lowest id on equal weight", points: []Point{{ID: 9, Weight: 5}, {ID: 4, Weight: 5}}, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := Build(tt.points, 0)
+			require.Len(t, clusters, 1)
+			assert.Equal(t, tt.want, clusters[0].Representative.ID)
+		})
+	}
+}
+
+func TestBuildIsOrderIndependent(t *testing.T) {
+	points := append(append([]Point{}, moscow...), petersburg)
+	reversed := []Point{petersburg, moscow[2], moscow[1], moscow[0]}
+	assert.Equal(t, Build(points, 3), Build(reversed, 3))
+}
+
+func TestCellOf(t *testing.T) {
+	cells := 4 * CellsPerTile
+	tests := []struct {
+		name string
+		p    Point
+		x, y int
+	}{
+		{name: "north-east corner", p: Point{Lat: 90, Lon: 180}, x: cells - 1, y: 0},
+		{name: "south-west corner", p: Point{Lat: -90, Lon: -180}, x: 0, y: cells - 1},
+		{name: "center", p: Point{Lat: 0, Lon: 0}, x: cells / 2, y: cells / 2},
+		{name: "just west of the prime meridian", p: Point{Lat: 0.001, Lon: -0.001}, x: cells/2 - 1, y: cells/2 - 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, cellKey{x: tt.x, y: tt.y}, cellOf(tt.p, 2))
+		})
+	}
+}
+
+func TestMaxSpan(t *testing.T) {
+	assert.Equal(t, 360.0, MaxSpan(0))
+	assert.Equal(t, 360.0, MaxSpan(4))
+	assert.Equal(t, 180.0, MaxSpan(5))
+	assert.InDelta(t, 0.0879, MaxSpan(MaxClusterZoom), 1e-4)
+	assert.Equal(t, MaxSpan(MaxZoom), MaxSpan(MaxZoom+1))
+}
@@ -14,6 +14,19 @@ type Config struct {
 	StoragePath string `yaml:"storage_path" env-requiered:"true"`
 	HTTPServer  `yaml:"http_server"`
 	Dsn         `yaml:"dsn"`
+	Session     `yaml:"session"`
+	Admin       `yaml:"admin"`
+	LoginLimit  `yaml:"login_limit"`
+	Mail        `yaml:"mail"`
+	TwoFactor   `yaml:"two_factor"`
+	OIDC        `yaml:"oidc"`
+	// Требования к паролю и параметры хэширования
+	PasswordPolicy  `yaml:"password_policy"`
+	PasswordHashing `yaml:"password_hashing"`
+	Export          `yaml:"export"`
+	AccountDeletion `yaml:"account_deletion"`
+	Search          `yaml:"search"`
+	GeoExport       `yaml:"geo_export"`
 	// Путь, куда будут загружаться аватарки
 	FileUploadPath string `yaml:"FILE_UPLOAD_PATH" env-default:"../../../frontend/public/avatars/"`
 }
@@ -34,8 +47,155 @@ type Dsn struct {
 	DBname   string `yaml:"DB_NAME"`
 }
 
+type Session struct {
+	// Ключи для подписи и шифрования куки сессии (hex), одинаковые на всех инстансах.
+	// Обязательны везде, кроме env: local
+	HashKey  string `yaml:"hash_key" env:"SESSION_HASH_KEY"`
+	BlockKey string `yaml:"block_key" env:"SESSION_BLOCK_KEY"`
+	// Хранилище сессий: "postgres" или "memory"
+	Store string `yaml:"store" env:"SESSION_STORE" env-default:"postgres"`
+	// Абсолютное время жизни сессии и время простоя, после которого она истекает
+	Lifetime    time.Duration `yaml:"lifetime" env-default:"720h"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"24h"`
+	// Как часто фоновая горутина чистит истекшие сессии
+	SweepInterval time.Duration `yaml:"sweep_interval" env-default:"10m"`
+	// Атрибуты куки сессии, в проде должно быть cookie_secure: true
+	CookieSecure   bool   `yaml:"cookie_secure" env:"SESSION_COOKIE_SECURE"`
+	CookieHTTPOnly bool   `yaml:"cookie_http_only" env-default:"true"`
+	CookieSameSite string `yaml:"cookie_same_site" env-default:"lax"`
+}
+
+// Первый администратор, создаётся (или повышается) при старте сервиса.
+// Email можно переопределить флагом -admin-email, пароль - только ADMIN_PASSWORD или конфигом
+type Admin struct {
+	Email    string `yaml:"email" env:"ADMIN_EMAIL"`
+	Password string `yaml:"password" env:"ADMIN_PASSWORD"`
+}
+
+// Защита входа от перебора паролей
+type LoginLimit struct {
+	// Сколько неудачных попыток подряд разрешено до блокировки
+	EmailAttempts int `yaml:"email_attempts" env-default:"5"`
+	IPAttempts    int `yaml:"ip_attempts" env-default:"20"`
+	// Первая блокировка, с каждой следующей ошибкой удваивается до MaxLockout
+	BaseLockout time.Duration `yaml:"base_lockout" env-default:"30s"`
+	MaxLockout  time.Duration `yaml:"max_lockout" env-default:"15m"`
+	// Через сколько после последней ошибки счётчик обнуляется
+	ResetAfter time.Duration `yaml:"reset_after" env-default:"1h"`
+	// Хранилище счётчиков: "postgres" или "memory"
+	Store string `yaml:"store" env-default:"postgres"`
+	// Как часто удаляются счётчики, которые уже обнулились по ResetAfter
+	SweepInterval time.Duration `yaml:"sweep_interval" env-default:"10m"`
+}
+
+// Отправка писем: driver "smtp" или "outbox" (письма пишутся в OutboxDir).
+// Без driver outbox используется только в env: local, иначе сервис не стартует
+type Mail struct {
+	Driver    string `yaml:"driver" env:"MAIL_DRIVER"`
+	From      string `yaml:"from" env-default:"noreply@localhost"`
+	OutboxDir string `yaml:"outbox_dir" env-default:"./outbox"`
+	SMTP      SMTP   `yaml:"smtp"`
+	// Адрес бэкенда, на который ведёт ссылка подтверждения почты
+	BaseURL string `yaml:"base_url" env-default:"http://localhost:8080"`
+	// Адрес фронтенда: ссылка сброса пароля открывает страницу с формой нового пароля
+	FrontendURL string `yaml:"frontend_url" env:"MAIL_FRONTEND_URL" env-default:"http://localhost:3000"`
+	// Время жизни ссылки подтверждения почты и минимальный интервал между письмами
+	VerificationTTL time.Duration `yaml:"verification_ttl" env-default:"24h"`
+	ResendInterval  time.Duration `yaml:"resend_interval" env-default:"1m"`
+	// Время жизни ссылки для сброса пароля
+	ResetTTL time.Duration `yaml:"reset_ttl" env-default:"1h"`
+}
+
+// Двухфакторная аутентификация (TOTP)
+type TwoFactor struct {
+	// Название сервиса в приложении-аутентификаторе
+	Issuer string `yaml:"issuer" env-default:"homework_ipl"`
+	// Сколько действует challenge между вводом пароля и вводом кода
+	ChallengeTTL time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+}
+
+// Вход через сторонних провайдеров (OpenID Connect)
+type OIDC struct {
+	// Адрес бэкенда, провайдер возвращает пользователя на RedirectBaseURL/oauth/{provider}/callback
+	RedirectBaseURL string `yaml:"redirect_base_url" env-default:"http://localhost:8080"`
+	// Куда отправить пользователя после входа
+	SuccessURL string `yaml:"success_url" env-default:"http://localhost:3000/"`
+	// Ключ - имя провайдера в адресе /oauth/{provider}/login
+	Providers map[string]OIDCProvider `yaml:"providers"`
+}
+
+type OIDCProvider struct {
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+// Требования к новым паролям (при регистрации, смене и сбросе пароля).
+// Длина считается в символах, а не в байтах
+type PasswordPolicy struct {
+	MinLength     int  `yaml:"min_length" env-default:"8"`
+	MaxLength     int  `yaml:"max_length" env-default:"128"`
+	RequireUpper  bool `yaml:"require_upper" env-default:"true"`
+	RequireLower  bool `yaml:"require_lower" env-default:"true"`
+	RequireDigit  bool `yaml:"require_digit" env-default:"true"`
+	RequireSymbol bool `yaml:"require_symbol" env-default:"false"`
+	// Отклонять пароли из встроенного списка распространённых
+	RejectCommon bool `yaml:"reject_common" env-default:"true"`
+}
+
+// Хэширование паролей: algorithm "argon2id" или "bcrypt".
+// Хэши с другим алгоритмом или параметрами пересчитываются при входе
+type PasswordHashing struct {
+	Algorithm string `yaml:"algorithm" env-default:"argon2id"`
+	// Память в KiB, число проходов и потоков argon2id
+	Argon2Memory  uint32 `yaml:"argon2_memory" env-default:"19456"`
+	Argon2Time    uint32 `yaml:"argon2_time" env-default:"2"`
+	Argon2Threads uint8  `yaml:"argon2_threads" env-default:"1"`
+	BcryptCost    int    `yaml:"bcrypt_cost" env-default:"12"`
+}
+
+// Выгрузка персональных данных пользователя (/profile/{id}/export)
+type Export struct {
+	// Куда складываются готовые архивы
+	Dir string `yaml:"dir" env-default:"./exports"`
+	// Сколько архив доступен для скачивания
+	TTL time.Duration `yaml:"ttl" env-default:"24h"`
+	// Как часто удаляются истекшие архивы
+	SweepInterval time.Duration `yaml:"sweep_interval" env-default:"1h"`
+}
+
+// Удаление аккаунта пользователем: до истечения GracePeriod аккаунт
+// восстанавливается входом, потом личные данные удаляются насовсем
+type AccountDeletion struct {
+	GracePeriod time.Duration `yaml:"grace_period" env-default:"720h"`
+	// Как часто фоновая задача удаляет аккаунты с истекшим периодом
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Подсказки поиска (/search/suggest)
+type Search struct {
+	// Сколько запросов держать в кэше подсказок, 0 - без кэша
+	SuggestCacheSize int           `yaml:"suggest_cache_size" env-default:"1000"`
+	SuggestCacheTTL  time.Duration `yaml:"suggest_cache_ttl" env-default:"5m"`
+}
+
+// Выгрузка в GeoJSON и KML
+type GeoExport struct {
+	// Адрес, с которого отдаются картинки (фронтенд), из него и пути собирается image_url
+	PublicURL string `yaml:"public_url" env:"GEO_EXPORT_PUBLIC_URL" env-default:"http://localhost:3000"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"587"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password" env:"MAIL_SMTP_PASSWORD"`
+}
+
 func LoadConfig() (*Config, error) {
-	if err := godotenv.Load(); err != nil {
+	// .env необязателен: переменные могут прийти из окружения (docker, тесты)
+	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
 		return nil, errors.Wrap(err, "error loading .env file")
 	}
 
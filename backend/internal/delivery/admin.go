@@ -0,0 +1,240 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/http-server/server/db"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/httputils"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/middle"
+	"homework_ipl/utils/wrapper"
+)
+
+// Хэндлеры админки, права проверяет middle.RequirePermission в роутере
+type AdminHandler struct{}
+
+type AdminUserResponse struct {
+	ID    int    `json:"id"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type AdminUsersResponse struct {
+	Users []AdminUserResponse `json:"users"`
+}
+
+var (
+	errGetUsers = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed getting users",
+	}
+	errSetRole = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed setting role",
+	}
+	errInvalidRole = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "invalid role",
+	}
+	errOwnRole = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "cannot change own role",
+	}
+	errUserNotFound = errors.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "user not found",
+	}
+	errGetCities = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed getting cities",
+	}
+	errCreateCity = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed creating city",
+	}
+	errDeleteCity = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed deleting city",
+	}
+	errCityInUse = errors.HttpError{
+		Code:    http.StatusConflict,
+		Message: "city has sights",
+	}
+	errCityNotFound = errors.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "city not found",
+	}
+	errDeleteSight = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed deleting sight",
+	}
+	errSightNotFound = errors.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "sight not found",
+	}
+)
+
+func (h *AdminHandler) GetUsers(ctx context.Context, _ entities.User) (AdminUsersResponse, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	userRepo := repository.NewUserRepo(db)
+	users, err := userRepo.GetUsers()
+	if err != nil {
+		return AdminUsersResponse{}, errGetUsers
+	}
+
+	response := AdminUsersResponse{Users: []AdminUserResponse{}}
+	for _, u := range users {
+		response.Users = append(response.Users, AdminUserResponse{ID: u.ID, Email: u.Email, Role: u.Role})
+	}
+	return response, nil
+}
+
+// Смена роли. Все сессии пользователя завершаются, чтобы новая роль
+// применилась сразу, а не после истечения старой сессии
+func (h *AdminHandler) SetUserRole(ctx context.Context, requestData entities.UserRole) (AdminUserResponse, error) {
+	logger := logger.Logger()
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	userID, err := strconv.Atoi(wrapper.GetPathParamsFromCtx(ctx)["id"])
+	if err != nil {
+		logger.Error("Error while converting string to int", "error", err)
+		return AdminUserResponse{}, errParsing
+	}
+	if err = requestData.Validate(); err != nil {
+		return AdminUserResponse{}, errInvalidRole
+	}
+	if currentID, _ := middle.CurrentUser(ctx); currentID == userID {
+		return AdminUserResponse{}, errOwnRole
+	}
+
+	userRepo := repository.NewUserRepo(db)
+	found, err := userRepo.SetUserRole(userID, requestData.Role)
+	if err != nil {
+		return AdminUserResponse{}, errSetRole
+	}
+	if !found {
+		return AdminUserResponse{}, errUserNotFound
+	}
+
+	if request, ok := httputils.HttpRequest(ctx); ok {
+		adminID, _ := middle.CurrentUser(ctx)
+		usecase.Audit(ctx, request, adminID, entities.AuditRoleChange, entities.AuditUser(userID))
+	}
+
+	if err = usecase.RevokeUserSessions(ctx, userID); err != nil {
+		logger.Error("Error while revoking sessions", "error", err)
+		return AdminUserResponse{}, errRevokeSession
+	}
+
+	return AdminUserResponse{ID: userID, Role: requestData.Role}, nil
+}
+
+func (h *AdminHandler) DeleteUser(ctx context.Context, _ entities.User) (AdminUserResponse, error) {
+	logger := logger.Logger()
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	userID, err := strconv.Atoi(wrapper.GetPathParamsFromCtx(ctx)["id"])
+	if err != nil {
+		logger.Error("Error while converting string to int", "error", err)
+		return AdminUserResponse{}, errParsing
+	}
+	if currentID, _ := middle.CurrentUser(ctx); currentID == userID {
+		return AdminUserResponse{}, errOwnRole
+	}
+
+	if err = usecase.RevokeUserSessions(ctx, userID); err != nil {
+		logger.Error("Error while revoking sessions", "error", err)
+		return AdminUserResponse{}, errRevokeSession
+	}
+
+	// удаление из админки окончательное, без периода восстановления
+	deletion := usecase.NewAccountDeletion(repository.NewUserRepo(db))
+	if err = deletion.Purge(ctx, userID); err != nil {
+		logger.Error("Error while purging account", "error", err)
+		return AdminUserResponse{}, errDeleteProfile
+	}
+
+	if request, ok := httputils.HttpRequest(ctx); ok {
+		adminID, _ := middle.CurrentUser(ctx)
+		usecase.Audit(ctx, request, adminID, entities.AuditProfileDelete, entities.AuditUser(userID))
+	}
+
+	return AdminUserResponse{ID: userID}, nil
+}
+
+func (h *AdminHandler) GetCities(ctx context.Context, _ entities.City) (entities.Cities, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	cityRepo := repository.NewCityRepo(db)
+	cities, err := cityRepo.GetCities()
+	if err != nil {
+		return entities.Cities{}, errGetCities
+	}
+
+	return entities.Cities{City: cities}, nil
+}
+
+func (h *AdminHandler) CreateCity(ctx context.Context, requestData entities.City) (entities.City, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	if err = requestData.Validate(); err != nil {
+		return entities.City{}, errors.HttpError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	cityRepo := repository.NewCityRepo(db)
+	city, err := cityRepo.CreateCity(requestData)
+	if err != nil {
+		return entities.City{}, errCreateCity
+	}
+	usecase.ClearSuggestions()
+
+	return city, nil
+}
+
+func (h *AdminHandler) DeleteCity(ctx context.Context, _ entities.City) (entities.City, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	cityID, err := strconv.Atoi(wrapper.GetPathParamsFromCtx(ctx)["id"])
+	if err != nil {
+		logger.Logger().Error("Error while converting string to int", "error", err)
+		return entities.City{}, errParsing
+	}
+
+	cityRepo := repository.NewCityRepo(db)
+	err = cityRepo.DeleteCity(cityID)
+	switch err {
+	case nil:
+		usecase.ClearSuggestions()
+		return entities.City{ID: cityID}, nil
+	case repository.ErrNotFound:
+		return entities.City{}, errCityNotFound
+	case repository.ErrCityInUse:
+		return entities.City{}, errCityInUse
+	}
+	return entities.City{}, errDeleteCity
+}
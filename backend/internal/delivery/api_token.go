@@ -0,0 +1,102 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/wrapper"
+)
+
+// Персональные API-токены профиля /profile/{id}/tokens
+type APITokenHandler struct{}
+
+// Ответ на создание: сам токен виден только здесь
+type CreatedAPITokenResponse struct {
+	entities.APIToken
+	Token string `json:"token"`
+}
+
+var (
+	errGetAPITokens = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed getting api tokens",
+	}
+	errCreateAPIToken = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed creating api token",
+	}
+	errRevokeAPIToken = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed revoking api token",
+	}
+	errAPITokenNotFound = errors.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "api token not found",
+	}
+)
+
+func (h *APITokenHandler) GetTokens(ctx context.Context, _ entities.APIToken) (entities.APITokens, error) {
+	userID, err := requirePathProfileOwner(ctx)
+	if err != nil {
+		return entities.APITokens{}, err
+	}
+
+	tokens, err := usecase.ListAPITokens(ctx, userID)
+	if err != nil {
+		return entities.APITokens{}, errGetAPITokens
+	}
+	if tokens == nil {
+		tokens = []entities.APIToken{}
+	}
+
+	return entities.APITokens{Tokens: tokens}, nil
+}
+
+func (h *APITokenHandler) CreateToken(ctx context.Context, requestData entities.APITokenRequest) (CreatedAPITokenResponse, error) {
+	userID, err := requirePathProfileOwner(ctx)
+	if err != nil {
+		return CreatedAPITokenResponse{}, err
+	}
+	if err = requestData.Validate(); err != nil {
+		return CreatedAPITokenResponse{}, errors.HttpError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	token, created, err := usecase.CreateAPIToken(ctx, userID, requestData)
+	if err == usecase.ErrAPITokenExpiry {
+		return CreatedAPITokenResponse{}, errors.HttpError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if err != nil {
+		logger.Logger().Error("Error while creating api token", "error", err)
+		return CreatedAPITokenResponse{}, errCreateAPIToken
+	}
+
+	return CreatedAPITokenResponse{APIToken: created, Token: token}, nil
+}
+
+func (h *APITokenHandler) RevokeToken(ctx context.Context, _ entities.APIToken) (entities.APIToken, error) {
+	userID, err := requirePathProfileOwner(ctx)
+	if err != nil {
+		return entities.APIToken{}, err
+	}
+
+	tokenID, err := strconv.Atoi(wrapper.GetPathParamsFromCtx(ctx)["tid"])
+	if err != nil {
+		logger.Logger().Error("Error while converting string to int", "error", err)
+		return entities.APIToken{}, errParsing
+	}
+
+	err = usecase.RevokeAPIToken(ctx, userID, tokenID)
+	if err == usecase.ErrAPITokenNotFound {
+		return entities.APIToken{}, errAPITokenNotFound
+	}
+	if err != nil {
+		return entities.APIToken{}, errRevokeAPIToken
+	}
+
+	return entities.APIToken{ID: tokenID}, nil
+}
@@ -0,0 +1,74 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/wrapper"
+)
+
+var (
+	errInvalidAuditFilter = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "invalid audit filter",
+	}
+	errGetAuditEvents = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed getting audit events",
+	}
+)
+
+// Журнал аудита: /admin/audit?user_id=5&action=login_failed&from=2024-05-01T00:00:00Z&to=...&limit=100
+// Время в формате RFC 3339, from включительно, to - нет
+func (h *AdminHandler) GetAuditEvents(ctx context.Context, _ entities.AuditEvent) (entities.AuditEvents, error) {
+	filter, err := parseAuditFilter(wrapper.GetQueryParamsFromCtx(ctx))
+	if err != nil {
+		logger.Logger().Error("Error while parsing audit filter", "error", err)
+		return entities.AuditEvents{}, errInvalidAuditFilter
+	}
+
+	events, err := usecase.ListAuditEvents(ctx, filter)
+	if err != nil {
+		return entities.AuditEvents{}, errGetAuditEvents
+	}
+	if events == nil {
+		events = []entities.AuditEvent{}
+	}
+
+	return entities.AuditEvents{Events: events}, nil
+}
+
+func parseAuditFilter(queryParams map[string]string) (entities.AuditFilter, error) {
+	var filter entities.AuditFilter
+	var err error
+
+	if value := queryParams["user_id"]; value != "" {
+		if filter.UserID, err = strconv.Atoi(value); err != nil {
+			return entities.AuditFilter{}, err
+		}
+	}
+	if value := queryParams["limit"]; value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			return entities.AuditFilter{}, err
+		}
+	}
+	if value := queryParams["from"]; value != "" {
+		if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+			return entities.AuditFilter{}, err
+		}
+	}
+	if value := queryParams["to"]; value != "" {
+		if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+			return entities.AuditFilter{}, err
+		}
+	}
+	filter.Action = queryParams["action"]
+
+	return filter, nil
+}
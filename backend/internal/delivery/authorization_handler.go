@@ -2,7 +2,10 @@ package delivery
 
 import (
 	"context"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"homework_ipl/internal/entities"
 	"homework_ipl/internal/http-server/server/db"
@@ -11,6 +14,7 @@ import (
 	"homework_ipl/utils/errors"
 	"homework_ipl/utils/httputils"
 	"homework_ipl/utils/logger"
+	"homework_ipl/utils/middle"
 )
 
 type AuthorizationHandler struct{}
@@ -18,6 +22,9 @@ type AuthorizationHandler struct{}
 type UserResponse struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
+	// Пароль верный, но нужен код 2FA: его вместе с challenge отправляют на /login/2fa
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	Challenge         string `json:"challenge,omitempty"`
 }
 
 var (
@@ -37,6 +44,14 @@ var (
 		Code:    http.StatusUnauthorized,
 		Message: "session is not set",
 	}
+	errTooManyAttempts = errors.HttpError{
+		Code:    http.StatusTooManyRequests,
+		Message: "too many login attempts",
+	}
+	errAccountDeleted = errors.HttpError{
+		Code:    http.StatusForbidden,
+		Message: "account has been deleted",
+	}
 )
 
 // Хэндлер авторизации
@@ -44,6 +59,25 @@ func (h *AuthorizationHandler) Authorize(ctx context.Context, requestData entiti
 	username := requestData.Email
 	password := requestData.Passwrd
 
+	responseWriter, ok := httputils.ContextWriter(ctx)
+	if !ok {
+		return UserResponse{}, errInternal
+	}
+	request, ok := httputils.HttpRequest(ctx)
+	if !ok {
+		return UserResponse{}, errInternal
+	}
+	ip := usecase.ClientIP(request)
+
+	// проверка блокировки до хэширования пароля, чтобы перебор не грузил процессор
+	retryAfter, err := usecase.Logins.RetryAfter(ctx, username, ip)
+	if err != nil {
+		return UserResponse{}, errInternal
+	}
+	if retryAfter > 0 {
+		return UserResponse{}, tooManyAttempts(responseWriter, retryAfter)
+	}
+
 	db, err := db.GetPostgres()
 	if err != nil {
 		logger.Logger().Error(err.Error())
@@ -56,19 +90,46 @@ func (h *AuthorizationHandler) Authorize(ctx context.Context, requestData entiti
 
 	UserRepo := userRep.NewUserRepo(db)
 	user, err := UserRepo.AuthorizeUser(dataStr)
-	if err != nil {
+	if err != nil || user.ID == 0 {
+		usecase.Audit(ctx, request, 0, entities.AuditLoginFailed, entities.AuditEmail(username))
+		lockout, limitErr := usecase.Logins.RegisterFailure(ctx, username, ip)
+		if limitErr != nil {
+			logger.Logger().Error("Error while registering login failure", "error", limitErr)
+		}
+		if lockout > 0 {
+			return UserResponse{}, tooManyAttempts(responseWriter, lockout)
+		}
 		return UserResponse{}, errLoginUser
 	}
 
-	responseWriter, ok := httputils.ContextWriter(ctx)
-	if !ok {
+	// при включённой 2FA сессия выдаётся только после проверки кода
+	twoFactor := usecase.NewTwoFactor(userRep.NewTOTPRepo(db))
+	enabled, err := twoFactor.Enabled(ctx, user.ID)
+	if err != nil {
 		return UserResponse{}, errInternal
 	}
+	if enabled {
+		challenge, err := twoFactor.NewChallenge(user.ID)
+		if err != nil {
+			logger.Logger().Error("Error while creating 2FA challenge", "error", err)
+			return UserResponse{}, errInternal
+		}
+		return UserResponse{TwoFactorRequired: true, Challenge: challenge}, nil
+	}
+
+	if err = restoreAccount(ctx, request, UserRepo, user.ID); err != nil {
+		return UserResponse{}, err
+	}
 
-	err = usecase.SetSession(responseWriter, user.ID)
+	if err = usecase.Logins.RegisterSuccess(ctx, username); err != nil {
+		logger.Logger().Error("Error while resetting login failures", "error", err)
+	}
+
+	err = usecase.SetSession(responseWriter, request, user.ID, user.Role)
 	if err != nil {
 		return UserResponse{}, errSetSession
 	}
+	usecase.Audit(ctx, request, user.ID, entities.AuditLogin, entities.AuditUser(user.ID))
 
 	userResponse := UserResponse{
 		ID:       user.ID,
@@ -78,6 +139,31 @@ func (h *AuthorizationHandler) Authorize(ctx context.Context, requestData entiti
 	return userResponse, nil
 }
 
+// Вход в удалённый аккаунт восстанавливает его, если период восстановления ещё не истёк.
+// Вызывается после всех проверок (пароль, 2FA), прямо перед выдачей сессии
+func restoreAccount(ctx context.Context, r *http.Request, store usecase.AccountStore, userID int) error {
+	restored, err := usecase.NewAccountDeletion(store).Restore(ctx, userID)
+	if err == usecase.ErrAccountDeleted {
+		return errAccountDeleted
+	}
+	if err != nil {
+		logger.Logger().Error("Error while restoring account", "error", err)
+		return errInternal
+	}
+
+	if restored {
+		usecase.Audit(ctx, r, userID, entities.AuditAccountRestore, entities.AuditUser(userID))
+	}
+	return nil
+}
+
+// 429 с заголовком Retry-After (в секундах, с округлением вверх)
+func tooManyAttempts(w http.ResponseWriter, retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	return errTooManyAttempts
+}
+
 // Выход
 func (h *AuthorizationHandler) LogOut(ctx context.Context, requestData entities.User) (UserResponse, error) {
 	request, ok := httputils.HttpRequest(ctx)
@@ -90,9 +176,7 @@ func (h *AuthorizationHandler) LogOut(ctx context.Context, requestData entities.
 		return UserResponse{}, errInternal
 	}
 
-	userID := usecase.GetSession(request)
-
-	if userID == 0 {
+	if _, ok := middle.CurrentUser(ctx); !ok {
 		return UserResponse{}, errSessionNotSet
 	}
 
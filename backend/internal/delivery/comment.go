@@ -8,7 +8,9 @@ import (
 	"homework_ipl/internal/entities"
 	"homework_ipl/internal/http-server/server/db"
 	sightRep "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/usecase"
 	"homework_ipl/utils/errors"
+	"homework_ipl/utils/httputils"
 	"homework_ipl/utils/logger"
 	"homework_ipl/utils/wrapper"
 )
@@ -48,10 +50,16 @@ func (h *CommentHandler) CreateComment(ctx context.Context, requestData entities
 		return entities.Comment{}, errParsing
 	}
 
+	// автор комментария - текущий пользователь, а не userID из тела запроса
+	userID, err := requireVerifiedUser(ctx, sightRep.NewUserRepo(db))
+	if err != nil {
+		return entities.Comment{}, err
+	}
+
 	dataStr := make(map[string]string)
 	dataInt := make(map[string]int)
 
-	dataInt["userID"] = requestData.UserID
+	dataInt["userID"] = userID
 	dataInt["sightID"] = sightID
 	dataInt["rating"] = requestData.Rating
 
@@ -81,6 +89,11 @@ func (h *CommentHandler) EditComment(ctx context.Context, requestData entities.C
 		return entities.Comment{}, errParsing
 	}
 
+	sightsRepo := sightRep.NewSightRepo(db)
+	if _, err = requireCommentOwner(ctx, sightsRepo, commentID); err != nil {
+		return entities.Comment{}, err
+	}
+
 	dataStr := make(map[string]string)
 	dataInt := make(map[string]int)
 
@@ -88,7 +101,6 @@ func (h *CommentHandler) EditComment(ctx context.Context, requestData entities.C
 	dataInt["rating"] = requestData.Rating
 	dataStr["feedback"] = requestData.Feedback
 
-	sightsRepo := sightRep.NewSightRepo(db)
 	err = sightsRepo.EditCommentByCommentID(dataStr, dataInt)
 
 	if err != nil {
@@ -112,15 +124,24 @@ func (h *CommentHandler) DeleteComment(ctx context.Context, requestData entities
 		return entities.Comment{}, errParsing
 	}
 
+	sightsRepo := sightRep.NewSightRepo(db)
+	userID, err := requireCommentDeleter(ctx, sightsRepo, commentID)
+	if err != nil {
+		return entities.Comment{}, err
+	}
+
 	dataInt := make(map[string]int)
 	dataInt["id"] = commentID
 
-	sightsRepo := sightRep.NewSightRepo(db)
 	err = sightsRepo.DeleteCommentByCommentID(dataInt)
 
 	if err != nil {
 		return entities.Comment{}, errDeleteComment
 	}
 
+	if request, ok := httputils.HttpRequest(ctx); ok {
+		usecase.Audit(ctx, request, userID, entities.AuditCommentDelete, entities.AuditComment(commentID))
+	}
+
 	return entities.Comment{}, nil
 }
@@ -0,0 +1,38 @@
+package delivery
+
+import (
+	"context"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/httputils"
+)
+
+type CSRFHandler struct{}
+
+type CSRFResponse struct {
+	Token string `json:"csrf_token"`
+}
+
+// Выдаёт CSRF-токен для текущей сессии, фронт кладёт его в заголовок X-CSRF-Token
+func (h *CSRFHandler) GetToken(ctx context.Context, _ entities.User) (CSRFResponse, error) {
+	r, ok := httputils.HttpRequest(ctx)
+	if !ok {
+		return CSRFResponse{}, errInternal
+	}
+
+	if _, err := requireUser(ctx); err != nil {
+		return CSRFResponse{}, err
+	}
+
+	token, ok := usecase.CSRFToken(r)
+	if !ok {
+		return CSRFResponse{}, errSessionNotSet
+	}
+
+	if w, ok := httputils.ContextWriter(ctx); ok {
+		w.Header().Set(usecase.CSRFHeader, token)
+	}
+
+	return CSRFResponse{Token: token}, nil
+}
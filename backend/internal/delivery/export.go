@@ -0,0 +1,128 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/http-server/server/db"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/wrapper"
+)
+
+// Выгрузка персональных данных /profile/{id}/export: архив собирается в фоне,
+// статус опрашивается по айди выгрузки, скачать архив можно до истечения ссылки
+type ExportHandler struct{}
+
+var (
+	errStartExport = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed starting export",
+	}
+	errGetExport = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed getting export",
+	}
+	errExportNotFound = errors.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "export not found",
+	}
+	errExportExpired = errors.HttpError{
+		Code:    http.StatusGone,
+		Message: "export has expired",
+	}
+	errExportNotReady = errors.HttpError{
+		Code:    http.StatusConflict,
+		Message: "export is not ready yet",
+	}
+)
+
+func (h *ExportHandler) StartExport(ctx context.Context, _ entities.DataExport) (entities.DataExport, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	userID, err := requirePathProfileOwner(ctx)
+	if err != nil {
+		return entities.DataExport{}, err
+	}
+
+	export, err := usecase.StartExport(ctx, repository.NewExportRepo(db), userID)
+	if err != nil {
+		logger.Logger().Error("Error while starting export", "error", err)
+		return entities.DataExport{}, errStartExport
+	}
+
+	return export, nil
+}
+
+func (h *ExportHandler) GetExport(ctx context.Context, _ entities.DataExport) (entities.DataExport, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	userID, err := requirePathProfileOwner(ctx)
+	if err != nil {
+		return entities.DataExport{}, err
+	}
+
+	export, err := usecase.GetExport(ctx, repository.NewExportRepo(db), userID, wrapper.GetPathParamsFromCtx(ctx)["eid"])
+	if err != nil {
+		return entities.DataExport{}, exportError(err)
+	}
+
+	return export, nil
+}
+
+// Архив отдаётся как есть, поэтому хэндлер без wrapper
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	logger := logger.Logger()
+	pathParams := wrapper.GetPathParams(r)
+
+	profileID, err := strconv.Atoi(pathParams["id"])
+	if err != nil {
+		errors.WriteHttpError(errParsing, w)
+		return
+	}
+	userID, err := requireProfileOwner(r.Context(), profileID)
+	if err != nil {
+		errors.WriteHttpError(err, w)
+		return
+	}
+
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	file, export, err := usecase.OpenExport(r.Context(), repository.NewExportRepo(db), userID, pathParams["eid"])
+	if err != nil {
+		errors.WriteHttpError(exportError(err), w)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="export-`+strconv.Itoa(userID)+`.zip"`)
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, "", export.CreatedAt, file)
+}
+
+func exportError(err error) error {
+	switch err {
+	case usecase.ErrExportNotFound:
+		return errExportNotFound
+	case usecase.ErrExportExpired:
+		return errExportExpired
+	case usecase.ErrExportNotReady:
+		return errExportNotReady
+	}
+	logger.Logger().Error("Error while getting export", "error", err)
+	return errGetExport
+}
@@ -56,10 +56,16 @@ func (h JourneyHandler) CreateJourney(ctx context.Context, requestData entities.
 		logger.Logger().Error(err.Error())
 	}
 
+	// владелец поездки - текущий пользователь, а не userID из тела запроса
+	userID, err := requireVerifiedUser(ctx, sightRep.NewUserRepo(db))
+	if err != nil {
+		return entities.Journey{}, err
+	}
+
 	dataStr := make(map[string]string)
 	dataInt := make(map[string]int)
 
-	dataInt["userID"] = requestData.UserID
+	dataInt["userID"] = userID
 	dataStr["name"] = requestData.Name
 	dataStr["description"] = requestData.Description
 
@@ -89,11 +95,15 @@ func (h JourneyHandler) DeleteJourney(ctx context.Context, requestData entities.
 		return entities.Journey{}, errParsing
 	}
 
+	sightsRepo := sightRep.NewSightRepo(db)
+	if _, err = requireJourneyOwner(ctx, sightsRepo, journeyID); err != nil {
+		return entities.Journey{}, err
+	}
+
 	dataInt := make(map[string]int)
 
 	dataInt["journeyID"] = journeyID
 
-	sightsRepo := sightRep.NewSightRepo(db)
 	err = sightsRepo.DeleteJourneyByID(dataInt)
 
 	if err != nil {
@@ -134,6 +144,11 @@ func (h *JourneyHandler) AddJourneySight(ctx context.Context, requestData entiti
 		return entities.JourneySight{}, errParsing
 	}
 
+	sightsRepo := sightRep.NewSightRepo(db)
+	if _, err = requireJourneyOwner(ctx, sightsRepo, journeyID); err != nil {
+		return entities.JourneySight{}, err
+	}
+
 	dataInt := make(map[string]int)
 	dataInt["journeyID"] = journeyID
 
@@ -144,7 +159,6 @@ func (h *JourneyHandler) AddJourneySight(ctx context.Context, requestData entiti
 	logrus.Info(dataInt)
 	logrus.Info(requestData.ListID)
 	logrus.Info(dataStr)
-	sightsRepo := sightRep.NewSightRepo(db)
 	err = sightsRepo.AddJourneySight(dataInt, requestData.ListID, dataStr)
 
 	if err != nil {
@@ -166,11 +180,15 @@ func (h *JourneyHandler) DeleteJourneySight(ctx context.Context, requestData ent
 		return entities.JourneySight{}, errParsing
 	}
 
+	sightsRepo := sightRep.NewSightRepo(db)
+	if _, err = requireJourneyOwner(ctx, sightsRepo, journeyID); err != nil {
+		return entities.JourneySight{}, err
+	}
+
 	dataInt := make(map[string]int)
 	dataInt["journeyID"] = journeyID
 	dataInt["sightID"] = requestData.SightID
 
-	sightsRepo := sightRep.NewSightRepo(db)
 	err = sightsRepo.DeleteJourneySight(dataInt)
 
 	if err != nil {
@@ -0,0 +1,143 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/http-server/server/db"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/middle"
+	"homework_ipl/utils/wrapper"
+)
+
+// Вход через сторонних провайдеров: /oauth/{provider}/login -> провайдер -> /oauth/{provider}/callback
+type OIDCHandler struct{}
+
+var (
+	errOIDCProviderNotFound = errors.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "unknown login provider",
+	}
+	errOIDCState = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "invalid or expired login state",
+	}
+	errOIDCLogin = errors.HttpError{
+		Code:    http.StatusBadGateway,
+		Message: "failed login with provider",
+	}
+	errOIDCEmailNotVerified = errors.HttpError{
+		Code:    http.StatusForbidden,
+		Message: "provider did not confirm the email",
+	}
+	errOIDCAccountNotVerified = errors.HttpError{
+		Code:    http.StatusConflict,
+		Message: "account with this email is not verified, log in with password to link the provider",
+	}
+)
+
+func (h *OIDCHandler) GetProviders(ctx context.Context, _ entities.OIDCProviders) (entities.OIDCProviders, error) {
+	return entities.OIDCProviders{Providers: usecase.OIDCProviderNames()}, nil
+}
+
+// Редирект на страницу входа провайдера
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	provider, err := usecase.GetOIDCProvider(wrapper.GetPathParams(r)["provider"])
+	if err != nil {
+		errors.WriteHttpError(errOIDCProviderNotFound, w)
+		return
+	}
+
+	authURL, err := provider.StartLogin(w)
+	if err != nil {
+		logger.Logger().Error("Error while starting oidc login", "error", err)
+		errors.WriteHttpError(errOIDCLogin, w)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Возврат с провайдера: находит, привязывает или создаёт пользователя и выдаёт обычную сессию
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	logger := logger.Logger()
+	ctx := r.Context()
+
+	provider, err := usecase.GetOIDCProvider(wrapper.GetPathParams(r)["provider"])
+	if err != nil {
+		errors.WriteHttpError(errOIDCProviderNotFound, w)
+		return
+	}
+
+	identity, err := provider.FinishLogin(w, r)
+	if err == usecase.ErrOIDCState {
+		errors.WriteHttpError(errOIDCState, w)
+		return
+	}
+	if err != nil {
+		logger.Error("Error while finishing oidc login", "error", err)
+		errors.WriteHttpError(errOIDCLogin, w)
+		return
+	}
+
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	// уже вошедший пользователь привязывает аккаунт провайдера к себе
+	currentUserID, _ := middle.CurrentUser(ctx)
+
+	user, err := usecase.LoginWithOIDC(ctx, repository.NewIdentityRepo(db), identity, currentUserID)
+	if err == usecase.ErrOIDCEmailNotVerified {
+		errors.WriteHttpError(errOIDCEmailNotVerified, w)
+		return
+	}
+	if err == usecase.ErrOIDCAccountNotVerified {
+		errors.WriteHttpError(errOIDCAccountNotVerified, w)
+		return
+	}
+	if err != nil {
+		logger.Error("Error while linking oidc identity", "error", err)
+		errors.WriteHttpError(errOIDCLogin, w)
+		return
+	}
+
+	// провайдер заменяет пароль, но не второй фактор
+	if user.ID != currentUserID {
+		twoFactor := usecase.NewTwoFactor(repository.NewTOTPRepo(db))
+		enabled, err := twoFactor.Enabled(ctx, user.ID)
+		if err != nil {
+			errors.WriteHttpError(errInternal, w)
+			return
+		}
+		if enabled {
+			challenge, err := twoFactor.NewChallenge(user.ID)
+			if err != nil {
+				errors.WriteHttpError(errInternal, w)
+				return
+			}
+			http.Redirect(w, r, usecase.OIDCSuccessURL(challenge), http.StatusFound)
+			return
+		}
+	}
+
+	if err = restoreAccount(ctx, r, repository.NewUserRepo(db), user.ID); err != nil {
+		errors.WriteHttpError(err, w)
+		return
+	}
+
+	if err = usecase.SetSession(w, r, user.ID, user.Role); err != nil {
+		errors.WriteHttpError(errSetSession, w)
+		return
+	}
+	if user.ID != currentUserID {
+		usecase.Audit(ctx, r, user.ID, entities.AuditLogin, entities.AuditUser(user.ID))
+	}
+
+	http.Redirect(w, r, usecase.OIDCSuccessURL(""), http.StatusFound)
+}
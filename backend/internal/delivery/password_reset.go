@@ -0,0 +1,108 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/http-server/server/db"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/httputils"
+	"homework_ipl/utils/logger"
+)
+
+// Восстановление пароля по ссылке из письма
+type PasswordResetHandler struct{}
+
+var (
+	errForgotPassword = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed sending reset link",
+	}
+	errResetPassword = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed resetting password",
+	}
+	errInvalidResetToken = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "invalid or expired reset link",
+	}
+	errWeakPassword = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "password is not complex",
+	}
+)
+
+// Запрос ссылки для сброса. Ответ одинаковый для существующих и несуществующих
+// адресов, чтобы по нему нельзя было перебирать зарегистрированные email
+func (h *PasswordResetHandler) ForgotPassword(ctx context.Context, requestData entities.User) (UserResponse, error) {
+	logger := logger.Logger()
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	userRepo := repository.NewUserRepo(db)
+	user, found, err := userRepo.GetUserByEmail(requestData.Email)
+	if err != nil {
+		return UserResponse{}, errForgotPassword
+	}
+	if !found {
+		return UserResponse{}, nil
+	}
+
+	resetRepo := repository.NewPasswordResetRepo(db)
+	err = usecase.SendPasswordReset(ctx, resetRepo, user)
+	if err == usecase.ErrResendTooSoon {
+		return UserResponse{}, nil
+	}
+	if err != nil {
+		logger.Error("Error while sending reset email", "error", err)
+		return UserResponse{}, errForgotPassword
+	}
+
+	return UserResponse{}, nil
+}
+
+// Новый пароль по токену из письма, все сессии пользователя завершаются
+func (h *PasswordResetHandler) ResetPassword(ctx context.Context, requestData entities.PasswordReset) (UserResponse, error) {
+	logger := logger.Logger()
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	// текст ошибки политики паролей отдаём как есть, чтобы было понятно, что исправить
+	if err := entities.ValidatePassword(requestData.NewPasswrd); err != nil {
+		return UserResponse{}, errors.HttpError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	resetRepo := repository.NewPasswordResetRepo(db)
+	userID, err := usecase.ResetPassword(ctx, resetRepo, requestData.Token, requestData.NewPasswrd)
+	switch err {
+	case nil:
+	case usecase.ErrInvalidToken:
+		return UserResponse{}, errInvalidResetToken
+	case usecase.ErrWeakPassword:
+		return UserResponse{}, errWeakPassword
+	default:
+		logger.Error("Error while resetting password", "error", err)
+		return UserResponse{}, errResetPassword
+	}
+
+	if request, ok := httputils.HttpRequest(ctx); ok {
+		usecase.Audit(ctx, request, 0, entities.AuditPasswordReset, entities.AuditUser(userID))
+	}
+
+	// владелец почты подтвердил, что он - это он: блокировку входа можно снять
+	userRepo := repository.NewUserRepo(db)
+	if user, err := userRepo.GetUserByID(userID); err == nil {
+		if err = usecase.Logins.RegisterSuccess(ctx, user.Email); err != nil {
+			logger.Error("Error while resetting login failures", "error", err)
+		}
+	}
+
+	return UserResponse{ID: userID}, nil
+}
@@ -0,0 +1,154 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"homework_ipl/internal/entities"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/middle"
+	"homework_ipl/utils/wrapper"
+)
+
+// Проверки прав доступа: 401 - пользователь не авторизован,
+// 403 - авторизован, но пытается изменить чужие данные
+
+var (
+	errUnauthorized = errors.HttpError{
+		Code:    http.StatusUnauthorized,
+		Message: "unauthorized",
+	}
+	errForbidden = errors.HttpError{
+		Code:    http.StatusForbidden,
+		Message: "permission denied",
+	}
+	errCommentNotFound = errors.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "comment not found",
+	}
+	errJourneyNotFound = errors.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "journey not found",
+	}
+	errEmailNotVerified = errors.HttpError{
+		Code:    http.StatusForbidden,
+		Message: "email is not verified",
+	}
+)
+
+type ownerRepo interface {
+	GetCommentOwner(commentID int) (int, error)
+	GetJourneyOwner(journeyID int) (int, error)
+}
+
+// Айди текущего пользователя или 401
+func requireUser(ctx context.Context) (int, error) {
+	userID, ok := middle.CurrentUser(ctx)
+	if !ok {
+		return 0, errUnauthorized
+	}
+	return userID, nil
+}
+
+type verifiedRepo interface {
+	IsEmailVerified(userID int) (bool, error)
+}
+
+// Писать отзывы и создавать поездки могут только пользователи с подтверждённой почтой
+func requireVerifiedUser(ctx context.Context, repo verifiedRepo) (int, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	verified, err := repo.IsEmailVerified(userID)
+	if err != nil {
+		return 0, errInternal
+	}
+	if !verified {
+		return 0, errEmailNotVerified
+	}
+	return userID, nil
+}
+
+// Профиль (и всё, что к нему привязано) может менять только его владелец
+func requireProfileOwner(ctx context.Context, profileID int) (int, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if userID != profileID {
+		logger.Logger().Error("Cannot edit other's profile", "userID", userID, "profileID", profileID)
+		return 0, errForbidden
+	}
+	return userID, nil
+}
+
+// То же для маршрутов /profile/{id}/...: айди профиля берётся из пути
+func requirePathProfileOwner(ctx context.Context) (int, error) {
+	profileID, err := strconv.Atoi(wrapper.GetPathParamsFromCtx(ctx)["id"])
+	if err != nil {
+		logger.Logger().Error("Error while converting string to int", "error", err)
+		return 0, errParsing
+	}
+	return requireProfileOwner(ctx, profileID)
+}
+
+// Комментарий может менять только его автор
+func requireCommentOwner(ctx context.Context, repo ownerRepo, commentID int) (int, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ownerID, err := repo.GetCommentOwner(commentID)
+	if err == repository.ErrNotFound {
+		return 0, errCommentNotFound
+	}
+	if err != nil {
+		return 0, errInternal
+	}
+
+	if ownerID != userID {
+		logger.Logger().Error("Cannot edit other's comment", "userID", userID, "commentID", commentID)
+		return 0, errForbidden
+	}
+	return userID, nil
+}
+
+// Удалять чужие комментарии могут модераторы и админы, остальные - только свои
+func requireCommentDeleter(ctx context.Context, repo ownerRepo, commentID int) (int, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if entities.HasPermission(middle.CurrentRole(ctx), entities.PermDeleteAnyComment) {
+		return userID, nil
+	}
+	return requireCommentOwner(ctx, repo, commentID)
+}
+
+// Поездку и её достопримечательности может менять только владелец поездки
+func requireJourneyOwner(ctx context.Context, repo ownerRepo, journeyID int) (int, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ownerID, err := repo.GetJourneyOwner(journeyID)
+	if err == repository.ErrNotFound {
+		return 0, errJourneyNotFound
+	}
+	if err != nil {
+		return 0, errInternal
+	}
+
+	if ownerID != userID {
+		logger.Logger().Error("Cannot edit other's journey", "userID", userID, "journeyID", journeyID)
+		return 0, errForbidden
+	}
+	return userID, nil
+}
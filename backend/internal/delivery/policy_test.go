@@ -0,0 +1,135 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"homework_ipl/internal/entities"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/middle"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeOwnerRepo struct {
+	comments map[int]int
+	journeys map[int]int
+}
+
+func (r fakeOwnerRepo) GetCommentOwner(commentID int) (int, error) {
+	owner, ok := r.comments[commentID]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	return owner, nil
+}
+
+func (r fakeOwnerRepo) GetJourneyOwner(journeyID int) (int, error) {
+	owner, ok := r.journeys[journeyID]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	return owner, nil
+}
+
+var owners = fakeOwnerRepo{
+	comments: map[int]int{10: 1},
+	journeys: map[int]int{20: 1},
+}
+
+func statusCode(t *testing.T, err error) int {
+	t.Helper()
+	httpErr, ok := err.(errors.HttpError)
+	if !assert.True(t, ok, "expected HttpError, got %v", err) {
+		return 0
+	}
+	return httpErr.Code
+}
+
+func TestRequireUser(t *testing.T) {
+	_, err := requireUser(context.Background())
+	assert.Equal(t, http.StatusUnauthorized, statusCode(t, err))
+
+	userID, err := requireUser(middle.WithCurrentUser(context.Background(), 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userID)
+}
+
+func TestRequireProfileOwner(t *testing.T) {
+	_, err := requireProfileOwner(context.Background(), 1)
+	assert.Equal(t, http.StatusUnauthorized, statusCode(t, err))
+
+	_, err = requireProfileOwner(middle.WithCurrentUser(context.Background(), 2), 1)
+	assert.Equal(t, http.StatusForbidden, statusCode(t, err))
+
+	_, err = requireProfileOwner(middle.WithCurrentUser(context.Background(), 1), 1)
+	assert.NoError(t, err)
+}
+
+func TestRequireCommentOwner(t *testing.T) {
+	_, err := requireCommentOwner(context.Background(), owners, 10)
+	assert.Equal(t, http.StatusUnauthorized, statusCode(t, err))
+
+	_, err = requireCommentOwner(middle.WithCurrentUser(context.Background(), 2), owners, 10)
+	assert.Equal(t, http.StatusForbidden, statusCode(t, err))
+
+	_, err = requireCommentOwner(middle.WithCurrentUser(context.Background(), 1), owners, 11)
+	assert.Equal(t, http.StatusNotFound, statusCode(t, err))
+
+	_, err = requireCommentOwner(middle.WithCurrentUser(context.Background(), 1), owners, 10)
+	assert.NoError(t, err)
+}
+
+func TestRequireJourneyOwner(t *testing.T) {
+	_, err := requireJourneyOwner(context.Background(), owners, 20)
+	assert.Equal(t, http.StatusUnauthorized, statusCode(t, err))
+
+	_, err = requireJourneyOwner(middle.WithCurrentUser(context.Background(), 2), owners, 20)
+	assert.Equal(t, http.StatusForbidden, statusCode(t, err))
+
+	_, err = requireJourneyOwner(middle.WithCurrentUser(context.Background(), 1), owners, 21)
+	assert.Equal(t, http.StatusNotFound, statusCode(t, err))
+
+	_, err = requireJourneyOwner(middle.WithCurrentUser(context.Background(), 1), owners, 20)
+	assert.NoError(t, err)
+}
+
+func TestRequireCommentDeleter(t *testing.T) {
+	_, err := requireCommentDeleter(context.Background(), owners, 10)
+	assert.Equal(t, http.StatusUnauthorized, statusCode(t, err))
+
+	user := middle.WithCurrentRole(middle.WithCurrentUser(context.Background(), 2), entities.RoleUser)
+	_, err = requireCommentDeleter(user, owners, 10)
+	assert.Equal(t, http.StatusForbidden, statusCode(t, err))
+
+	moderator := middle.WithCurrentRole(middle.WithCurrentUser(context.Background(), 3), entities.RoleModerator)
+	_, err = requireCommentDeleter(moderator, owners, 10)
+	assert.NoError(t, err)
+
+	author := middle.WithCurrentRole(middle.WithCurrentUser(context.Background(), 1), entities.RoleUser)
+	_, err = requireCommentDeleter(author, owners, 10)
+	assert.NoError(t, err)
+}
+
+type fakeVerifiedRepo map[int]bool
+
+func (r fakeVerifiedRepo) IsEmailVerified(userID int) (bool, error) {
+	return r[userID], nil
+}
+
+func TestRequireVerifiedUser(t *testing.T) {
+	repo := fakeVerifiedRepo{1: true, 2: false}
+
+	_, err := requireVerifiedUser(context.Background(), repo)
+	assert.Equal(t, http.StatusUnauthorized, statusCode(t, err))
+
+	userID, err := requireVerifiedUser(middle.WithCurrentUser(context.Background(), 1), repo)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userID)
+
+	_, err = requireVerifiedUser(middle.WithCurrentUser(context.Background(), 2), repo)
+	assert.Equal(t, http.StatusForbidden, statusCode(t, err))
+	assert.Equal(t, errEmailNotVerified, err)
+}
@@ -10,14 +10,14 @@ import (
 
 	"homework_ipl/internal/entities"
 	"homework_ipl/internal/http-server/server/db"
+	"homework_ipl/internal/password"
 	userRep "homework_ipl/internal/repository/postgres"
 	"homework_ipl/internal/usecase"
 	"homework_ipl/utils/errors"
 	"homework_ipl/utils/httputils"
 	"homework_ipl/utils/logger"
+	"homework_ipl/utils/middle"
 	"homework_ipl/utils/wrapper"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 type ProfileHandler struct{}
@@ -38,11 +38,6 @@ var (
 		Code:    http.StatusInternalServerError,
 		Message: "failed deleting profile",
 	}
-	errProfilePermissionDenied = errors.HttpError{
-		Code:    http.StatusUnauthorized,
-		Message: "permission denied",
-	}
-
 	errProfileResetPassword = errors.HttpError{
 		Code:    http.StatusUnauthorized,
 		Message: "weak password",
@@ -102,22 +97,21 @@ func (h *ProfileHandler) DeleteUser(ctx context.Context, requestData entities.Us
 		return ProfileResponse{}, errParsing
 	}
 
-	if r, _ := httputils.HttpRequest(ctx); userID != usecase.GetSession(r) {
-		logger.Error("Cannot edit other's profile")
-		return ProfileResponse{}, errProfilePermissionDenied
+	if _, err = requireProfileOwner(ctx, userID); err != nil {
+		return ProfileResponse{}, err
 	}
 
-	dataInt := make(map[string]int)
-
-	dataInt["userID"] = userID
-
-	userRepo := userRep.NewUserRepo(db)
-	err = userRepo.DeleteUserProfile(dataInt)
-
-	if err != nil {
+	// аккаунт можно восстановить входом, пока не истёк период восстановления
+	deletion := usecase.NewAccountDeletion(userRep.NewUserRepo(db))
+	if _, err = deletion.Delete(ctx, userID); err != nil {
+		logger.Error("Error while deleting account", "error", err)
 		return ProfileResponse{}, errDeleteProfile
 	}
 
+	if r, ok := httputils.HttpRequest(ctx); ok {
+		usecase.Audit(ctx, r, userID, entities.AuditProfileDelete, entities.AuditUser(userID))
+	}
+
 	return ProfileResponse{}, nil
 }
 
@@ -135,10 +129,8 @@ func (h *ProfileHandler) EditUserProfile(ctx context.Context, requestData entiti
 		logger.Error("Error while converting string to int", "error", err)
 		return entities.UserProfile{}, errParsing
 	}
-	r, _ := httputils.HttpRequest(ctx)
-	if userID != usecase.GetSession(r) {
-		logger.Error("Cannot edit other's profile")
-		return entities.UserProfile{}, errProfilePermissionDenied
+	if _, err = requireProfileOwner(ctx, userID); err != nil {
+		return entities.UserProfile{}, err
 	}
 
 	dataInt := make(map[string]int)
@@ -172,10 +164,12 @@ func (h *ProfileHandler) UpdateUserPassword(ctx context.Context, requestData ent
 		logger.Error("Error while converting string to int", "error", err)
 		return ProfileResponse{}, errParsing
 	}
-	r, _ := httputils.HttpRequest(ctx)
-	if userID != usecase.GetSession(r) {
-		logger.Error("Cannot edit other's profile")
-		return ProfileResponse{}, errProfilePermissionDenied
+	if _, err = requireProfileOwner(ctx, userID); err != nil {
+		return ProfileResponse{}, err
+	}
+	r, ok := httputils.HttpRequest(ctx)
+	if !ok {
+		return ProfileResponse{}, errInternal
 	}
 
 	userRepo := userRep.NewUserRepo(db)
@@ -187,22 +181,38 @@ func (h *ProfileHandler) UpdateUserPassword(ctx context.Context, requestData ent
 		return ProfileResponse{}, errLoginUser
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(old_passwrd_hash), []byte(requestData.Passwrd))
+	match, err := password.Verify(requestData.Passwrd, old_passwrd_hash)
 
-	if err != nil {
+	if err != nil || !match {
 		fmt.Println("Passwords not match!")
 		return ProfileResponse{}, errIncorrectOldPassword
 	}
 	// --------------------------------
 
-	if !entities.ValidatePassword(requestData.NewPasswrd) {
-		return ProfileResponse{}, errProfileResetPassword
+	if err = entities.ValidatePassword(requestData.NewPasswrd); err != nil {
+		return ProfileResponse{}, errors.HttpError{Code: errProfileResetPassword.Code, Message: err.Error()}
 	}
 
 	err = userRepo.UpdateUserPassword(userID, requestData.NewPasswrd)
 	if err != nil {
 		return ProfileResponse{}, err
 	}
+	usecase.Audit(ctx, r, userID, entities.AuditPasswordChange, entities.AuditUser(userID))
+
+	// после смены пароля все остальные устройства разлогиниваются
+	if err = usecase.RevokeOtherSessions(r, userID); err != nil {
+		logger.Error("Error while revoking sessions", "error", err)
+		return ProfileResponse{}, errRevokeSession
+	}
+
+	// текущей сессии выдаётся новый айди
+	w, ok := httputils.ContextWriter(ctx)
+	if !ok {
+		return ProfileResponse{}, errInternal
+	}
+	if err = usecase.SetSession(w, r, userID, middle.CurrentRole(ctx)); err != nil {
+		return ProfileResponse{}, errSetSession
+	}
 
 	return ProfileResponse{}, nil
 }
@@ -225,9 +235,9 @@ func (f *ProfileHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Проверка прав пользователя
-	if userID != usecase.GetSession(r) {
+	if _, err = requireProfileOwner(r.Context(), userID); err != nil {
 		logger.Error("Пользователь пытается изменить чужой профиль:", "userID", userID)
-		errors.WriteHttpError(errProfilePermissionDenied, w)
+		errors.WriteHttpError(err, w)
 		return
 	}
 	// Сохранение файла
@@ -252,6 +262,7 @@ func (f *ProfileHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	logger.Info("Профиль успешно обновлен в БД для пользователя:", "userID", userID)
+	usecase.Audit(r.Context(), r, userID, entities.AuditAvatarUpload, entities.AuditUser(userID))
 	// Формирование JSON-ответа
 	rawJSON, err := json.Marshal(profile)
 	if err != nil {
@@ -11,6 +11,7 @@ import (
 	"homework_ipl/utils/errors"
 	"homework_ipl/utils/httputils"
 	"homework_ipl/utils/logger"
+	"homework_ipl/utils/wrapper"
 )
 
 type RegistrationHandler struct{}
@@ -20,6 +21,30 @@ var (
 		Code:    http.StatusInternalServerError,
 		Message: "failed creating new profile",
 	}
+	errEmailTaken = errors.HttpError{
+		Code:    http.StatusConflict,
+		Message: "email is already registered",
+	}
+	errUsernameTaken = errors.HttpError{
+		Code:    http.StatusConflict,
+		Message: "username is already taken",
+	}
+	errVerifyEmail = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed verifying email",
+	}
+	errInvalidVerificationToken = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "invalid or expired verification link",
+	}
+	errAlreadyVerified = errors.HttpError{
+		Code:    http.StatusConflict,
+		Message: "email is already verified",
+	}
+	errResendTooSoon = errors.HttpError{
+		Code:    http.StatusTooManyRequests,
+		Message: "verification email was sent recently",
+	}
 )
 
 func (h *RegistrationHandler) SignUp(ctx context.Context, requestData entities.User) (UserResponse, error) {
@@ -35,35 +60,102 @@ func (h *RegistrationHandler) SignUp(ctx context.Context, requestData entities.U
 		return UserResponse{}, errors.HttpError{Code: http.StatusBadRequest, Message: err.Error()}
 	}
 
-	user, err := entities.CreateUser(username, password)
+	userService := usecase.NewUserService(userRep.NewUserRepo(db))
+	user, err := signUpWithSession(ctx, userService, username, password)
 	if err != nil {
-		return UserResponse{}, errCreateUser
+		return UserResponse{}, err
 	}
 
+	// письмо не дошло - не повод отменять регистрацию, его можно запросить повторно
+	verificationRepo := userRep.NewVerificationRepo(db)
+	if err = usecase.SendVerification(ctx, verificationRepo, user); err != nil {
+		logger.Logger().Error("Error while sending verification email", "error", err)
+	}
+
+	return UserResponse{ID: user.ID, Username: user.Email}, nil
+}
+
+// Создаёт пользователя и выдаёт ему ровно одну сессию. Если пользователь не создан
+// (email или имя заняты), сессия не выдаётся
+func signUpWithSession(ctx context.Context, userService *usecase.UserService, email, password string) (entities.User, error) {
 	responseWriter, ok := httputils.ContextWriter(ctx)
 	if !ok {
-		return UserResponse{}, errInternal
+		return entities.User{}, errInternal
+	}
+	request, ok := httputils.HttpRequest(ctx)
+	if !ok {
+		return entities.User{}, errInternal
+	}
+
+	user, err := userService.SignUp(ctx, email, password)
+	switch err {
+	case nil:
+	case entities.ErrEmailTaken:
+		return entities.User{}, errEmailTaken
+	case entities.ErrUsernameTaken:
+		return entities.User{}, errUsernameTaken
+	default:
+		return entities.User{}, errCreateUser
+	}
+
+	err = usecase.SetSession(responseWriter, request, user.ID, entities.RoleUser)
+	if err != nil {
+		return entities.User{}, errSetSession
 	}
 
-	err = usecase.SetSession(responseWriter, user.ID)
+	return user, nil
+}
+
+// Подтверждение почты по ссылке из письма (/signup/verify?token=...)
+func (h *RegistrationHandler) VerifyEmail(ctx context.Context, _ entities.User) (UserResponse, error) {
+	db, err := db.GetPostgres()
 	if err != nil {
-		return UserResponse{}, errSetSession
+		logger.Logger().Error(err.Error())
 	}
 
-	dataStr := make(map[string]string)
+	token := wrapper.GetQueryParamsFromCtx(ctx)["token"]
+
+	verificationRepo := userRep.NewVerificationRepo(db)
+	userID, err := usecase.VerifyEmail(ctx, verificationRepo, token)
+	if err == usecase.ErrInvalidToken {
+		return UserResponse{}, errInvalidVerificationToken
+	}
+	if err != nil {
+		return UserResponse{}, errVerifyEmail
+	}
 
-	dataStr["email"] = user.Email
-	dataStr["passwrd"] = user.Passwrd
+	return UserResponse{ID: userID}, nil
+}
 
-	UserRepo := userRep.NewUserRepo(db)
-	user, err = UserRepo.CreateUser(dataStr)
+// Повторная отправка письма с подтверждением текущему пользователю
+func (h *RegistrationHandler) ResendVerification(ctx context.Context, _ entities.User) (UserResponse, error) {
+	db, err := db.GetPostgres()
 	if err != nil {
-		return UserResponse{}, errCreateUser
+		logger.Logger().Error(err.Error())
 	}
 
-	err = usecase.SetSession(responseWriter, user.ID)
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return UserResponse{}, err
+	}
+
+	userRepo := userRep.NewUserRepo(db)
+	user, err := userRepo.GetUserByID(userID)
+	if err != nil {
+		return UserResponse{}, errVerifyEmail
+	}
+	if user.EmailVerified {
+		return UserResponse{}, errAlreadyVerified
+	}
+
+	verificationRepo := userRep.NewVerificationRepo(db)
+	err = usecase.SendVerification(ctx, verificationRepo, user)
+	if err == usecase.ErrResendTooSoon {
+		return UserResponse{}, errResendTooSoon
+	}
 	if err != nil {
-		return UserResponse{}, errSetSession
+		logger.Logger().Error("Error while sending verification email", "error", err)
+		return UserResponse{}, errVerifyEmail
 	}
 
 	return UserResponse{ID: user.ID, Username: user.Email}, nil
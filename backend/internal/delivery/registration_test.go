@@ -0,0 +1,78 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/httputils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Пользователи в памяти, имя профиля по умолчанию - email, как в бд
+type fakeSignUpStore struct {
+	emails    map[string]int
+	usernames map[string]int
+}
+
+func (s *fakeSignUpStore) CreateUser(_ context.Context, user entities.User) (entities.User, error) {
+	if _, ok := s.emails[user.Email]; ok {
+		return entities.User{}, entities.ErrEmailTaken
+	}
+	if _, ok := s.usernames[user.Email]; ok {
+		return entities.User{}, entities.ErrUsernameTaken
+	}
+	user.ID = len(s.emails) + 1
+	s.emails[user.Email] = user.ID
+	s.usernames[user.Email] = user.ID
+	return user, nil
+}
+
+func signUpContext() (context.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/signup", nil)
+	ctx := context.WithValue(r.Context(), httputils.ResponseWriterKey, http.ResponseWriter(w))
+	return context.WithValue(ctx, httputils.HttpRequestKey, r), w
+}
+
+func TestSignUpWithSession(t *testing.T) {
+	sessions := usecase.NewMemorySessionStore()
+	prev := usecase.Sessions
+	usecase.Sessions = sessions
+	t.Cleanup(func() { usecase.Sessions = prev })
+
+	store := &fakeSignUpStore{
+		emails: map[string]int{"old@example.com": 1},
+		// старый пользователь переименовал профиль в чужой email
+		usernames: map[string]int{"taken@example.com": 1},
+	}
+	service := usecase.NewUserService(store)
+
+	ctx, w := signUpContext()
+	user, err := signUpWithSession(ctx, service, "new@example.com", "Password1")
+	require.NoError(t, err)
+	list, err := sessions.ListByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Len(t, list, 1, "exactly one session")
+	assert.Len(t, w.Result().Cookies(), 1)
+
+	for email, want := range map[string]error{
+		"old@example.com":   errEmailTaken,
+		"taken@example.com": errUsernameTaken,
+	} {
+		ctx, w = signUpContext()
+		_, err = signUpWithSession(ctx, service, email, "Password1")
+		assert.Equal(t, want, err, email)
+		assert.Equal(t, http.StatusConflict, statusCode(t, err), email)
+		assert.Empty(t, w.Result().Cookies(), "no session for a failed signup: %s", email)
+	}
+
+	list, err = sessions.ListByUser(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,38 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/http-server/server/db"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/wrapper"
+)
+
+type SearchHandler struct{}
+
+var errSuggest = errors.HttpError{
+	Code:    http.StatusInternalServerError,
+	Message: "failed getting suggestions",
+}
+
+// Подсказки для строки поиска: /search/suggest?q=моск
+func (h *SearchHandler) Suggest(ctx context.Context, _ entities.Sight) (entities.Suggestions, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	query := wrapper.GetQueryParamsFromCtx(ctx)["q"]
+
+	suggestions, err := usecase.Suggest(ctx, repository.NewSearchRepo(db), query)
+	if err != nil {
+		return entities.Suggestions{}, errSuggest
+	}
+
+	return entities.Suggestions{Suggestions: suggestions}, nil
+}
@@ -0,0 +1,127 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/httputils"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/wrapper"
+)
+
+type SessionHandler struct{}
+
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	Device    string    `json:"device"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+	LastUsed  time.Time `json:"last_used"`
+	Current   bool      `json:"current"`
+}
+
+type SessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+var (
+	errGetSessions = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed getting sessions",
+	}
+	errRevokeSession = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed revoking session",
+	}
+	errSessionNotFound = errors.HttpError{
+		Code:    http.StatusNotFound,
+		Message: "session not found",
+	}
+)
+
+// Список активных сессий (устройств) пользователя
+func (h *SessionHandler) GetSessions(ctx context.Context, _ entities.User) (SessionsResponse, error) {
+	userID, r, err := sessionOwner(ctx)
+	if err != nil {
+		return SessionsResponse{}, err
+	}
+
+	sessions, current, err := usecase.ListSessions(r, userID)
+	if err != nil {
+		return SessionsResponse{}, errGetSessions
+	}
+
+	response := SessionsResponse{Sessions: []SessionResponse{}}
+	for _, session := range sessions {
+		publicID := usecase.PublicSessionID(session.ID)
+		response.Sessions = append(response.Sessions, SessionResponse{
+			ID:        publicID,
+			Device:    session.UserAgent,
+			IP:        session.IP,
+			CreatedAt: session.CreatedAt,
+			LastUsed:  session.LastSeen,
+			Current:   publicID == current,
+		})
+	}
+
+	return response, nil
+}
+
+// Завершение одной сессии (выход на другом устройстве)
+func (h *SessionHandler) RevokeSession(ctx context.Context, _ entities.User) (SessionsResponse, error) {
+	userID, r, err := sessionOwner(ctx)
+	if err != nil {
+		return SessionsResponse{}, err
+	}
+
+	pathParams := wrapper.GetPathParamsFromCtx(ctx)
+	err = usecase.RevokeSession(r, userID, pathParams["sid"])
+	if err == usecase.ErrSessionNotFound {
+		return SessionsResponse{}, errSessionNotFound
+	}
+	if err != nil {
+		return SessionsResponse{}, errRevokeSession
+	}
+
+	return h.GetSessions(ctx, entities.User{})
+}
+
+// Завершение всех сессий, кроме текущей
+func (h *SessionHandler) RevokeOtherSessions(ctx context.Context, _ entities.User) (SessionsResponse, error) {
+	userID, r, err := sessionOwner(ctx)
+	if err != nil {
+		return SessionsResponse{}, err
+	}
+
+	if err = usecase.RevokeOtherSessions(r, userID); err != nil {
+		return SessionsResponse{}, errRevokeSession
+	}
+
+	return h.GetSessions(ctx, entities.User{})
+}
+
+// Сессиями профиля может управлять только сам владелец
+func sessionOwner(ctx context.Context) (int, *http.Request, error) {
+	pathParams := wrapper.GetPathParamsFromCtx(ctx)
+	userID, err := strconv.Atoi(pathParams["id"])
+	if err != nil {
+		logger.Logger().Error("Error while converting string to int", "error", err)
+		return 0, nil, errParsing
+	}
+
+	if _, err = requireProfileOwner(ctx, userID); err != nil {
+		return 0, nil, err
+	}
+
+	r, ok := httputils.HttpRequest(ctx)
+	if !ok {
+		return 0, nil, errInternal
+	}
+
+	return userID, r, nil
+}
@@ -0,0 +1,110 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/httputils"
+	"homework_ipl/utils/middle"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Сессии в памяти: у пользователя 1 текущая "current" и ещё две, у пользователя 2 одна
+func setupSessions(t *testing.T) *usecase.MemorySessionStore {
+	t.Helper()
+	store := usecase.NewMemorySessionStore()
+	prev := usecase.Sessions
+	usecase.Sessions = store
+	t.Cleanup(func() { usecase.Sessions = prev })
+
+	now := time.Now()
+	for _, session := range []entities.Session{
+		{ID: "current", UserID: 1, ExpiresAt: now.Add(time.Hour), LastSeen: now},
+		{ID: "laptop", UserID: 1, ExpiresAt: now.Add(time.Hour), LastSeen: now},
+		{ID: "phone", UserID: 1, ExpiresAt: now.Add(time.Hour), LastSeen: now},
+		{ID: "stranger", UserID: 2, ExpiresAt: now.Add(time.Hour), LastSeen: now},
+	} {
+		require.NoError(t, store.Create(context.Background(), session))
+	}
+	return store
+}
+
+// Контекст запроса пользователя 1 с кукой сессии "current", как его собирает wrapper
+func sessionRequestContext(t *testing.T, pathParams map[string]string) context.Context {
+	t.Helper()
+	encoded, err := usecase.CookieHandler.Encode("session_id", "current")
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/profile/1/sessions", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: encoded})
+
+	ctx := middle.WithCurrentUser(r.Context(), 1)
+	ctx = context.WithValue(ctx, httputils.HttpRequestKey, r)
+	return context.WithValue(ctx, httputils.RequestPathParamsKey, pathParams)
+}
+
+func sessionIDs(response SessionsResponse) map[string]bool {
+	ids := make(map[string]bool)
+	for _, session := range response.Sessions {
+		ids[session.ID] = session.Current
+	}
+	return ids
+}
+
+func TestGetSessions(t *testing.T) {
+	setupSessions(t)
+	h := &SessionHandler{}
+
+	response, err := h.GetSessions(sessionRequestContext(t, map[string]string{"id": "1"}), entities.User{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		usecase.PublicSessionID("current"): true,
+		usecase.PublicSessionID("laptop"):  false,
+		usecase.PublicSessionID("phone"):   false,
+	}, sessionIDs(response))
+
+	_, err = h.GetSessions(sessionRequestContext(t, map[string]string{"id": "2"}), entities.User{})
+	assert.Equal(t, http.StatusForbidden, statusCode(t, err))
+}
+
+func TestRevokeSession(t *testing.T) {
+	store := setupSessions(t)
+	h := &SessionHandler{}
+
+	ctx := sessionRequestContext(t, map[string]string{"id": "1", "sid": usecase.PublicSessionID("laptop")})
+	response, err := h.RevokeSession(ctx, entities.User{})
+	require.NoError(t, err)
+	assert.NotContains(t, sessionIDs(response), usecase.PublicSessionID("laptop"))
+	_, ok, _ := store.Get(context.Background(), "laptop")
+	assert.False(t, ok)
+
+	// чужая сессия выглядит так же, как несуществующая
+	ctx = sessionRequestContext(t, map[string]string{"id": "1", "sid": usecase.PublicSessionID("stranger")})
+	_, err = h.RevokeSession(ctx, entities.User{})
+	assert.Equal(t, errSessionNotFound, err)
+	_, ok, _ = store.Get(context.Background(), "stranger")
+	assert.True(t, ok)
+
+	ctx = sessionRequestContext(t, map[string]string{"id": "1", "sid": "unknown"})
+	_, err = h.RevokeSession(ctx, entities.User{})
+	assert.Equal(t, http.StatusNotFound, statusCode(t, err))
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	store := setupSessions(t)
+	h := &SessionHandler{}
+
+	response, err := h.RevokeOtherSessions(sessionRequestContext(t, map[string]string{"id": "1"}), entities.User{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{usecase.PublicSessionID("current"): true}, sessionIDs(response))
+
+	_, ok, _ := store.Get(context.Background(), "stranger")
+	assert.True(t, ok, "other users keep their sessions")
+}
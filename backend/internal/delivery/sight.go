@@ -2,13 +2,17 @@ package delivery
 
 import (
 	"context"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"homework_ipl/internal/entities"
 	"homework_ipl/internal/http-server/server/db"
+	"homework_ipl/utils/errors"
 	"homework_ipl/utils/logger"
 	"homework_ipl/utils/wrapper"
 
+	pkgErrors "github.com/pkg/errors"
 	sightRep "homework_ipl/internal/repository/postgres"
 )
 
@@ -19,19 +23,86 @@ type SightComments struct {
 	Comms []entities.Comment `json:"comments"`
 }
 
+var (
+	errInvalidSightFilter = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "invalid sights filter",
+	}
+	errGetSights = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed getting sights",
+	}
+)
+
+// Список достопримечательностей постранично:
+// /sights?limit=20&sort=rating|name|newest&min_rating=4&city_id=1&country_id=1&category=museum&cursor=...
 func (h *SightsHandler) GetSights(ctx context.Context, _ entities.Sight) (entities.Sights, error) {
 	db, err := db.GetPostgres()
 
 	if err != nil {
 		logger.Logger().Error(err.Error())
 	}
+
+	filter, err := parseSightFilter(wrapper.GetQueryParamsFromCtx(ctx))
+	if err != nil {
+		logger.Logger().Error("Error while parsing sights filter", "error", err)
+		return entities.Sights{}, errInvalidSightFilter
+	}
+
 	sightsRepo := sightRep.NewSightRepo(db)
-	sights, err := sightsRepo.GetSightsList()
+	sights, nextCursor, err := sightsRepo.GetSightsPage(ctx, filter)
+	if err == sightRep.ErrInvalidCursor {
+		return entities.Sights{}, errInvalidSightFilter
+	}
 	if err != nil {
-		return entities.Sights{}, err
+		return entities.Sights{}, errGetSights
 	}
 
-	return entities.Sights{Sight: sights}, nil
+	return entities.Sights{Sight: sights, NextCursor: nextCursor}, nil
+}
+
+func parseSightFilter(queryParams map[string]string) (entities.SightFilter, error) {
+	filter := entities.SightFilter{
+		Limit:    entities.DefaultSightsLimit,
+		Sort:     entities.SortRating,
+		Cursor:   queryParams["cursor"],
+		Category: queryParams["category"],
+	}
+	var err error
+
+	if value := queryParams["limit"]; value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			return entities.SightFilter{}, err
+		}
+		if filter.Limit < 1 || filter.Limit > entities.MaxSightsLimit {
+			return entities.SightFilter{}, pkgErrors.Errorf("limit must be between 1 and %d", entities.MaxSightsLimit)
+		}
+	}
+	if value := queryParams["sort"]; value != "" {
+		switch value {
+		case entities.SortRating, entities.SortName, entities.SortNewest:
+			filter.Sort = value
+		default:
+			return entities.SightFilter{}, pkgErrors.Errorf("unknown sort %q", value)
+		}
+	}
+	if value := queryParams["min_rating"]; value != "" {
+		if filter.MinRating, err = strconv.ParseFloat(value, 64); err != nil {
+			return entities.SightFilter{}, err
+		}
+	}
+	if value := queryParams["city_id"]; value != "" {
+		if filter.CityID, err = strconv.Atoi(value); err != nil {
+			return entities.SightFilter{}, err
+		}
+	}
+	if value := queryParams["country_id"]; value != "" {
+		if filter.CountryID, err = strconv.Atoi(value); err != nil {
+			return entities.SightFilter{}, err
+		}
+	}
+
+	return filter, nil
 }
 
 func (h *SightsHandler) GetSightByID(ctx context.Context, requestData entities.Sight) (SightComments, error) {
@@ -47,40 +118,61 @@ func (h *SightsHandler) GetSightByID(ctx context.Context, requestData entities.S
 		return SightComments{}, err
 	}
 	sightsRepo := sightRep.NewSightRepo(db)
-	sight, _ := sightsRepo.GetSightByID(id)
+	sight, err := sightsRepo.GetSightByID(id)
+	if err == sightRep.ErrNotFound {
+		return SightComments{}, errSightNotFound
+	}
+	if err != nil {
+		return SightComments{}, errGetSights
+	}
 
 	comments, err := sightsRepo.GetCommentsBySightID(id)
 
 	return SightComments{Sight: sight, Comms: comments}, err
 }
 
-func (h *SightsHandler) GetFilteredSights(ctx context.Context, _ entities.Sight) (entities.Sights, error) {
+// Поиск достопримечательностей: /sights/search?q=москв&limit=20.
+// Параметр name оставлен для старых клиентов, пустой запрос возвращает все достопримечательности
+func (h *SightsHandler) GetFilteredSights(ctx context.Context, _ entities.Sight) (entities.SightSearchResults, error) {
 	// Получаем соединение с базой данных
 	db, err := db.GetPostgres()
 	if err != nil {
 		logger.Logger().Error("Failed to connect to database: " + err.Error())
-		return entities.Sights{}, err
+		return entities.SightSearchResults{}, err
 	}
 
-	sightsRepo := sightRep.NewSightRepo(db)
-	sights, err := sightsRepo.GetSightsList()
-	if err != nil {
-		return entities.Sights{}, err
+	queryParams := wrapper.GetQueryParamsFromCtx(ctx)
+	query := strings.TrimSpace(queryParams["q"])
+	if query == "" {
+		query = strings.TrimSpace(queryParams["name"])
 	}
 
-	queryRow := wrapper.GetQueryParamsFromCtx(ctx)
-	name_of_city_of_country := queryRow["name"]
+	limit := entities.DefaultSearchLimit
+	if value := queryParams["limit"]; value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > entities.MaxSightsLimit {
+			return entities.SightSearchResults{}, errInvalidSightFilter
+		}
+	}
 
-	if name_of_city_of_country == "" {
-		return entities.Sights{Sight: sights}, nil
+	sightsRepo := sightRep.NewSightRepo(db)
+	if query == "" {
+		sights, err := sightsRepo.GetSightsList()
+		if err != nil {
+			return entities.SightSearchResults{}, errGetSights
+		}
+		results := make([]entities.SightSearchResult, 0, len(sights))
+		for _, s := range sights {
+			results = append(results, entities.SightSearchResult{Sight: s})
+		}
+		return entities.SightSearchResults{Sight: results}, nil
 	}
 
-	// Вызываем метод репозитория с фильтрами
-	sights, err = sightsRepo.GetFilteredSights(name_of_city_of_country)
+	results, err := sightsRepo.SearchSights(ctx, query, limit)
 	if err != nil {
-		logger.Logger().Error("Failed to fetch filtered sights: " + err.Error())
-		return entities.Sights{}, err
+		logger.Logger().Error("Failed to search sights: " + err.Error())
+		return entities.SightSearchResults{}, errGetSights
 	}
 
-	return entities.Sights{Sight: sights}, nil
+	return entities.SightSearchResults{Sight: results}, nil
 }
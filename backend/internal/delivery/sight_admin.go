@@ -0,0 +1,304 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/http-server/server/db"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/httputils"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/middle"
+	"homework_ipl/utils/wrapper"
+)
+
+var (
+	errGetSight = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed getting sight",
+	}
+	errSaveSight = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed saving sight",
+	}
+	errSightVersionConflict = errors.HttpError{
+		Code:    http.StatusPreconditionFailed,
+		Message: "sight was changed by someone else, reload it and try again",
+	}
+	errSightVersionRequired = errors.HttpError{
+		Code:    http.StatusPreconditionRequired,
+		Message: "If-Match header or version is required",
+	}
+	errInvalidIfMatch = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "invalid If-Match header",
+	}
+	errUnknownCity = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "unknown city",
+	}
+	errSightExists = errors.HttpError{
+		Code:    http.StatusConflict,
+		Message: "sight with this name already exists in the city",
+	}
+)
+
+// Версия достопримечательности в ETag: "3"
+func sightETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func setSightETag(ctx context.Context, sight entities.Sight) {
+	if w, ok := httputils.ContextWriter(ctx); ok {
+		w.Header().Set("ETag", sightETag(sight.Version))
+	}
+}
+
+// Версия из If-Match. Слабый ETag (W/"3") принимается так же, как сильный,
+// "*" - любая версия. Из списка ETag'ов берётся первый
+func parseIfMatch(header string) (version int, anyVersion bool, err error) {
+	value := strings.TrimSpace(header)
+	if i := strings.IndexByte(value, ','); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	if value == "*" {
+		return 0, true, nil
+	}
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, false, errInvalidIfMatch
+	}
+	version, err = strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version <= 0 {
+		return 0, false, errInvalidIfMatch
+	}
+	return version, false, nil
+}
+
+// Ожидаемая версия: If-Match, иначе version из тела.
+// found == false - версию не передали; version == 0 при found - "*"
+func requestedSightVersion(ctx context.Context, bodyVersion *int) (version int, found bool, err error) {
+	if r, ok := httputils.HttpRequest(ctx); ok {
+		if header := r.Header.Get("If-Match"); header != "" {
+			version, anyVersion, err := parseIfMatch(header)
+			if err != nil {
+				return 0, false, err
+			}
+			if anyVersion {
+				return 0, true, nil
+			}
+			return version, true, nil
+		}
+	}
+	if bodyVersion != nil {
+		return *bodyVersion, true, nil
+	}
+	return 0, false, nil
+}
+
+func sightRepoError(err error) error {
+	switch err {
+	case repository.ErrNotFound:
+		return errSightNotFound
+	case repository.ErrVersionConflict:
+		return errSightVersionConflict
+	case repository.ErrUnknownCity:
+		return errUnknownCity
+	case repository.ErrSightExists:
+		return errSightExists
+	}
+	return errSaveSight
+}
+
+func auditSight(ctx context.Context, action string, sightID int) {
+	if request, ok := httputils.HttpRequest(ctx); ok {
+		adminID, _ := middle.CurrentUser(ctx)
+		usecase.Audit(ctx, request, adminID, action, entities.AuditSight(sightID))
+	}
+}
+
+func sightIDFromPath(ctx context.Context) (int, error) {
+	sightID, err := strconv.Atoi(wrapper.GetPathParamsFromCtx(ctx)["id"])
+	if err != nil {
+		logger.Logger().Error("Error while converting string to int", "error", err)
+		return 0, errParsing
+	}
+	return sightID, nil
+}
+
+// Достопримечательность для редактирования, в том числе архивная. Версия - в ETag
+func (h *AdminHandler) GetSight(ctx context.Context, _ entities.Sight) (entities.Sight, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	sightID, err := sightIDFromPath(ctx)
+	if err != nil {
+		return entities.Sight{}, err
+	}
+
+	sight, err := repository.NewSightRepo(db).GetSightForAdmin(ctx, sightID)
+	if err == repository.ErrNotFound {
+		return entities.Sight{}, errSightNotFound
+	}
+	if err != nil {
+		return entities.Sight{}, errGetSight
+	}
+
+	setSightETag(ctx, sight)
+	return sight, nil
+}
+
+func (h *AdminHandler) CreateSight(ctx context.Context, requestData entities.Sight) (entities.Sight, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	// пустое тело обёртка не проверяет
+	if err = requestData.Validate(); err != nil {
+		return entities.Sight{}, errors.HttpError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	requestData.Name = strings.TrimSpace(requestData.Name)
+	requestData.Category = strings.TrimSpace(requestData.Category)
+
+	sight, err := repository.NewSightRepo(db).CreateSight(ctx, requestData)
+	if err != nil {
+		return entities.Sight{}, sightRepoError(err)
+	}
+	usecase.ClearSuggestions()
+	auditSight(ctx, entities.AuditSightCreate, sight.ID)
+
+	setSightETag(ctx, sight)
+	return sight, nil
+}
+
+// Изменение полей. Версия обязательна: If-Match или version в теле,
+// если достопримечательность успели изменить - 412
+func (h *AdminHandler) UpdateSight(ctx context.Context, requestData entities.SightPatch) (entities.Sight, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	sightID, err := sightIDFromPath(ctx)
+	if err != nil {
+		return entities.Sight{}, err
+	}
+	version, found, err := requestedSightVersion(ctx, requestData.Version)
+	if err != nil {
+		return entities.Sight{}, err
+	}
+	if !found {
+		return entities.Sight{}, errSightVersionRequired
+	}
+
+	sightsRepo := repository.NewSightRepo(db)
+	current, err := sightsRepo.GetSightForAdmin(ctx, sightID)
+	if err == repository.ErrNotFound {
+		return entities.Sight{}, errSightNotFound
+	}
+	if err != nil {
+		return entities.Sight{}, errGetSight
+	}
+	if version == 0 {
+		version = current.Version
+	}
+	if version != current.Version {
+		return entities.Sight{}, errSightVersionConflict
+	}
+
+	updated := requestData.Apply(current)
+	if err = updated.Validate(); err != nil {
+		return entities.Sight{}, errors.HttpError{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	sight, err := sightsRepo.UpdateSight(ctx, updated, version)
+	if err != nil {
+		return entities.Sight{}, sightRepoError(err)
+	}
+	usecase.ClearSuggestions()
+	auditSight(ctx, entities.AuditSightUpdate, sight.ID)
+
+	setSightETag(ctx, sight)
+	return sight, nil
+}
+
+// Архивная достопримечательность пропадает из списков, поиска и карты,
+// но остаётся в поездках и доступна по id. Версия не обязательна
+func (h *AdminHandler) ArchiveSight(ctx context.Context, requestData entities.SightPatch) (entities.Sight, error) {
+	return h.setSightArchived(ctx, requestData, true)
+}
+
+func (h *AdminHandler) RestoreSight(ctx context.Context, requestData entities.SightPatch) (entities.Sight, error) {
+	return h.setSightArchived(ctx, requestData, false)
+}
+
+func (h *AdminHandler) setSightArchived(ctx context.Context, requestData entities.SightPatch, archived bool) (entities.Sight, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	sightID, err := sightIDFromPath(ctx)
+	if err != nil {
+		return entities.Sight{}, err
+	}
+	version, _, err := requestedSightVersion(ctx, requestData.Version)
+	if err != nil {
+		return entities.Sight{}, err
+	}
+
+	sightsRepo := repository.NewSightRepo(db)
+	var sight entities.Sight
+	action := entities.AuditSightArchive
+	if archived {
+		sight, err = sightsRepo.ArchiveSight(ctx, sightID, version)
+	} else {
+		sight, err = sightsRepo.UnarchiveSight(ctx, sightID, version)
+		action = entities.AuditSightRestore
+	}
+	if err != nil {
+		return entities.Sight{}, sightRepoError(err)
+	}
+	usecase.ClearSuggestions()
+	auditSight(ctx, action, sight.ID)
+
+	setSightETag(ctx, sight)
+	return sight, nil
+}
+
+// Версия в If-Match или теле проверяется, если передана
+func (h *AdminHandler) DeleteSight(ctx context.Context, requestData entities.SightPatch) (entities.Sight, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	sightID, err := sightIDFromPath(ctx)
+	if err != nil {
+		return entities.Sight{}, err
+	}
+	version, _, err := requestedSightVersion(ctx, requestData.Version)
+	if err != nil {
+		return entities.Sight{}, err
+	}
+
+	err = repository.NewSightRepo(db).DeleteSight(sightID, version)
+	if err == repository.ErrNotFound || err == repository.ErrVersionConflict {
+		return entities.Sight{}, sightRepoError(err)
+	}
+	if err != nil {
+		return entities.Sight{}, errDeleteSight
+	}
+	usecase.ClearSuggestions()
+	auditSight(ctx, entities.AuditSightDelete, sightID)
+
+	return entities.Sight{ID: sightID}, nil
+}
@@ -0,0 +1,96 @@
+package delivery
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/httputils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header     string
+		version    int
+		anyVersion bool
+		wantErr    bool
+	}{
+		{header: `"3"`, version: 3},
+		{header: `W/"7"`, version: 7},
+		{header: ` "2", "5"`, version: 2},
+		{header: `*`, anyVersion: true},
+		{header: `3`, wantErr: true},
+		{header: `"abc"`, wantErr: true},
+		{header: `"0"`, wantErr: true},
+		{header: `"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		version, anyVersion, err := parseIfMatch(tt.header)
+		if tt.wantErr {
+			assert.Equal(t, errInvalidIfMatch, err, tt.header)
+			continue
+		}
+		assert.NoError(t, err, tt.header)
+		assert.Equal(t, tt.version, version, tt.header)
+		assert.Equal(t, tt.anyVersion, anyVersion, tt.header)
+	}
+}
+
+func TestRequestedSightVersion(t *testing.T) {
+	withIfMatch := func(header string) context.Context {
+		r := httptest.NewRequest("PATCH", "/admin/sights/1", nil)
+		if header != "" {
+			r.Header.Set("If-Match", header)
+		}
+		return context.WithValue(context.Background(), httputils.HttpRequestKey, r)
+	}
+	bodyVersion := 4
+
+	version, found, err := requestedSightVersion(withIfMatch(`"2"`), &bodyVersion)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 2, version, "If-Match wins over body")
+
+	version, found, err = requestedSightVersion(withIfMatch(""), &bodyVersion)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 4, version)
+
+	version, found, err = requestedSightVersion(withIfMatch("*"), nil)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 0, version)
+
+	_, found, err = requestedSightVersion(withIfMatch(""), nil)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestSightPatchApplyAndValidate(t *testing.T) {
+	current := entities.Sight{ID: 1, Name: "Кремль", Rating: 4.5, CityID: 1, Latitude: 55.75, Longitude: 37.61, Version: 3}
+
+	name := "  Московский Кремль "
+	cityID := 2
+	updated := entities.SightPatch{Name: &name, CityID: &cityID}.Apply(current)
+	assert.Equal(t, "Московский Кремль", updated.Name)
+	assert.Equal(t, 2, updated.CityID)
+	assert.Equal(t, current.Rating, updated.Rating)
+	assert.Equal(t, 3, updated.Version)
+	assert.NoError(t, updated.Validate())
+
+	empty := " "
+	assert.Error(t, entities.SightPatch{Name: &empty}.Apply(current).Validate())
+
+	rating := float32(6)
+	assert.Error(t, entities.SightPatch{Rating: &rating}.Apply(current).Validate())
+
+	latitude := float32(91)
+	assert.Error(t, entities.SightPatch{Latitude: &latitude}.Apply(current).Validate())
+
+	version := 0
+	assert.Error(t, entities.SightPatch{Version: &version}.Validate())
+}
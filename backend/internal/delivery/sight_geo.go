@@ -0,0 +1,238 @@
+package delivery
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"homework_ipl/internal/cluster"
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/http-server/server/db"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/wrapper"
+
+	pkgErrors "github.com/pkg/errors"
+	sightRep "homework_ipl/internal/repository/postgres"
+)
+
+var (
+	errInvalidLocation = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "invalid coordinates",
+	}
+	errBBoxTooLarge = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "bounding box is too large for this zoom",
+	}
+)
+
+// Достопримечательности рядом с точкой: /sights/nearby?lat=55.75&lon=37.62&radius_km=5&limit=20
+func (h *SightsHandler) GetNearbySights(ctx context.Context, _ entities.Sight) (entities.NearbySights, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	queryParams := wrapper.GetQueryParamsFromCtx(ctx)
+	lat, errLat := parseCoordinate(queryParams["lat"], 90)
+	lon, errLon := parseCoordinate(queryParams["lon"], 180)
+	limit, errLimit := parseGeoLimit(queryParams["limit"])
+	if errLat != nil || errLon != nil || errLimit != nil {
+		return entities.NearbySights{}, errInvalidLocation
+	}
+
+	radius := float64(entities.DefaultNearbyRadiusKM)
+	if value := queryParams["radius_km"]; value != "" {
+		radius, err = strconv.ParseFloat(value, 64)
+		if err != nil || !(radius > 0 && radius <= entities.MaxNearbyRadiusKM) {
+			return entities.NearbySights{}, errInvalidLocation
+		}
+	}
+
+	sightsRepo := sightRep.NewSightRepo(db)
+	sights, err := sightsRepo.GetSightsNearby(ctx, lat, lon, radius, limit)
+	if err != nil {
+		return entities.NearbySights{}, errGetSights
+	}
+
+	return entities.NearbySights{Sight: sights}, nil
+}
+
+// Достопримечательности в прямоугольнике карты:
+// /sights/in-bbox?minLat=55.5&minLon=37.3&maxLat=56&maxLon=37.9&limit=100
+func (h *SightsHandler) GetSightsInBBox(ctx context.Context, _ entities.Sight) (entities.NearbySights, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	queryParams := wrapper.GetQueryParamsFromCtx(ctx)
+	box, err := parseBoundingBox(queryParams)
+	if err != nil {
+		logger.Logger().Error("Error while parsing bounding box", "error", err)
+		return entities.NearbySights{}, errInvalidLocation
+	}
+	limit, err := parseGeoLimit(queryParams["limit"])
+	if err != nil {
+		return entities.NearbySights{}, errInvalidLocation
+	}
+
+	sightsRepo := sightRep.NewSightRepo(db)
+	sights, err := sightsRepo.GetSightsInBBox(ctx, box, limit)
+	if err != nil {
+		return entities.NearbySights{}, errGetSights
+	}
+
+	return entities.NearbySights{Sight: sights}, nil
+}
+
+// Широта или долгота в пределах [-bound, bound], параметр обязателен
+func parseCoordinate(value string, bound float64) (float64, error) {
+	coordinate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(coordinate) || coordinate < -bound || coordinate > bound {
+		return 0, pkgErrors.Errorf("coordinate %v is out of range", coordinate)
+	}
+	return coordinate, nil
+}
+
+func parseGeoLimit(value string) (int, error) {
+	if value == "" {
+		return entities.DefaultSightsLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if limit < 1 || limit > entities.MaxSightsLimit {
+		return 0, pkgErrors.Errorf("limit must be between 1 and %d", entities.MaxSightsLimit)
+	}
+	return limit, nil
+}
+
+func parseBoundingBox(queryParams map[string]string) (entities.BoundingBox, error) {
+	var box entities.BoundingBox
+	var err error
+
+	if box.MinLat, err = parseCoordinate(queryParams["minLat"], 90); err != nil {
+		return entities.BoundingBox{}, err
+	}
+	if box.MaxLat, err = parseCoordinate(queryParams["maxLat"], 90); err != nil {
+		return entities.BoundingBox{}, err
+	}
+	if box.MinLon, err = parseCoordinate(queryParams["minLon"], 180); err != nil {
+		return entities.BoundingBox{}, err
+	}
+	if box.MaxLon, err = parseCoordinate(queryParams["maxLon"], 180); err != nil {
+		return entities.BoundingBox{}, err
+	}
+	// по долготе minLon > maxLon допустимо - переход через 180-й меридиан
+	if box.MinLat > box.MaxLat {
+		return entities.BoundingBox{}, pkgErrors.New("minLat is greater than maxLat")
+	}
+	return box, nil
+}
+
+// Кластеры достопримечательностей для карты:
+// /sights/clusters?bbox=minLon,minLat,maxLon,maxLat&zoom=5
+func (h *SightsHandler) GetSightClusters(ctx context.Context, _ entities.Sight) (entities.SightClusters, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	queryParams := wrapper.GetQueryParamsFromCtx(ctx)
+	box, err := parseBBoxParam(queryParams["bbox"])
+	if err != nil {
+		logger.Logger().Error("Error while parsing bounding box", "error", err)
+		return entities.SightClusters{}, errInvalidLocation
+	}
+	zoom, err := strconv.Atoi(queryParams["zoom"])
+	if err != nil || zoom < 0 || zoom > cluster.MaxZoom {
+		return entities.SightClusters{}, errInvalidLocation
+	}
+
+	if !bboxFitsZoom(box, zoom) {
+		return entities.SightClusters{}, errBBoxTooLarge
+	}
+
+	sightsRepo := sightRep.NewSightRepo(db)
+	sights, err := sightsRepo.GetSightPointsInBBox(ctx, box)
+	if err != nil {
+		return entities.SightClusters{}, errGetSights
+	}
+
+	points := make([]cluster.Point, 0, len(sights))
+	for _, s := range sights {
+		points = append(points, cluster.Point{
+			ID:     s.ID,
+			Lat:    float64(s.Latitude),
+			Lon:    float64(s.Longitude),
+			Weight: float64(s.Rating),
+		})
+	}
+
+	// кластеры отсортированы по убыванию размера, отдаём самые крупные
+	clusters := cluster.Build(points, zoom)
+	truncated := len(clusters) > entities.MaxSightClusters
+	if truncated {
+		clusters = clusters[:entities.MaxSightClusters]
+	}
+
+	ids := make([]int, 0, len(clusters))
+	for _, c := range clusters {
+		ids = append(ids, c.Representative.ID)
+	}
+	representatives, err := sightsRepo.GetGeoSightsByIDs(ctx, ids)
+	if err != nil {
+		return entities.SightClusters{}, errGetSights
+	}
+	sightByID := make(map[int]entities.Sight, len(representatives))
+	for _, s := range representatives {
+		sightByID[s.ID] = s
+	}
+
+	result := make([]entities.SightCluster, 0, len(clusters))
+	for _, c := range clusters {
+		result = append(result, entities.SightCluster{
+			Lat:   c.Lat,
+			Lon:   c.Lon,
+			Count: c.Count,
+			Sight: sightByID[c.Representative.ID],
+		})
+	}
+
+	return entities.SightClusters{Zoom: zoom, Clusters: result, Truncated: truncated}, nil
+}
+
+// Прямоугольник не больше cluster.MaxSpan на этом зуме, долгота - с учётом перехода через 180-й меридиан
+func bboxFitsZoom(box entities.BoundingBox, zoom int) bool {
+	lonSpan := box.MaxLon - box.MinLon
+	if box.MinLon > box.MaxLon {
+		lonSpan += 360
+	}
+	maxSpan := cluster.MaxSpan(zoom)
+	return lonSpan <= maxSpan && box.MaxLat-box.MinLat <= maxSpan
+}
+
+// bbox в порядке GeoJSON: minLon,minLat,maxLon,maxLat
+func parseBBoxParam(value string) (entities.BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return entities.BoundingBox{}, pkgErrors.Errorf("bbox must have 4 values, got %q", value)
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parseBoundingBox(map[string]string{
+		"minLon": parts[0],
+		"minLat": parts[1],
+		"maxLon": parts[2],
+		"maxLat": parts[3],
+	})
+}
@@ -0,0 +1,46 @@
+package delivery
+
+import (
+	"testing"
+
+	"homework_ipl/internal/cluster"
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBBoxFitsZoom(t *testing.T) {
+	world := entities.BoundingBox{MinLat: -85, MinLon: -180, MaxLat: 85, MaxLon: 180}
+	moscow := entities.BoundingBox{MinLat: 55.70, MinLon: 37.55, MaxLat: 55.80, MaxLon: 37.70}
+
+	tests := []struct {
+		name string
+		box  entities.BoundingBox
+		zoom int
+		want bool
+	}{
+		{name: "world at zoom 0", box: world, zoom: 0, want: true},
+		{name: "world at zoom 5", box: world, zoom: 5, want: false},
+		{name: "world without clustering", box: world, zoom: cluster.MaxClusterZoom, want: false},
+		{name: "city at zoom 12", box: moscow, zoom: 12, want: true},
+		{name: "city without clustering", box: moscow, zoom: cluster.MaxClusterZoom, want: false},
+		{
+			name: "across the antimeridian",
+			box:  entities.BoundingBox{MinLat: 60, MinLon: 179, MaxLat: 61, MaxLon: -179},
+			zoom: 10,
+			want: true,
+		},
+		{
+			name: "almost the whole longitude range",
+			box:  entities.BoundingBox{MinLat: 60, MinLon: -179, MaxLat: 61, MaxLon: 179},
+			zoom: 10,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, bboxFitsZoom(tt.box, tt.zoom))
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/http-server/server/db"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/sightimport"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/logger"
+	"homework_ipl/utils/middle"
+
+	pkgErrors "github.com/pkg/errors"
+)
+
+// Размер файла импорта
+const maxImportFileBytes = 20 << 20
+
+var (
+	errImportFile = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "invalid import file",
+	}
+	errImportFormat = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "unknown import format, use csv or geojson",
+	}
+	errImportSights = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed importing sights",
+	}
+)
+
+// Импорт достопримечательностей: multipart-форма с полем file (.csv или .geojson).
+// ?dry_run=true - только отчёт, ?format= - если по расширению формат не понять.
+// При ошибках в строках ничего не записывается и отчёт возвращается с кодом 422
+func (h *AdminHandler) ImportSights(w http.ResponseWriter, r *http.Request) {
+	logger := logger.Logger()
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileBytes)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		logger.Error("Error while reading import file", "error", err)
+		errors.WriteHttpError(errImportFile, w)
+		return
+	}
+	defer file.Close()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = sightimport.FormatFromFilename(header.Filename)
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true" || r.URL.Query().Get("dry_run") == "1"
+
+	report, err := usecase.ImportSights(r.Context(), repository.NewImportRepo(db), file, format, dryRun)
+	switch {
+	case err == nil:
+	case pkgErrors.Is(err, sightimport.ErrUnknownFormat):
+		errors.WriteHttpError(errImportFormat, w)
+		return
+	case pkgErrors.Is(err, sightimport.ErrMalformedFile):
+		logger.Error("Malformed import file", "name", header.Filename, "error", err)
+		errors.WriteHttpError(errors.HttpError{Code: http.StatusBadRequest, Message: err.Error()}, w)
+		return
+	default:
+		errors.WriteHttpError(errImportSights, w)
+		return
+	}
+
+	if report.Applied {
+		adminID, _ := middle.CurrentUser(r.Context())
+		usecase.Audit(r.Context(), r, adminID, entities.AuditSightImport, "file:"+header.Filename)
+	}
+
+	rawJSON, err := json.Marshal(report)
+	if err != nil {
+		logger.Error("Error encoding import report", "error", err)
+		errors.WriteHttpError(errImportSights, w)
+		return
+	}
+
+	status := http.StatusOK
+	if len(report.Errors) > 0 && !dryRun {
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(rawJSON)
+}
@@ -0,0 +1,240 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/http-server/server/db"
+	"homework_ipl/internal/password"
+	repository "homework_ipl/internal/repository/postgres"
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/httputils"
+	"homework_ipl/utils/logger"
+)
+
+// Двухфакторная аутентификация: подключение в профиле и второй шаг входа
+type TwoFactorHandler struct{}
+
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+var (
+	errTwoFactor = errors.HttpError{
+		Code:    http.StatusInternalServerError,
+		Message: "failed two-factor authentication",
+	}
+	errTOTPAlreadyEnabled = errors.HttpError{
+		Code:    http.StatusConflict,
+		Message: "two-factor authentication is already enabled",
+	}
+	errTOTPNotEnrolled = errors.HttpError{
+		Code:    http.StatusBadRequest,
+		Message: "two-factor authentication is not enabled",
+	}
+	errInvalidTOTPCode = errors.HttpError{
+		Code:    http.StatusUnauthorized,
+		Message: "invalid two-factor code",
+	}
+	errIncorrectPassword = errors.HttpError{
+		Code:    http.StatusUnauthorized,
+		Message: "incorrect password",
+	}
+	errInvalidChallenge = errors.HttpError{
+		Code:    http.StatusUnauthorized,
+		Message: "invalid or expired two-factor challenge",
+	}
+)
+
+// Второй шаг входа: challenge из ответа /login и код из приложения
+// (или код восстановления). Попытки считаются тем же лимитером, что и пароли
+func (h *TwoFactorHandler) Login(ctx context.Context, requestData entities.TwoFactorCode) (UserResponse, error) {
+	logger := logger.Logger()
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	responseWriter, ok := httputils.ContextWriter(ctx)
+	if !ok {
+		return UserResponse{}, errInternal
+	}
+	request, ok := httputils.HttpRequest(ctx)
+	if !ok {
+		return UserResponse{}, errInternal
+	}
+
+	twoFactor := usecase.NewTwoFactor(repository.NewTOTPRepo(db))
+	userID, err := twoFactor.ParseChallenge(requestData.Challenge)
+	if err != nil {
+		return UserResponse{}, errInvalidChallenge
+	}
+
+	userRepo := repository.NewUserRepo(db)
+	user, err := userRepo.GetUserByID(userID)
+	if err != nil {
+		return UserResponse{}, errInvalidChallenge
+	}
+
+	ip := usecase.ClientIP(request)
+	retryAfter, err := usecase.Logins.RetryAfter(ctx, user.Email, ip)
+	if err != nil {
+		return UserResponse{}, errInternal
+	}
+	if retryAfter > 0 {
+		return UserResponse{}, tooManyAttempts(responseWriter, retryAfter)
+	}
+
+	err = twoFactor.Verify(ctx, userID, requestData.Code)
+	if err == usecase.ErrInvalidTOTPCode {
+		usecase.Audit(ctx, request, 0, entities.AuditLoginFailed, entities.AuditUser(user.ID))
+		lockout, limitErr := usecase.Logins.RegisterFailure(ctx, user.Email, ip)
+		if limitErr != nil {
+			logger.Error("Error while registering login failure", "error", limitErr)
+		}
+		if lockout > 0 {
+			return UserResponse{}, tooManyAttempts(responseWriter, lockout)
+		}
+		return UserResponse{}, errInvalidTOTPCode
+	}
+	if err == usecase.ErrTOTPNotEnrolled {
+		return UserResponse{}, errInvalidChallenge
+	}
+	if err != nil {
+		logger.Error("Error while verifying 2FA code", "error", err)
+		return UserResponse{}, errTwoFactor
+	}
+
+	if err = restoreAccount(ctx, request, userRepo, user.ID); err != nil {
+		return UserResponse{}, err
+	}
+
+	if err = usecase.Logins.RegisterSuccess(ctx, user.Email); err != nil {
+		logger.Error("Error while resetting login failures", "error", err)
+	}
+
+	if err = usecase.SetSession(responseWriter, request, user.ID, user.Role); err != nil {
+		return UserResponse{}, errSetSession
+	}
+	usecase.Audit(ctx, request, user.ID, entities.AuditLogin, entities.AuditUser(user.ID))
+
+	return UserResponse{ID: user.ID, Username: user.Email}, nil
+}
+
+func (h *TwoFactorHandler) Status(ctx context.Context, _ entities.TwoFactorCode) (entities.TOTP, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	userID, err := requirePathProfileOwner(ctx)
+	if err != nil {
+		return entities.TOTP{}, err
+	}
+
+	twoFactor := usecase.NewTwoFactor(repository.NewTOTPRepo(db))
+	enabled, err := twoFactor.Enabled(ctx, userID)
+	if err != nil {
+		return entities.TOTP{}, errTwoFactor
+	}
+
+	return entities.TOTP{Enabled: enabled}, nil
+}
+
+// Выдаёт секрет и otpauth-ссылку, 2FA ещё не включена до Confirm
+func (h *TwoFactorHandler) Enroll(ctx context.Context, _ entities.TwoFactorCode) (TOTPEnrollResponse, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	userID, err := requirePathProfileOwner(ctx)
+	if err != nil {
+		return TOTPEnrollResponse{}, err
+	}
+
+	userRepo := repository.NewUserRepo(db)
+	user, err := userRepo.GetUserByID(userID)
+	if err != nil {
+		return TOTPEnrollResponse{}, errTwoFactor
+	}
+
+	twoFactor := usecase.NewTwoFactor(repository.NewTOTPRepo(db))
+	secret, uri, err := twoFactor.Enroll(ctx, user)
+	if err == usecase.ErrTOTPAlreadyEnabled {
+		return TOTPEnrollResponse{}, errTOTPAlreadyEnabled
+	}
+	if err != nil {
+		return TOTPEnrollResponse{}, errTwoFactor
+	}
+
+	return TOTPEnrollResponse{Secret: secret, URI: uri}, nil
+}
+
+// Включает 2FA после проверки первого кода, возвращает коды восстановления
+func (h *TwoFactorHandler) Confirm(ctx context.Context, requestData entities.TwoFactorCode) (RecoveryCodesResponse, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	userID, err := requirePathProfileOwner(ctx)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+
+	twoFactor := usecase.NewTwoFactor(repository.NewTOTPRepo(db))
+	codes, err := twoFactor.Confirm(ctx, userID, requestData.Code)
+	switch err {
+	case nil:
+		return RecoveryCodesResponse{RecoveryCodes: codes}, nil
+	case usecase.ErrTOTPNotEnrolled:
+		return RecoveryCodesResponse{}, errTOTPNotEnrolled
+	case usecase.ErrTOTPAlreadyEnabled:
+		return RecoveryCodesResponse{}, errTOTPAlreadyEnabled
+	case usecase.ErrInvalidTOTPCode:
+		return RecoveryCodesResponse{}, errInvalidTOTPCode
+	}
+	return RecoveryCodesResponse{}, errTwoFactor
+}
+
+// Отключение 2FA: нужны пароль и действующий код (или код восстановления)
+func (h *TwoFactorHandler) Disable(ctx context.Context, requestData entities.TwoFactorCode) (entities.TOTP, error) {
+	db, err := db.GetPostgres()
+	if err != nil {
+		logger.Logger().Error(err.Error())
+	}
+
+	userID, err := requirePathProfileOwner(ctx)
+	if err != nil {
+		return entities.TOTP{}, err
+	}
+
+	userRepo := repository.NewUserRepo(db)
+	hash, err := userRepo.GetHashPassword(userID)
+	if err != nil {
+		return entities.TOTP{}, errTwoFactor
+	}
+	if match, err := password.Verify(requestData.Passwrd, hash); err != nil || !match {
+		return entities.TOTP{}, errIncorrectPassword
+	}
+
+	twoFactor := usecase.NewTwoFactor(repository.NewTOTPRepo(db))
+	err = twoFactor.Disable(ctx, userID, requestData.Code)
+	switch err {
+	case nil:
+		return entities.TOTP{Enabled: false}, nil
+	case usecase.ErrTOTPNotEnrolled:
+		return entities.TOTP{}, errTOTPNotEnrolled
+	case usecase.ErrInvalidTOTPCode:
+		return entities.TOTP{}, errInvalidTOTPCode
+	}
+	return entities.TOTP{}, errTwoFactor
+}
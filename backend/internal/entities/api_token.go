@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Права персональных API-токенов. Токен не даёт прав админки и модератора,
+// какая бы роль ни была у его владельца
+const (
+	ScopeRead           = "read"
+	ScopeWriteJourneys  = "write:journeys"
+	ScopeWriteComments  = "write:comments"
+	maxAPITokenNameSize = 100
+)
+
+var scopes = map[string]bool{
+	ScopeRead:          true,
+	ScopeWriteJourneys: true,
+	ScopeWriteComments: true,
+}
+
+// Персональный токен для скриптов и мобильного приложения.
+// В бд хранится только хэш, сам токен показывается один раз при создании
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type APITokens struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+// Запрос на создание токена, expires_at не обязателен (бессрочный токен)
+type APITokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (h APIToken) Validate() error {
+	return nil
+}
+
+func (h APITokenRequest) Validate() error {
+	if h.Name == "" || len(h.Name) > maxAPITokenNameSize {
+		return errors.New("token name is required and must be at most 100 characters")
+	}
+	if len(h.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, scope := range h.Scopes {
+		if !scopes[scope] {
+			return errors.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package entities
+
+import (
+	"strconv"
+	"time"
+)
+
+// Действия, которые пишутся в журнал аудита
+const (
+	AuditLogin          = "login"
+	AuditLoginFailed    = "login_failed"
+	AuditPasswordChange = "password_change"
+	AuditPasswordReset  = "password_reset"
+	AuditProfileDelete  = "profile_delete"
+	AuditCommentDelete  = "comment_delete"
+	AuditAvatarUpload   = "avatar_upload"
+	AuditRoleChange     = "role_change"
+	AuditAccountRestore = "account_restore"
+	AuditSightImport    = "sight_import"
+	AuditSightCreate    = "sight_create"
+	AuditSightUpdate    = "sight_update"
+	AuditSightArchive   = "sight_archive"
+	AuditSightRestore   = "sight_restore"
+	AuditSightDelete    = "sight_delete"
+)
+
+// Событие журнала аудита. ActorID == 0 - действие анонимного пользователя
+// (например, неудачный вход), Target - над чем совершено действие: "user:5", "comment:12"
+type AuditEvent struct {
+	ID        int       `json:"id"`
+	ActorID   int       `json:"actor_id,omitempty"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target,omitempty"`
+	IP        string    `json:"ip"`
+	RequestID string    `json:"request_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditEvents struct {
+	Events []AuditEvent `json:"events"`
+}
+
+func (h AuditEvent) Validate() error {
+	return nil
+}
+
+// Фильтр для выборки из журнала, нулевые поля не учитываются.
+// UserID ищет события, где пользователь - и автор, и цель
+type AuditFilter struct {
+	UserID int
+	Action string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+func AuditUser(userID int) string {
+	return "user:" + strconv.Itoa(userID)
+}
+
+func AuditComment(commentID int) string {
+	return "comment:" + strconv.Itoa(commentID)
+}
+
+func AuditSight(sightID int) string {
+	return "sight:" + strconv.Itoa(sightID)
+}
+
+func AuditEmail(email string) string {
+	return "email:" + email
+}
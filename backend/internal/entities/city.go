@@ -0,0 +1,21 @@
+package entities
+
+import "github.com/pkg/errors"
+
+type City struct {
+	ID        int    `json:"id"`
+	City      string `json:"city"`
+	CountryID int    `json:"countryID"`
+	Country   string `json:"country"`
+}
+
+type Cities struct {
+	City []City `json:"cities"`
+}
+
+func (h City) Validate() error {
+	if h.City == "" || h.CountryID <= 0 {
+		return errors.New("city name and country are required")
+	}
+	return nil
+}
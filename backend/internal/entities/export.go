@@ -0,0 +1,58 @@
+package entities
+
+import "time"
+
+// Статусы выгрузки персональных данных
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// Выгрузка всех данных пользователя в ZIP. Архив собирается в фоне,
+// после ExpiresAt файл удаляется и ссылка перестаёт работать
+type DataExport struct {
+	ID          string     `json:"id"`
+	UserID      int        `json:"-"`
+	Status      string     `json:"status"`
+	FilePath    string     `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+func (h DataExport) Validate() error {
+	return nil
+}
+
+// Содержимое архива, каждое поле - отдельный json-файл
+type UserExportData struct {
+	Account    ExportAccount    `json:"account"`
+	Profile    UserProfile      `json:"profile"`
+	Feedback   []Comment        `json:"feedback"`
+	Journeys   []ExportJourney  `json:"journeys"`
+	Identities []ExportIdentity `json:"identities"`
+	APITokens  []APIToken       `json:"api_tokens"`
+	Audit      []AuditEvent     `json:"audit"`
+}
+
+type ExportAccount struct {
+	ID               int    `json:"id"`
+	Email            string `json:"email"`
+	Role             string `json:"role"`
+	EmailVerified    bool   `json:"email_verified"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+}
+
+// Поездка вместе с достопримечательностями в порядке priority
+type ExportJourney struct {
+	Journey
+	Sights []JourneySight `json:"sights"`
+}
+
+type ExportIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package entities
+
+// Строка файла импорта достопримечательностей (CSV или GeoJSON).
+// Line - номер строки CSV или объекта GeoJSON, начиная с 1, для отчёта об ошибках
+type ImportRow struct {
+	Line        int
+	Name        string
+	Description string
+	City        string
+	Country     string
+	Rating      float64
+	Category    string
+	Latitude    *float64
+	Longitude   *float64
+	Images      []string
+}
+
+// Действие импорта над достопримечательностью
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportUnchanged = "unchanged"
+)
+
+type ImportChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type ImportRowResult struct {
+	Line    int            `json:"line"`
+	SightID int            `json:"sight_id,omitempty"`
+	Name    string         `json:"name"`
+	City    string         `json:"city"`
+	Action  string         `json:"action"`
+	Changes []ImportChange `json:"changes,omitempty"`
+}
+
+type ImportRowError struct {
+	Line   int      `json:"line"`
+	Errors []string `json:"errors"`
+}
+
+// Отчёт импорта. При ошибках в строках ничего не записывается,
+// при DryRun - тоже, но отчёт показывает, что было бы изменено
+type ImportReport struct {
+	DryRun bool `json:"dry_run"`
+	// Изменения записаны в базу
+	Applied          bool              `json:"applied"`
+	Created          int               `json:"created"`
+	Updated          int               `json:"updated"`
+	Unchanged        int               `json:"unchanged"`
+	CitiesCreated    []string          `json:"cities_created,omitempty"`
+	CountriesCreated []string          `json:"countries_created,omitempty"`
+	Rows             []ImportRowResult `json:"rows"`
+	Errors           []ImportRowError  `json:"errors,omitempty"`
+}
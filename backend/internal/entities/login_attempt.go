@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+// Счётчик неудачных попыток входа по ключу (email или IP)
+type LoginAttempt struct {
+	Key         string    `json:"key"`
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until"`
+}
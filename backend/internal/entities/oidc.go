@@ -0,0 +1,18 @@
+package entities
+
+// Аккаунт у стороннего провайдера (OpenID Connect), привязанный к пользователю
+type OIDCIdentity struct {
+	Provider string `json:"provider"`
+	// Постоянный айди пользователя у провайдера (claim sub)
+	Subject       string `json:"-"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"-"`
+}
+
+type OIDCProviders struct {
+	Providers []string `json:"providers"`
+}
+
+func (h OIDCProviders) Validate() error {
+	return nil
+}
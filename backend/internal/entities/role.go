@@ -0,0 +1,52 @@
+package entities
+
+import "github.com/pkg/errors"
+
+// Роли пользователей, хранятся в user_data.role
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+type Permission string
+
+const (
+	PermDeleteAnyComment Permission = "comments:delete_any"
+	PermManageSights     Permission = "sights:manage"
+	PermManageCities     Permission = "cities:manage"
+	PermManageUsers      Permission = "users:manage"
+	PermViewAudit        Permission = "audit:view"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleUser:      {},
+	RoleModerator: {PermDeleteAnyComment},
+	RoleAdmin:     {PermDeleteAnyComment, PermManageSights, PermManageCities, PermManageUsers, PermViewAudit},
+}
+
+// Запрос на смену роли пользователя
+type UserRole struct {
+	Role string `json:"role"`
+}
+
+func (h UserRole) Validate() error {
+	if !ValidRole(h.Role) {
+		return errors.Errorf("unknown role %q", h.Role)
+	}
+	return nil
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package entities
+
+// Типы подсказок поиска
+const (
+	SuggestionSight   = "sight"
+	SuggestionCity    = "city"
+	SuggestionCountry = "country"
+
+	MaxSuggestions = 10
+)
+
+// Подсказка для строки поиска: по Type и ID фронт понимает, куда вести
+type Suggestion struct {
+	Type  string  `json:"type"`
+	ID    int     `json:"id"`
+	Label string  `json:"label"`
+	Score float64 `json:"-"`
+}
+
+type Suggestions struct {
+	Suggestions []Suggestion `json:"suggestions"`
+}
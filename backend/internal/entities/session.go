@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+// Сессия пользователя (одна запись на каждое устройство/браузер)
+type Session struct {
+	ID        string    `json:"-"`
+	UserID    int       `json:"userID"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+}
@@ -1,5 +1,13 @@
 package entities
 
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 type Sight struct {
 	ID          int     `json:"id"`
 	Rating      float32 `json:"rating"`
@@ -12,12 +20,166 @@ type Sight struct {
 	Path        string  `json:"url"`
 	Latitude    float32 `json:"latitude"`
 	Longitude   float32 `json:"longitude"`
+	Category    string  `json:"category,omitempty"`
+	// Растёт при каждом изменении, отдаётся в ETag админки
+	Version    int        `json:"version,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
 }
 
+const MaxSightNameLength = 255
+
+// Проверка полей, которые задаёт админка. Координаты 0, 0 - без координат
 func (h Sight) Validate() error {
+	name := strings.TrimSpace(h.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if len([]rune(name)) > MaxSightNameLength {
+		return errors.Errorf("name must be at most %d characters", MaxSightNameLength)
+	}
+	if !(h.Rating > 0 && h.Rating <= 5) {
+		return errors.New("rating must be greater than 0 and at most 5")
+	}
+	if h.CityID <= 0 {
+		return errors.New("city is required")
+	}
+	if !(math.Abs(float64(h.Latitude)) <= 90) || !(math.Abs(float64(h.Longitude)) <= 180) {
+		return errors.New("coordinates are out of range")
+	}
+	return nil
+}
+
+// Частичное изменение достопримечательности в админке: nil - поле не меняется.
+// Version - альтернатива заголовку If-Match
+type SightPatch struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Rating      *float32 `json:"rating"`
+	CityID      *int     `json:"cityID"`
+	Latitude    *float32 `json:"latitude"`
+	Longitude   *float32 `json:"longitude"`
+	Category    *string  `json:"category"`
+	Version     *int     `json:"version"`
+}
+
+// Сами поля проверяются Sight.Validate после применения к текущей версии
+func (p SightPatch) Validate() error {
+	if p.Version != nil && *p.Version <= 0 {
+		return errors.New("version must be positive")
+	}
 	return nil
 }
 
+// Применяет изменения к копии достопримечательности
+func (p SightPatch) Apply(sight Sight) Sight {
+	if p.Name != nil {
+		sight.Name = strings.TrimSpace(*p.Name)
+	}
+	if p.Description != nil {
+		sight.Description = *p.Description
+	}
+	if p.Rating != nil {
+		sight.Rating = *p.Rating
+	}
+	if p.CityID != nil {
+		sight.CityID = *p.CityID
+	}
+	if p.Latitude != nil {
+		sight.Latitude = *p.Latitude
+	}
+	if p.Longitude != nil {
+		sight.Longitude = *p.Longitude
+	}
+	if p.Category != nil {
+		sight.Category = strings.TrimSpace(*p.Category)
+	}
+	return sight
+}
+
 type Sights struct {
 	Sight []Sight `json:"sights"`
+	// Курсор следующей страницы, пустой на последней странице
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Сортировки списка достопримечательностей
+const (
+	SortRating = "rating"
+	SortName   = "name"
+	SortNewest = "newest"
+
+	DefaultSightsLimit = 20
+	MaxSightsLimit     = 100
+)
+
+// Параметры GET /sights, нулевые фильтры не применяются.
+// Cursor - непрозрачная строка из NextCursor предыдущей страницы
+type SightFilter struct {
+	Limit     int
+	Cursor    string
+	Sort      string
+	MinRating float64
+	CityID    int
+	CountryID int
+	Category  string
 }
+
+const DefaultSearchLimit = 20
+
+// Найденная достопримечательность. Rank - релевантность запросу,
+// в NameHighlight и Snippet найденные слова обёрнуты в <mark>
+type SightSearchResult struct {
+	Sight
+	Rank          float64 `json:"rank"`
+	NameHighlight string  `json:"name_highlight,omitempty"`
+	Snippet       string  `json:"snippet,omitempty"`
+}
+
+type SightSearchResults struct {
+	Sight []SightSearchResult `json:"sights"`
+}
+
+// Поиск рядом с точкой (/sights/nearby) и в прямоугольнике карты (/sights/in-bbox)
+const (
+	DefaultNearbyRadiusKM = 10
+	MaxNearbyRadiusKM     = 500
+)
+
+// Достопримечательность с расстоянием до точки поиска
+// (для прямоугольника - до его центра)
+type NearbySight struct {
+	Sight
+	DistanceKM float64 `json:"distance_km"`
+}
+
+type NearbySights struct {
+	Sight []NearbySight `json:"sights"`
+}
+
+// Прямоугольник карты. MinLon > MaxLon - прямоугольник пересекает 180-й меридиан
+type BoundingBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// Кластер достопримечательностей на карте (/sights/clusters).
+// Sight - представитель кластера, при Count == 1 - сама достопримечательность
+type SightCluster struct {
+	Lat   float64 `json:"lat"`
+	Lon   float64 `json:"lon"`
+	Count int     `json:"count"`
+	Sight Sight   `json:"sight"`
+}
+
+// Truncated - в прямоугольнике больше MaxSightClusters кластеров, отданы самые крупные.
+// Количество точек в каждом кластере при этом точное
+type SightClusters struct {
+	Zoom      int            `json:"zoom"`
+	Clusters  []SightCluster `json:"clusters"`
+	Truncated bool           `json:"truncated"`
+}
+
+// Сколько кластеров отдаётся за один запрос: больше меток на экране уже не различить
+const MaxSightClusters = 1000
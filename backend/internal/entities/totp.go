@@ -0,0 +1,22 @@
+package entities
+
+// Настройки TOTP пользователя. Пока Enabled == false, секрет выдан,
+// но вход по нему не требуется (ожидает подтверждения кодом)
+type TOTP struct {
+	UserID  int    `json:"-"`
+	Secret  string `json:"-"`
+	Enabled bool   `json:"enabled"`
+	// Номер последнего использованного 30-секундного окна, повторно код из него не принимается
+	LastStep int64 `json:"-"`
+}
+
+// Код из приложения-аутентификатора или одноразовый код восстановления
+type TwoFactorCode struct {
+	Challenge string `json:"challenge,omitempty"`
+	Code      string `json:"code"`
+	Passwrd   string `json:"password,omitempty"`
+}
+
+func (h TwoFactorCode) Validate() error {
+	return nil
+}
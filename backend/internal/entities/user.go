@@ -1,17 +1,33 @@
 package entities
 
 import (
-	"regexp"
-	"sync"
+	"strings"
+
+	"homework_ipl/internal/password"
 
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
 	ID      int    `json:"id"`
 	Email   string `json:"username"`
 	Passwrd string `json:"password"`
+	Role    string `json:"-"`
+	// Неподтверждённые аккаунты не могут писать отзывы и создавать поездки
+	EmailVerified bool `json:"-"`
+}
+
+// Служебный пользователь, которому передаются отзывы окончательно удалённых аккаунтов.
+// Хэш "!" не выдаёт password.Hash, поэтому войти под ним нельзя. Email зарезервирован:
+// ни регистрация, ни вход через провайдера не могут его занять (см. IsReservedEmail)
+const (
+	AnonymousEmail    = "anonymous@deleted.invalid"
+	AnonymousPassword = "!"
+)
+
+// Email служебного пользователя, который нельзя зарегистрировать или привязать
+func IsReservedEmail(email string) bool {
+	return strings.EqualFold(strings.TrimSpace(email), AnonymousEmail)
 }
 
 type UserProfile struct {
@@ -29,81 +45,31 @@ type Password struct {
 	NewPasswrd string `json:"new_password"`
 }
 
+// Запрос на сброс пароля по ссылке из письма
+type PasswordReset struct {
+	Token      string `json:"token"`
+	NewPasswrd string `json:"new_password"`
+}
+
 var (
-	users []User
-	mu    sync.Mutex
+	ErrEmailTaken    = errors.New("email is already registered")
+	ErrUsernameTaken = errors.New("username is already taken")
 )
 
 func (h Password) Validate() error {
 	return nil
 }
 
-func (h User) Validate() error {
+func (h PasswordReset) Validate() error {
 	return nil
 }
 
-func (h UserProfile) Validate() error {
+func (h User) Validate() error {
 	return nil
 }
 
-func init() {
-	testUser := User{
-		ID:      1,
-		Email:   "testuser",
-		Passwrd: "testpassword",
-	}
-
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(testUser.Passwrd), bcrypt.DefaultCost)
-	if err != nil {
-		panic("Failed creating hash password for test user")
-	}
-
-	testUser.Passwrd = string(hashPassword)
-	users = append(users, testUser)
-}
-
-// func GetUserByUsername(username string) (*User, error) {
-// 	for _, user := range users {
-// 		if user.Username == username {
-// 			return &user, nil
-// 		}
-// 	}
-// 	return nil, errors.New("User not found")
-// }
-
-// func IsAuthenticated(username, password string) bool {
-// 	user, err := GetUserByUsername(username)
-// 	if err != nil {
-// 		return false
-// 	}
-
-// 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-// 	return err == nil
-// }
-
-func CreateUser(username, password string) (User, error) {
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return User{}, errors.New("Failed creating hash password")
-	}
-	var lastUser User
-
-	mu.Lock()
-	defer mu.Unlock()
-
-	if len(users) != 0 {
-		lastUser = users[len(users)-1]
-	}
-
-	newUser := User{
-		lastUser.ID + 1,
-		username,
-		string(hashPassword),
-	}
-
-	users = append(users, newUser)
-
-	return newUser, nil
+func (h UserProfile) Validate() error {
+	return nil
 }
 
 func UserDataVerification(username, password string) error {
@@ -111,24 +77,10 @@ func UserDataVerification(username, password string) error {
 		return errors.New("username and password must not be empty")
 	}
 
-	// for _, user := range users {
-	// 	if user.Username == username {
-	// 		return errors.New("username already exists")
-	// 	}
-	// }
-
-	if !ValidatePassword(password) {
-		return errors.New("password is not complex")
-	}
-
-	return nil
+	return ValidatePassword(password)
 }
 
-func ValidatePassword(password string) bool {
-	hasDigit := regexp.MustCompile(`\d`).MatchString(password)
-	hasUppercase := regexp.MustCompile(`[A-Z]`).MatchString(password)
-	hasLowercase := regexp.MustCompile(`[a-z]`).MatchString(password)
-	hasMinLength := len(password) >= 8
-
-	return hasDigit && hasUppercase && hasLowercase && hasMinLength
+// Проверка пароля по политике из конфига (password_policy)
+func ValidatePassword(pw string) error {
+	return password.Validate(pw)
 }
@@ -0,0 +1,255 @@
+// Выгрузка достопримечательностей и поездок в GeoJSON и KML,
+// чтобы их можно было открыть в картографических приложениях
+package geoexport
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/wrapper"
+)
+
+const (
+	GeoJSONContentType = "application/geo+json"
+	KMLContentType     = "application/vnd.google-earth.kml+xml"
+)
+
+// Адрес, с которого отдаются картинки, задаётся в main через Init
+var imageBaseURL = "http://localhost:3000"
+
+func Init(cfg config.GeoExport) {
+	if cfg.PublicURL != "" {
+		imageBaseURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	}
+}
+
+// В бд путь картинки относительный ("public/1.jpg"), в выгрузке нужна полная ссылка
+func imageURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return imageBaseURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Document - то, что выгружается: набор достопримечательностей
+// и, для поездки, маршрут через них в заданном порядке
+type Document struct {
+	Name        string
+	Description string
+	Sights      []entities.Sight
+	Route       bool
+}
+
+// Форматы для wrapper.Wrapper: ?format=geojson и ?format=kml
+func Formats[Resp any](toDocument func(response Resp) Document) []wrapper.Format[Resp] {
+	return []wrapper.Format[Resp]{
+		{
+			Name:        "geojson",
+			ContentType: GeoJSONContentType,
+			Encode:      func(response Resp) ([]byte, error) { return GeoJSON(toDocument(response)) },
+		},
+		{
+			Name:        "kml",
+			ContentType: KMLContentType,
+			Encode:      func(response Resp) ([]byte, error) { return KML(toDocument(response)) },
+		},
+	}
+}
+
+// Достопримечательность с номером в исходном списке, начиная с 1
+type orderedSight struct {
+	entities.Sight
+	Order int
+}
+
+// Достопримечательности без координат на карту не попадают, но номер
+// остальных не сдвигается: в поездке он совпадает с порядком journey_sight.priority
+func located(sights []entities.Sight) []orderedSight {
+	result := make([]orderedSight, 0, len(sights))
+	for i, s := range sights {
+		if s.Latitude != 0 || s.Longitude != 0 {
+			result = append(result, orderedSight{Sight: s, Order: i + 1})
+		}
+	}
+	return result
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	ID         int             `json:"id,omitempty"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// В GeoJSON координаты идут в порядке долгота, широта
+func lonLat(s entities.Sight) []float64 {
+	return []float64{float64(s.Longitude), float64(s.Latitude)}
+}
+
+func sightProperties(s entities.Sight) map[string]any {
+	return map[string]any{
+		"name":      s.Name,
+		"rating":    s.Rating,
+		"city":      s.City,
+		"country":   s.Country,
+		"image_url": imageURL(s.Path),
+	}
+}
+
+// FeatureCollection: маршрут LineString (если есть) и точки достопримечательностей.
+// У точек маршрута в свойстве order - их место в поездке, включая пропущенные без координат
+func GeoJSON(doc Document) ([]byte, error) {
+	sights := located(doc.Sights)
+	collection := geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+
+	if doc.Route && len(sights) > 1 {
+		line := make([][]float64, 0, len(sights))
+		for _, s := range sights {
+			line = append(line, lonLat(s.Sight))
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{Type: "LineString", Coordinates: line},
+			Properties: map[string]any{
+				"name":        doc.Name,
+				"description": doc.Description,
+			},
+		})
+	}
+
+	for _, s := range sights {
+		properties := sightProperties(s.Sight)
+		if doc.Route {
+			properties["order"] = s.Order
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			ID:         s.ID,
+			Geometry:   geoJSONGeometry{Type: "Point", Coordinates: lonLat(s.Sight)},
+			Properties: properties,
+		})
+	}
+
+	return json.Marshal(collection)
+}
+
+type kmlRoot struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name        string         `xml:"name,omitempty"`
+	Description string         `xml:"description,omitempty"`
+	Placemarks  []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	ID           string          `xml:"id,attr,omitempty"`
+	Name         string          `xml:"name"`
+	Description  string          `xml:"description,omitempty"`
+	ExtendedData *kmlData        `xml:"ExtendedData,omitempty"`
+	Point        *kmlCoordinates `xml:"Point,omitempty"`
+	LineString   *kmlCoordinates `xml:"LineString,omitempty"`
+}
+
+type kmlData struct {
+	Data []kmlDataItem `xml:"Data"`
+}
+
+type kmlDataItem struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlCoordinates struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// В KML координаты тоже "долгота,широта", точки разделяются пробелом
+func kmlCoordinate(s entities.Sight) string {
+	return strconv.FormatFloat(float64(s.Longitude), 'f', -1, 32) + "," +
+		strconv.FormatFloat(float64(s.Latitude), 'f', -1, 32)
+}
+
+func sightPlacemark(s entities.Sight, order int) kmlPlacemark {
+	data := []kmlDataItem{
+		{Name: "rating", Value: strconv.FormatFloat(float64(s.Rating), 'f', -1, 32)},
+		{Name: "city", Value: s.City},
+		{Name: "country", Value: s.Country},
+		{Name: "image_url", Value: imageURL(s.Path)},
+	}
+	if order > 0 {
+		data = append(data, kmlDataItem{Name: "order", Value: strconv.Itoa(order)})
+	}
+
+	var location []string
+	for _, part := range []string{s.City, s.Country} {
+		if part != "" {
+			location = append(location, part)
+		}
+	}
+	description := fmt.Sprintf("Рейтинг: %.1f", s.Rating)
+	if len(location) > 0 {
+		description += ", " + strings.Join(location, ", ")
+	}
+
+	return kmlPlacemark{
+		ID:           "sight-" + strconv.Itoa(s.ID),
+		Name:         s.Name,
+		Description:  description,
+		ExtendedData: &kmlData{Data: data},
+		Point:        &kmlCoordinates{Coordinates: kmlCoordinate(s)},
+	}
+}
+
+// KML-документ с теми же данными, что и GeoJSON
+func KML(doc Document) ([]byte, error) {
+	sights := located(doc.Sights)
+	document := kmlDocument{Name: doc.Name, Description: doc.Description}
+
+	if doc.Route && len(sights) > 1 {
+		coordinates := make([]string, 0, len(sights))
+		for _, s := range sights {
+			coordinates = append(coordinates, kmlCoordinate(s.Sight))
+		}
+		name := doc.Name
+		if name == "" {
+			name = "Маршрут"
+		}
+		document.Placemarks = append(document.Placemarks, kmlPlacemark{
+			Name:       name,
+			LineString: &kmlCoordinates{Coordinates: strings.Join(coordinates, " ")},
+		})
+	}
+
+	for _, s := range sights {
+		order := 0
+		if doc.Route {
+			order = s.Order
+		}
+		document.Placemarks = append(document.Placemarks, sightPlacemark(s.Sight, order))
+	}
+
+	body, err := xml.MarshalIndent(kmlRoot{Xmlns: "http://www.opengis.net/kml/2.2", Document: document}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,111 @@
+package geoexport
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var trip = Document{
+	Name:        "Москва за день",
+	Description: "Центр",
+	Route:       true,
+	Sights: []entities.Sight{
+		{ID: 3, Name: "Кремль", Rating: 4.9, City: "Москва", Country: "Россия", Path: "public/kremlin.jpg", Latitude: 55.752, Longitude: 37.6175},
+		{ID: 7, Name: "Без координат"},
+		{ID: 1, Name: "Парк Горького", Rating: 4.7, City: "Москва", Country: "Россия", Latitude: 55.7298, Longitude: 37.6011},
+	},
+}
+
+func TestGeoJSONRoute(t *testing.T) {
+	body, err := GeoJSON(trip)
+	require.NoError(t, err)
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			ID       int `json:"id"`
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	require.NoError(t, json.Unmarshal(body, &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	require.Len(t, collection.Features, 3, "route and two located sights")
+
+	route := collection.Features[0]
+	assert.Equal(t, "LineString", route.Geometry.Type)
+	var line [][]float64
+	require.NoError(t, json.Unmarshal(route.Geometry.Coordinates, &line))
+	require.Len(t, line, 2)
+	assert.InDelta(t, 37.6175, line[0][0], 1e-4, "longitude goes first")
+	assert.InDelta(t, 55.752, line[0][1], 1e-4)
+
+	kremlin := collection.Features[1]
+	assert.Equal(t, "Point", kremlin.Geometry.Type)
+	assert.Equal(t, 3, kremlin.ID)
+	assert.Equal(t, "Кремль", kremlin.Properties["name"])
+	assert.Equal(t, "Москва", kremlin.Properties["city"])
+	assert.Equal(t, "Россия", kremlin.Properties["country"])
+	assert.Equal(t, "http://localhost:3000/public/kremlin.jpg", kremlin.Properties["image_url"])
+	assert.EqualValues(t, 1, kremlin.Properties["order"])
+	// достопримечательность без координат пропущена, но номер следующей - её место в поездке
+	assert.EqualValues(t, 3, collection.Features[2].Properties["order"])
+	assert.Equal(t, "", collection.Features[2].Properties["image_url"], "no image")
+}
+
+func TestGeoJSONWithoutRoute(t *testing.T) {
+	body, err := GeoJSON(Document{Sights: trip.Sights[:1]})
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "LineString")
+	assert.NotContains(t, string(body), "order")
+
+	body, err = GeoJSON(Document{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[]}`, string(body))
+}
+
+func TestKMLRoute(t *testing.T) {
+	body, err := KML(trip)
+	require.NoError(t, err)
+
+	var root kmlRoot
+	require.NoError(t, xml.Unmarshal(body, &root))
+	assert.Equal(t, "Москва за день", root.Document.Name)
+	require.Len(t, root.Document.Placemarks, 3)
+
+	route := root.Document.Placemarks[0]
+	require.NotNil(t, route.LineString)
+	assert.Equal(t, "37.6175,55.752 37.6011,55.7298", route.LineString.Coordinates)
+
+	kremlin := root.Document.Placemarks[1]
+	assert.Equal(t, "sight-3", kremlin.ID)
+	require.NotNil(t, kremlin.Point)
+	assert.Equal(t, "37.6175,55.752", kremlin.Point.Coordinates)
+	assert.Contains(t, kremlin.Description, "Москва, Россия")
+	assert.Contains(t, kremlin.ExtendedData.Data, kmlDataItem{Name: "image_url", Value: "http://localhost:3000/public/kremlin.jpg"})
+	assert.Contains(t, kremlin.ExtendedData.Data, kmlDataItem{Name: "order", Value: "1"})
+
+	park := root.Document.Placemarks[2]
+	assert.Contains(t, park.ExtendedData.Data, kmlDataItem{Name: "order", Value: "3"})
+}
+
+func TestImageURL(t *testing.T) {
+	prev := imageBaseURL
+	defer func() { imageBaseURL = prev }()
+
+	Init(config.GeoExport{PublicURL: "https://tudasuda.ru/"})
+	assert.Equal(t, "https://tudasuda.ru/public/1.jpg", imageURL("public/1.jpg"))
+	assert.Equal(t, "https://tudasuda.ru/public/avatars/1.png", imageURL("/public/avatars/1.png"))
+	assert.Equal(t, "https://cdn.example.com/1.jpg", imageURL("https://cdn.example.com/1.jpg"))
+	assert.Empty(t, imageURL(""))
+}
@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"homework_ipl/internal/config"
+)
+
+const envLocal = "local"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма пользователям (подтверждение почты, сброс пароля)
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Mailer по настройкам из конфига: "smtp" для прода, "outbox" для локального запуска.
+// Вне env: local драйвер обязателен, чтобы письма со ссылками не уходили молча в outbox
+func New(cfg config.Mail, env string) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg.From, cfg.SMTP), nil
+	case "outbox":
+		return NewOutboxMailer(cfg.From, cfg.OutboxDir), nil
+	case "":
+		if env != envLocal {
+			return nil, fmt.Errorf("mail driver is not set, use MAIL_DRIVER=smtp")
+		}
+		return NewOutboxMailer(cfg.From, cfg.OutboxDir), nil
+	}
+	return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+}
+
+// Письмо в формате RFC 5322 (заголовки + тело), тема кодируется для кириллицы
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"homework_ipl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	m, err := New(config.Mail{}, "local")
+	assert.NoError(t, err)
+	assert.IsType(t, &OutboxMailer{}, m)
+
+	_, err = New(config.Mail{}, "prod")
+	assert.Error(t, err, "driver is required outside local")
+
+	m, err = New(config.Mail{Driver: "smtp"}, "prod")
+	assert.NoError(t, err)
+	assert.IsType(t, &SMTPMailer{}, m)
+
+	_, err = New(config.Mail{Driver: "pigeon"}, "local")
+	assert.Error(t, err)
+}
+
+func TestOutboxMailer(t *testing.T) {
+	dir := t.TempDir()
+	m := NewOutboxMailer("noreply@localhost", dir)
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "user@mail.ru", Subject: "Сброс пароля", Body: "token=secret"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*_user_at_mail.ru.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(data), "\r\n\r\ntoken=secret"))
+}
@@ -0,0 +1,17 @@
+package mailer
+
+import (
+	"os"
+	"testing"
+
+	_ "homework_ipl/internal/testenv"
+	"homework_ipl/utils/logger"
+
+	"golang.org/x/exp/slog"
+)
+
+// В тестах пишем только ошибки
+func TestMain(m *testing.M) {
+	logger.SetLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+	os.Exit(m.Run())
+}
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"homework_ipl/utils/logger"
+)
+
+// OutboxMailer для локальной разработки: письма не отправляются, а пишутся
+// .eml-файлами в dir. В лог попадают только адрес и тема: в теле токены
+// подтверждения и сброса пароля
+type OutboxMailer struct {
+	from string
+	dir  string
+}
+
+func NewOutboxMailer(from, dir string) *OutboxMailer {
+	return &OutboxMailer{
+		from: from,
+		dir:  dir,
+	}
+}
+
+func (m *OutboxMailer) Send(_ context.Context, msg Message) error {
+	if m.dir == "" {
+		logger.Logger().Info("Outbox mail dropped, outbox_dir is not set", "to", msg.To, "subject", msg.Subject)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, format(m.from, msg), 0o600); err != nil {
+		return err
+	}
+
+	logger.Logger().Info("Outbox mail", "to", msg.To, "subject", msg.Subject, "file", path)
+	return nil
+}
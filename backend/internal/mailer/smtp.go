@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strconv"
+
+	"homework_ipl/internal/config"
+)
+
+// SMTPMailer отправляет письма через SMTP-сервер
+type SMTPMailer struct {
+	from string
+	cfg  config.SMTP
+}
+
+func NewSMTPMailer(from string, cfg config.SMTP) *SMTPMailer {
+	return &SMTPMailer{
+		from: from,
+		cfg:  cfg,
+	}
+}
+
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+
+	var auth smtp.Auth
+	if m.cfg.User != "" {
+		auth = smtp.PlainAuth("", m.cfg.User, m.cfg.Password, m.cfg.Host)
+	}
+
+	return smtp.SendMail(addr, auth, m.from, []string{msg.To}, format(m.from, msg))
+}
@@ -0,0 +1,167 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"homework_ipl/internal/config"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Хэши хранятся в формате PHC, алгоритм и параметры записаны в самой строке:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<соль base64>$<хэш base64>
+//
+// Поэтому соль отдельно не хранится, а параметры можно менять в конфиге:
+// хэши со старыми параметрами (и старые bcrypt-хэши $2a$/$2b$) проверяются
+// как раньше и перехэшируются при следующем успешном входе (NeedsRehash)
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+// Параметры по умолчанию - рекомендация OWASP для argon2id
+var (
+	algorithm  = AlgorithmArgon2id
+	params     = argon2Params{memory: 19 * 1024, time: 2, threads: 1}
+	bcryptCost = 12
+)
+
+// Настройки применяются только целиком, чтобы ошибка в конфиге не оставила их наполовину изменёнными
+func initHashing(cfg config.PasswordHashing) error {
+	newAlgorithm, newParams, newCost := algorithm, params, bcryptCost
+
+	switch cfg.Algorithm {
+	case "":
+	case AlgorithmArgon2id, AlgorithmBcrypt:
+		newAlgorithm = cfg.Algorithm
+	default:
+		return errors.Errorf("unknown password hashing algorithm %q", cfg.Algorithm)
+	}
+
+	if cfg.Argon2Memory > 0 {
+		newParams.memory = cfg.Argon2Memory
+	}
+	if cfg.Argon2Time > 0 {
+		newParams.time = cfg.Argon2Time
+	}
+	if cfg.Argon2Threads > 0 {
+		newParams.threads = cfg.Argon2Threads
+	}
+	if cfg.BcryptCost > 0 {
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return errors.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		newCost = cfg.BcryptCost
+	}
+
+	algorithm, params, bcryptCost = newAlgorithm, newParams, newCost
+	return nil
+}
+
+// Хэш пароля текущим алгоритмом с текущими параметрами
+func Hash(password string) (string, error) {
+	if algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Совпадает ли пароль с хэшем любого поддерживаемого формата
+func Verify(password, hash string) (bool, error) {
+	if isBcrypt(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	actual := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+// Нужно ли пересчитать хэш: другой алгоритм или устаревшие параметры
+func NeedsRehash(hash string) bool {
+	if isBcrypt(hash) {
+		if algorithm != AlgorithmBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != bcryptCost
+	}
+
+	if algorithm != AlgorithmArgon2id {
+		return true
+	}
+	p, _, _, err := decodeArgon2id(hash)
+	return err != nil || p != params
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func decodeArgon2id(hash string) (argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", соль, хэш
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+
+	var p argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,139 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"homework_ipl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Меняет настройки пакета на время теста
+func withSettings(t *testing.T, p config.PasswordPolicy, h config.PasswordHashing) {
+	prevPolicy, prevAlgorithm, prevParams, prevCost := policy, algorithm, params, bcryptCost
+	t.Cleanup(func() {
+		policy, algorithm, params, bcryptCost = prevPolicy, prevAlgorithm, prevParams, prevCost
+	})
+	require.NoError(t, Init(p, h))
+}
+
+func TestValidateDefaultPolicy(t *testing.T) {
+	cases := []struct {
+		password string
+		valid    bool
+	}{
+		{"Sh0rt", false},
+		{"alllowercase1", false},
+		{"ALLUPPERCASE1", false},
+		{"NoDigitsHere", false},
+		{"Password1", false},
+		{"QWERTY123", false},
+		{"Tr1cky-Horse", true},
+		{"Пароль2Надёжный", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.password, func(t *testing.T) {
+			err := Validate(c.password)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateConfiguredPolicy(t *testing.T) {
+	withSettings(t, config.PasswordPolicy{
+		MinLength:     12,
+		MaxLength:     16,
+		RequireSymbol: true,
+	}, config.PasswordHashing{})
+
+	assert.EqualError(t, Validate("short!"), "password must be at least 12 characters long")
+	assert.EqualError(t, Validate(strings.Repeat("a", 17)), "password must be at most 16 characters long")
+	assert.EqualError(t, Validate("nosymbolsatall"), "password must contain a special character")
+	assert.NoError(t, Validate("lowercase only!"))
+	// без reject_common список не проверяется
+	assert.NoError(t, Validate("password123!"))
+}
+
+func TestInitRejectsInvalidSettings(t *testing.T) {
+	assert.Error(t, Init(config.PasswordPolicy{MinLength: 10, MaxLength: 5}, config.PasswordHashing{}))
+	assert.Error(t, Init(config.PasswordPolicy{MinLength: 8}, config.PasswordHashing{Algorithm: "md5"}))
+	assert.Error(t, Init(config.PasswordPolicy{MinLength: 8}, config.PasswordHashing{Algorithm: AlgorithmBcrypt, BcryptCost: 99}))
+}
+
+func TestHashArgon2id(t *testing.T) {
+	hash, err := Hash("Tr1cky-Horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$"))
+
+	other, err := Hash("Tr1cky-Horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "each hash has its own salt")
+
+	ok, err := Verify("Tr1cky-Horse", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = Verify("wrong", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, NeedsRehash(hash))
+
+	_, err = Verify("Tr1cky-Horse", "$argon2id$v=19$broken")
+	assert.Equal(t, ErrUnknownHash, err)
+	_, err = Verify("Tr1cky-Horse", "plaintext")
+	assert.Equal(t, ErrUnknownHash, err)
+}
+
+func TestLegacyBcryptIsRehashed(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("Tr1cky-Horse"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ok, err := Verify("Tr1cky-Horse", string(legacy))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = Verify("wrong", string(legacy))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.True(t, NeedsRehash(string(legacy)))
+}
+
+func TestRehashAfterParamsChange(t *testing.T) {
+	hash, err := Hash("Tr1cky-Horse")
+	require.NoError(t, err)
+
+	withSettings(t, policy, config.PasswordHashing{Algorithm: AlgorithmArgon2id, Argon2Memory: 8 * 1024, Argon2Time: 3})
+	assert.True(t, NeedsRehash(hash))
+
+	// старый хэш по-прежнему проверяется со своими параметрами
+	ok, err := Verify("Tr1cky-Horse", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	rehashed, err := Hash("Tr1cky-Horse")
+	require.NoError(t, err)
+	assert.False(t, NeedsRehash(rehashed))
+}
+
+func TestBcryptAlgorithm(t *testing.T) {
+	argonHash, err := Hash("Tr1cky-Horse")
+	require.NoError(t, err)
+
+	withSettings(t, policy, config.PasswordHashing{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost})
+
+	hash, err := Hash("Tr1cky-Horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$2a$"))
+	assert.False(t, NeedsRehash(hash))
+	assert.True(t, NeedsRehash(argonHash))
+}
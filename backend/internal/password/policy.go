@@ -0,0 +1,108 @@
+package password
+
+import (
+	_ "embed"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"homework_ipl/internal/config"
+
+	"github.com/pkg/errors"
+)
+
+// Список самых распространённых паролей (по одному в строке, сравнение без учёта регистра)
+//
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var commonPasswords = parseCommon(commonPasswordsFile)
+
+var ErrCommonPassword = errors.New("password is too common")
+
+// Политика по умолчанию совпадает с прежней проверкой: 8+ символов, заглавная, строчная и цифра
+var policy = config.PasswordPolicy{
+	MinLength:    8,
+	MaxLength:    128,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	RejectCommon: true,
+}
+
+// Настройка политики и хэширования, вызывается в main
+func Init(p config.PasswordPolicy, h config.PasswordHashing) error {
+	if p.MinLength < 1 || (p.MaxLength > 0 && p.MaxLength < p.MinLength) {
+		return errors.Errorf("invalid password length limits %d..%d", p.MinLength, p.MaxLength)
+	}
+	if err := initHashing(h); err != nil {
+		return err
+	}
+	policy = p
+	return nil
+}
+
+// Проверка нового пароля по политике, текст ошибки можно показывать пользователю
+func Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		return errors.Errorf("password must be at least %d characters long", policy.MinLength)
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		return errors.Errorf("password must be at most %d characters long", policy.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+	if policy.RequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if policy.RequireLower && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a special character")
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("password must contain %s", strings.Join(missing, ", "))
+	}
+
+	if policy.RejectCommon && IsCommon(password) {
+		return ErrCommonPassword
+	}
+	return nil
+}
+
+// Есть ли пароль в списке распространённых
+func IsCommon(password string) bool {
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
+
+func parseCommon(file string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range strings.Split(file, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// APITokenRepo - персональные API-токены пользователей (api_token)
+type APITokenRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewAPITokenRepo creates api token repo
+func NewAPITokenRepo(db *pgxpool.Pool) *APITokenRepo {
+	return &APITokenRepo{
+		db: db,
+	}
+}
+
+const apiTokenColumns = `id, user_id, name, scopes, token_hash, created_at, expires_at, last_used_at`
+
+func (repo *APITokenRepo) Create(ctx context.Context, token entities.APIToken) (entities.APIToken, error) {
+	err := repo.db.QueryRow(ctx, `INSERT INTO api_token(user_id, name, scopes, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		token.UserID, token.Name, token.Scopes, token.TokenHash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.APIToken{}, err
+	}
+
+	return token, nil
+}
+
+func (repo *APITokenRepo) GetByHash(ctx context.Context, tokenHash string) (entities.APIToken, bool, error) {
+	var tokens []*entities.APIToken
+
+	err := pgxscan.Select(ctx, repo.db, &tokens, `SELECT `+apiTokenColumns+` FROM api_token WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.APIToken{}, false, err
+	}
+	if len(tokens) == 0 {
+		return entities.APIToken{}, false, nil
+	}
+
+	return *tokens[0], true, nil
+}
+
+func (repo *APITokenRepo) ListByUser(ctx context.Context, userID int) ([]entities.APIToken, error) {
+	var tokens []*entities.APIToken
+
+	err := pgxscan.Select(ctx, repo.db, &tokens, `SELECT `+apiTokenColumns+` FROM api_token WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	var result []entities.APIToken
+	for _, t := range tokens {
+		result = append(result, *t)
+	}
+
+	return result, nil
+}
+
+func (repo *APITokenRepo) Delete(ctx context.Context, userID, tokenID int) (bool, error) {
+	tag, err := repo.db.Exec(ctx, `DELETE FROM api_token WHERE id = $1 AND user_id = $2`, tokenID, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (repo *APITokenRepo) Touch(ctx context.Context, tokenID int, lastUsed time.Time) error {
+	_, err := repo.db.Exec(ctx, `UPDATE api_token SET last_used_at = $1 WHERE id = $2`, lastUsed, tokenID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditRepo - журнал аудита в таблице audit_event. Записи только добавляются,
+// внешних ключей на user_data нет: история остаётся и после удаления пользователя
+type AuditRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewAuditRepo creates audit repo
+func NewAuditRepo(db *pgxpool.Pool) *AuditRepo {
+	return &AuditRepo{
+		db: db,
+	}
+}
+
+func (repo *AuditRepo) Create(ctx context.Context, event entities.AuditEvent) error {
+	_, err := repo.db.Exec(ctx, `INSERT INTO audit_event(actor_id, action, target, ip, request_id) VALUES (NULLIF($1, 0), $2, $3, $4, $5)`,
+		event.ActorID, event.Action, event.Target, event.IP, event.RequestID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (repo *AuditRepo) List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error) {
+	var events []*entities.AuditEvent
+
+	var conditions []string
+	var queryParams []interface{}
+	param := func(value interface{}) string {
+		queryParams = append(queryParams, value)
+		return "$" + strconv.Itoa(len(queryParams))
+	}
+
+	if filter.UserID != 0 {
+		conditions = append(conditions, "(actor_id = "+param(filter.UserID)+" OR target = "+param(entities.AuditUser(filter.UserID))+")")
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = "+param(filter.Action))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= "+param(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < "+param(filter.To))
+	}
+
+	query := `SELECT id, COALESCE(actor_id, 0) AS actor_id, action, target, ip, request_id, created_at FROM audit_event`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + param(filter.Limit)
+	}
+
+	err := pgxscan.Select(ctx, repo.db, &events, query, queryParams...)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	result := make([]entities.AuditEvent, 0, len(events))
+	for _, e := range events {
+		result = append(result, *e)
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// Город нельзя удалить, пока к нему привязаны достопримечательности
+var ErrCityInUse = errors.New("city is referenced by sights")
+
+// CityRepo struct
+type CityRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewCityRepo creates city repo
+func NewCityRepo(db *pgxpool.Pool) *CityRepo {
+	return &CityRepo{
+		db: db,
+	}
+}
+
+func (repo *CityRepo) GetCities() ([]entities.City, error) {
+	var cities []*entities.City
+	ctx := context.Background()
+
+	err := pgxscan.Select(ctx, repo.db, &cities, `SELECT city.id, city.city, city.country_id, country.country FROM city INNER JOIN country ON city.country_id = country.id ORDER BY city.id`)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	var cityList []entities.City
+	for _, c := range cities {
+		cityList = append(cityList, *c)
+	}
+	return cityList, nil
+}
+
+func (repo *CityRepo) CreateCity(city entities.City) (entities.City, error) {
+	ctx := context.Background()
+
+	err := repo.db.QueryRow(ctx, `INSERT INTO city(city, country_id) VALUES ($1, $2) RETURNING id`, city.City, city.CountryID).Scan(&city.ID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.City{}, err
+	}
+
+	return city, nil
+}
+
+func (repo *CityRepo) DeleteCity(cityID int) error {
+	ctx := context.Background()
+
+	tag, err := repo.db.Exec(ctx, `DELETE FROM city WHERE id = $1`, cityID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return ErrCityInUse
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExportRepo - задания на выгрузку персональных данных (таблица data_export)
+// и сбор самих данных пользователя из всех таблиц
+type ExportRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewExportRepo creates export repo
+func NewExportRepo(db *pgxpool.Pool) *ExportRepo {
+	return &ExportRepo{
+		db: db,
+	}
+}
+
+const exportColumns = `id, user_id, status, file_path, created_at, expires_at`
+
+func (repo *ExportRepo) CreateExport(ctx context.Context, export entities.DataExport) error {
+	_, err := repo.db.Exec(ctx, `INSERT INTO data_export(id, user_id, status, created_at) VALUES ($1, $2, $3, $4)`,
+		export.ID, export.UserID, export.Status, export.CreatedAt)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (repo *ExportRepo) GetExport(ctx context.Context, id string) (entities.DataExport, bool, error) {
+	var exports []*entities.DataExport
+
+	err := pgxscan.Select(ctx, repo.db, &exports, `SELECT `+exportColumns+` FROM data_export WHERE id = $1`, id)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.DataExport{}, false, err
+	}
+	if len(exports) == 0 {
+		return entities.DataExport{}, false, nil
+	}
+
+	return *exports[0], true, nil
+}
+
+// Сборка, начатая больше часа назад, считается зависшей (например, сервис перезапустили)
+func (repo *ExportRepo) PendingExport(ctx context.Context, userID int) (entities.DataExport, bool, error) {
+	var exports []*entities.DataExport
+
+	err := pgxscan.Select(ctx, repo.db, &exports, `SELECT `+exportColumns+` FROM data_export
+		WHERE user_id = $1 AND status = $2 AND created_at > now() - interval '1 hour'
+		ORDER BY created_at DESC LIMIT 1`, userID, entities.ExportPending)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.DataExport{}, false, err
+	}
+	if len(exports) == 0 {
+		return entities.DataExport{}, false, nil
+	}
+
+	return *exports[0], true, nil
+}
+
+func (repo *ExportRepo) FinishExport(ctx context.Context, export entities.DataExport) error {
+	_, err := repo.db.Exec(ctx, `UPDATE data_export SET status = $1, file_path = $2, expires_at = $3 WHERE id = $4`,
+		export.Status, export.FilePath, export.ExpiresAt, export.ID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Истекшие архивы, а также неудачные и зависшие сборки старше суток
+func (repo *ExportRepo) ExpiredExports(ctx context.Context, now time.Time) ([]entities.DataExport, error) {
+	var exports []*entities.DataExport
+
+	err := pgxscan.Select(ctx, repo.db, &exports, `SELECT `+exportColumns+` FROM data_export
+		WHERE expires_at < $1 OR (status <> $2 AND created_at < $1 - interval '1 day')`, now, entities.ExportReady)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	result := make([]entities.DataExport, 0, len(exports))
+	for _, e := range exports {
+		result = append(result, *e)
+	}
+	return result, nil
+}
+
+func (repo *ExportRepo) DeleteExport(ctx context.Context, id string) error {
+	_, err := repo.db.Exec(ctx, `DELETE FROM data_export WHERE id = $1`, id)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (repo *ExportRepo) GetUserExportData(ctx context.Context, userID int) (entities.UserExportData, error) {
+	var data entities.UserExportData
+	logger := logger.Logger()
+
+	var accounts []*entities.ExportAccount
+	err := pgxscan.Select(ctx, repo.db, &accounts, `SELECT u.id, u.email, u.role, u.email_verified,
+		COALESCE(t.enabled, false) AS two_factor_enabled
+		FROM user_data u LEFT JOIN user_totp t ON t.user_id = u.id WHERE u.id = $1`, userID)
+	if err != nil {
+		logger.Error(err.Error())
+		return entities.UserExportData{}, err
+	}
+	if len(accounts) == 0 {
+		return entities.UserExportData{}, ErrNotFound
+	}
+	data.Account = *accounts[0]
+
+	var profiles []*entities.UserProfile
+	err = pgxscan.Select(ctx, repo.db, &profiles, `SELECT user_id, COALESCE(username, '') AS username,
+		COALESCE(bio, '') AS bio, COALESCE(avatar, '') AS avatar FROM profile_data WHERE user_id = $1`, userID)
+	if err != nil {
+		logger.Error(err.Error())
+		return entities.UserExportData{}, err
+	}
+	if len(profiles) > 0 {
+		data.Profile = *profiles[0]
+	}
+
+	var feedback []*entities.Comment
+	err = pgxscan.Select(ctx, repo.db, &feedback, `SELECT id, user_id, sight_id, rating, feedback FROM feedback WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		logger.Error(err.Error())
+		return entities.UserExportData{}, err
+	}
+	data.Feedback = make([]entities.Comment, 0, len(feedback))
+	for _, f := range feedback {
+		data.Feedback = append(data.Feedback, *f)
+	}
+
+	var journeys []*entities.Journey
+	err = pgxscan.Select(ctx, repo.db, &journeys, `SELECT id, user_id, name, COALESCE(description, '') AS description
+		FROM journey WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		logger.Error(err.Error())
+		return entities.UserExportData{}, err
+	}
+
+	var journeySights []*entities.JourneySight
+	err = pgxscan.Select(ctx, repo.db, &journeySights, `SELECT js.id, js.journey_id, js.sight_id, js.priority, s.name AS sight_name
+		FROM journey_sight js JOIN journey j ON j.id = js.journey_id JOIN sight s ON s.id = js.sight_id
+		WHERE j.user_id = $1 ORDER BY js.journey_id, js.priority, js.id`, userID)
+	if err != nil {
+		logger.Error(err.Error())
+		return entities.UserExportData{}, err
+	}
+
+	data.Journeys = make([]entities.ExportJourney, 0, len(journeys))
+	for _, j := range journeys {
+		journey := entities.ExportJourney{Journey: *j, Sights: []entities.JourneySight{}}
+		for _, js := range journeySights {
+			if js.JourneyID == j.ID {
+				js.UserID = userID
+				journey.Sights = append(journey.Sights, *js)
+			}
+		}
+		data.Journeys = append(data.Journeys, journey)
+	}
+
+	var identities []*entities.ExportIdentity
+	err = pgxscan.Select(ctx, repo.db, &identities, `SELECT provider, subject, email, created_at FROM user_identity WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		logger.Error(err.Error())
+		return entities.UserExportData{}, err
+	}
+	data.Identities = make([]entities.ExportIdentity, 0, len(identities))
+	for _, i := range identities {
+		data.Identities = append(data.Identities, *i)
+	}
+
+	data.APITokens, err = NewAPITokenRepo(repo.db).ListByUser(ctx, userID)
+	if err != nil {
+		return entities.UserExportData{}, err
+	}
+	if data.APITokens == nil {
+		data.APITokens = []entities.APIToken{}
+	}
+
+	return data, nil
+}
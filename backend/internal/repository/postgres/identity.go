@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdentityRepo - аккаунты сторонних провайдеров входа (user_identity)
+type IdentityRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewIdentityRepo creates identity repo
+func NewIdentityRepo(db *pgxpool.Pool) *IdentityRepo {
+	return &IdentityRepo{
+		db: db,
+	}
+}
+
+func (repo *IdentityRepo) GetUserByIdentity(ctx context.Context, provider, subject string) (entities.User, bool, error) {
+	var users []*entities.User
+
+	err := pgxscan.Select(ctx, repo.db, &users, `SELECT u.id, u.email, u.role, u.email_verified
+		FROM user_identity i JOIN user_data u ON u.id = i.user_id
+		WHERE i.provider = $1 AND i.subject = $2`, provider, subject)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.User{}, false, err
+	}
+	if len(users) == 0 {
+		return entities.User{}, false, nil
+	}
+
+	return *users[0], true, nil
+}
+
+func (repo *IdentityRepo) FindUserByEmail(ctx context.Context, email string) (entities.User, bool, error) {
+	var users []*entities.User
+
+	err := pgxscan.Select(ctx, repo.db, &users, `SELECT id, email, role, email_verified FROM user_data WHERE lower(email) = lower($1)`, email)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.User{}, false, err
+	}
+	if len(users) == 0 {
+		return entities.User{}, false, nil
+	}
+
+	return *users[0], true, nil
+}
+
+func (repo *IdentityRepo) LinkIdentity(ctx context.Context, userID int, identity entities.OIDCIdentity) error {
+	_, err := repo.db.Exec(ctx, `INSERT INTO user_identity(provider, subject, user_id, email) VALUES ($1, $2, $3, $4)`,
+		identity.Provider, identity.Subject, userID, identity.Email)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Пользователь и профиль создаются вместе с привязкой в одной транзакции
+func (repo *IdentityRepo) CreateOIDCUser(ctx context.Context, identity entities.OIDCIdentity, passwordHash string) (entities.User, error) {
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	userID, err := insertUser(ctx, tx, entities.User{
+		Email:         identity.Email,
+		Passwrd:       passwordHash,
+		EmailVerified: true,
+	})
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO user_identity(provider, subject, user_id, email) VALUES ($1, $2, $3, $4)`,
+		identity.Provider, identity.Subject, userID, identity.Email)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.User{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.User{}, err
+	}
+
+	return entities.User{ID: userID, Email: identity.Email, Role: entities.RoleUser, EmailVerified: true}, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/sightimport"
+	"homework_ipl/utils/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Импорт достопримечательностей вместе с городами, странами и картинками
+type ImportRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewImportRepo(db *pgxpool.Pool) *ImportRepo {
+	return &ImportRepo{
+		db: db,
+	}
+}
+
+// Состояние одного импорта: найденные и созданные страны и города
+type importTx struct {
+	tx        pgx.Tx
+	countries map[string]int
+	cities    map[[2]string]int
+	report    *entities.ImportReport
+}
+
+// Записывает проверенные строки в одной транзакции: достопримечательность
+// обновляется по UNIQUE (name, city_id), недостающие города и страны создаются.
+// При dryRun или ошибках в строках (картинка уже принадлежит другой
+// достопримечательности) транзакция откатывается, отчёт остаётся
+func (repo *ImportRepo) ImportSights(ctx context.Context, rows []entities.ImportRow, dryRun bool) (entities.ImportReport, error) {
+	report := entities.ImportReport{DryRun: dryRun, Rows: []entities.ImportRowResult{}}
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.ImportReport{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	state := &importTx{
+		tx:        tx,
+		countries: make(map[string]int),
+		cities:    make(map[[2]string]int),
+		report:    &report,
+	}
+	for _, row := range rows {
+		if err = state.importRow(ctx, row); err != nil {
+			logger.Logger().Error(err.Error())
+			return entities.ImportReport{}, err
+		}
+	}
+
+	if dryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+	if err = tx.Commit(ctx); err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.ImportReport{}, err
+	}
+	report.Applied = true
+	return report, nil
+}
+
+func (s *importTx) importRow(ctx context.Context, row entities.ImportRow) error {
+	countryID, err := s.countryID(ctx, row.Country)
+	if err != nil {
+		return err
+	}
+	cityID, err := s.cityID(ctx, row.City, row.Country, countryID)
+	if err != nil {
+		return err
+	}
+
+	result := entities.ImportRowResult{Line: row.Line, Name: row.Name, City: row.City}
+
+	var existing sightimport.Existing
+	err = s.tx.QueryRow(ctx, `SELECT id, rating, EXISTS(SELECT 1 FROM feedback WHERE sight_id = sight.id),
+			COALESCE(description, ''), COALESCE(category, ''), latitude::float8, longitude::float8
+		FROM sight WHERE name = $1 AND city_id = $2 FOR UPDATE`, row.Name, cityID).
+		Scan(&result.SightID, &existing.Rating, &existing.HasFeedback, &existing.Description, &existing.Category,
+			&existing.Latitude, &existing.Longitude)
+	switch err {
+	case pgx.ErrNoRows:
+		err = s.tx.QueryRow(ctx, `INSERT INTO sight(rating, name, description, city_id, country_id, latitude, longitude, category)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, NULLIF($8, '')) RETURNING id`,
+			row.Rating, row.Name, row.Description, cityID, countryID, row.Latitude, row.Longitude, row.Category).Scan(&result.SightID)
+		if err != nil {
+			return err
+		}
+		result.Action = entities.ImportCreate
+		s.report.Created++
+	case nil:
+		rows, err := s.tx.Query(ctx, `SELECT path FROM image_data WHERE sight_id = $1`, result.SightID)
+		if err != nil {
+			return err
+		}
+		existing.Images, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+
+		result.Changes = sightimport.Diff(existing, row)
+		if len(result.Changes) == 0 {
+			result.Action = entities.ImportUnchanged
+			s.report.Unchanged++
+			break
+		}
+		// рейтинг из файла - только начальный, при отзывах его считает триггер
+		_, err = s.tx.Exec(ctx, `UPDATE sight SET rating = $1, description = NULLIF($2, ''), latitude = $3, longitude = $4,
+			category = NULLIF($5, ''), version = version + 1 WHERE id = $6`,
+			sightimport.UpdatedRating(existing, row), row.Description, row.Latitude, row.Longitude, row.Category, result.SightID)
+		if err != nil {
+			return err
+		}
+		result.Action = entities.ImportUpdate
+		s.report.Updated++
+	default:
+		return err
+	}
+
+	var problems []string
+	for _, image := range sightimport.NewImages(existing.Images, row.Images) {
+		tag, err := s.tx.Exec(ctx, `INSERT INTO image_data(path, sight_id) VALUES ($1, $2) ON CONFLICT (path) DO NOTHING`,
+			image, result.SightID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			problems = append(problems, "image "+image+" belongs to another sight")
+		}
+	}
+	if len(problems) > 0 {
+		s.report.Errors = append(s.report.Errors, entities.ImportRowError{Line: row.Line, Errors: problems})
+	}
+
+	s.report.Rows = append(s.report.Rows, result)
+	return nil
+}
+
+func (s *importTx) countryID(ctx context.Context, country string) (int, error) {
+	if id, ok := s.countries[country]; ok {
+		return id, nil
+	}
+
+	var id int
+	err := s.tx.QueryRow(ctx, `SELECT id FROM country WHERE country = $1`, country).Scan(&id)
+	if err == pgx.ErrNoRows {
+		err = s.tx.QueryRow(ctx, `INSERT INTO country(country) VALUES ($1) RETURNING id`, country).Scan(&id)
+		s.report.CountriesCreated = append(s.report.CountriesCreated, country)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	s.countries[country] = id
+	return id, nil
+}
+
+func (s *importTx) cityID(ctx context.Context, city, country string, countryID int) (int, error) {
+	key := [2]string{city, country}
+	if id, ok := s.cities[key]; ok {
+		return id, nil
+	}
+
+	var id int
+	err := s.tx.QueryRow(ctx, `SELECT id FROM city WHERE city = $1 AND country_id = $2 ORDER BY id LIMIT 1`, city, countryID).Scan(&id)
+	if err == pgx.ErrNoRows {
+		err = s.tx.QueryRow(ctx, `INSERT INTO city(city, country_id) VALUES ($1, $2) RETURNING id`, city, countryID).Scan(&id)
+		s.report.CitiesCreated = append(s.report.CitiesCreated, city+", "+country)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	s.cities[key] = id
+	return id, nil
+}
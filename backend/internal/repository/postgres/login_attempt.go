@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LoginAttemptRepo хранит счётчики неудачных входов в таблице login_attempt,
+// чтобы блокировка действовала на всех инстансах бэкенда
+type LoginAttemptRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewLoginAttemptRepo creates login attempt repo
+func NewLoginAttemptRepo(db *pgxpool.Pool) *LoginAttemptRepo {
+	return &LoginAttemptRepo{
+		db: db,
+	}
+}
+
+func (repo *LoginAttemptRepo) Get(ctx context.Context, key string) (entities.LoginAttempt, error) {
+	var attempts []*entities.LoginAttempt
+
+	err := pgxscan.Select(ctx, repo.db, &attempts, `SELECT key, failures, last_failure, locked_until FROM login_attempt WHERE key = $1`, key)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.LoginAttempt{}, err
+	}
+
+	if len(attempts) == 0 {
+		return entities.LoginAttempt{Key: key}, nil
+	}
+
+	return *attempts[0], nil
+}
+
+// Счётчик увеличивается в одном запросе, без чтения в Go
+func (repo *LoginAttemptRepo) AddFailure(ctx context.Context, key string, at, resetBefore time.Time) (entities.LoginAttempt, error) {
+	var attempt entities.LoginAttempt
+	err := repo.db.QueryRow(ctx, `INSERT INTO login_attempt(key, failures, last_failure, locked_until) VALUES ($1, 1, $2, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempt.last_failure < $3 THEN 1 ELSE login_attempt.failures + 1 END,
+			last_failure = GREATEST(login_attempt.last_failure, EXCLUDED.last_failure)
+		RETURNING key, failures, last_failure, locked_until`, key, at, resetBefore).
+		Scan(&attempt.Key, &attempt.Failures, &attempt.LastFailure, &attempt.LockedUntil)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.LoginAttempt{}, err
+	}
+
+	return attempt, nil
+}
+
+func (repo *LoginAttemptRepo) Lock(ctx context.Context, key string, lockedUntil time.Time) error {
+	_, err := repo.db.Exec(ctx, `UPDATE login_attempt SET locked_until = GREATEST(locked_until, $2) WHERE key = $1`, key, lockedUntil)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (repo *LoginAttemptRepo) DeleteStale(ctx context.Context, failedBefore, now time.Time) (int64, error) {
+	tag, err := repo.db.Exec(ctx, `DELETE FROM login_attempt WHERE last_failure < $1 AND locked_until <= $2`, failedBefore, now)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (repo *LoginAttemptRepo) Delete(ctx context.Context, key string) error {
+	_, err := repo.db.Exec(ctx, `DELETE FROM login_attempt WHERE key = $1`, key)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
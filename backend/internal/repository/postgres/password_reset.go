@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PasswordResetRepo - токены сброса пароля (password_reset)
+type PasswordResetRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewPasswordResetRepo creates password reset repo
+func NewPasswordResetRepo(db *pgxpool.Pool) *PasswordResetRepo {
+	return &PasswordResetRepo{
+		db: db,
+	}
+}
+
+func (repo *PasswordResetRepo) CreateToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := repo.db.Exec(ctx, `INSERT INTO password_reset(token_hash, user_id, expires_at) VALUES ($1, $2, $3)`, tokenHash, userID, expiresAt)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Когда пользователю последний раз отправлялась ссылка (ok == false, если не отправлялась)
+func (repo *PasswordResetRepo) LastSentAt(ctx context.Context, userID int) (time.Time, bool, error) {
+	var created []time.Time
+
+	err := pgxscan.Select(ctx, repo.db, &created, `SELECT created_at FROM password_reset WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return time.Time{}, false, err
+	}
+	if len(created) == 0 {
+		return time.Time{}, false, nil
+	}
+
+	return created[0], true, nil
+}
+
+// Погашение токена и запись нового хэша пароля в одной транзакции.
+// Остальные токены пользователя удаляются. ok == false, если токена нет или он истёк
+func (repo *PasswordResetRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, bool, error) {
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userIDs []int
+	err = pgxscan.Select(ctx, tx, &userIDs, `DELETE FROM password_reset WHERE token_hash = $1 AND expires_at > now() RETURNING user_id`, tokenHash)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+	if len(userIDs) == 0 {
+		return 0, false, nil
+	}
+	userID := userIDs[0]
+
+	if _, err = tx.Exec(ctx, `UPDATE user_data SET passwrd = $1 WHERE id = $2`, passwordHash, userID); err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM password_reset WHERE user_id = $1`, userID); err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+	return userID, true, nil
+}
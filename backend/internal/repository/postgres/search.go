@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Подсказки строки поиска по достопримечательностям, городам и странам
+type SearchRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewSearchRepo(db *pgxpool.Pool) *SearchRepo {
+	return &SearchRepo{
+		db: db,
+	}
+}
+
+// Экранирует % и _ для LIKE, чтобы запрос пользователя искался как есть
+func likePrefix(query string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(query) + "%"
+}
+
+// Совпадение с начала названия выше похожих по триграммам, внутри - по похожести.
+// Каждая ветка ограничена limit, чтобы не сортировать лишнее
+func (repo *SearchRepo) Suggest(ctx context.Context, query string, limit int) ([]entities.Suggestion, error) {
+	var suggestions []*entities.Suggestion
+	err := pgxscan.Select(ctx, repo.db, &suggestions, `SELECT type, id, label, score FROM (
+			(SELECT 'sight' AS type, id, name AS label,
+				(name ILIKE $1)::int + similarity(name, $2) AS score
+			FROM sight WHERE archived_at IS NULL AND (name ILIKE $1 OR name % $2)
+			ORDER BY score DESC LIMIT $3)
+			UNION ALL
+			(SELECT 'city' AS type, id, city AS label,
+				(city ILIKE $1)::int + similarity(city, $2) AS score
+			FROM city WHERE city ILIKE $1 OR city % $2
+			ORDER BY score DESC LIMIT $3)
+			UNION ALL
+			(SELECT 'country' AS type, id, country AS label,
+				(country ILIKE $1)::int + similarity(country, $2) AS score
+			FROM country WHERE country ILIKE $1 OR country % $2
+			ORDER BY score DESC LIMIT $3)
+		) AS s
+		ORDER BY score DESC, label
+		LIMIT $3`, likePrefix(query), query, limit)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	suggestionList := make([]entities.Suggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		suggestionList = append(suggestionList, *s)
+	}
+	return suggestionList, nil
+}
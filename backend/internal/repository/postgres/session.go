@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SessionRepo хранит сессии пользователей в таблице user_session
+type SessionRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewSessionRepo creates session repo
+func NewSessionRepo(db *pgxpool.Pool) *SessionRepo {
+	return &SessionRepo{
+		db: db,
+	}
+}
+
+func (repo *SessionRepo) Create(ctx context.Context, session entities.Session) error {
+	_, err := repo.db.Exec(ctx, `INSERT INTO user_session(id, user_id, role, created_at, expires_at, last_seen, user_agent, ip) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		session.ID, session.UserID, session.Role, session.CreatedAt, session.ExpiresAt, session.LastSeen, session.UserAgent, session.IP)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Возвращает ok == false, если сессии с таким айди нет
+func (repo *SessionRepo) Get(ctx context.Context, id string) (entities.Session, bool, error) {
+	var sessions []*entities.Session
+
+	err := pgxscan.Select(ctx, repo.db, &sessions, `SELECT id, user_id, role, created_at, expires_at, last_seen, user_agent, ip FROM user_session WHERE id = $1`, id)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.Session{}, false, err
+	}
+
+	if len(sessions) == 0 {
+		return entities.Session{}, false, nil
+	}
+
+	return *sessions[0], true, nil
+}
+
+func (repo *SessionRepo) Delete(ctx context.Context, id string) error {
+	_, err := repo.db.Exec(ctx, `DELETE FROM user_session WHERE id = $1`, id)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Активные сессии пользователя, последние использованные - первыми
+func (repo *SessionRepo) ListByUser(ctx context.Context, userID int) ([]entities.Session, error) {
+	var sessions []*entities.Session
+
+	err := pgxscan.Select(ctx, repo.db, &sessions, `SELECT id, user_id, role, created_at, expires_at, last_seen, user_agent, ip FROM user_session WHERE user_id = $1 AND expires_at > now() ORDER BY last_seen DESC`, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	var sessionList []entities.Session
+	for _, s := range sessions {
+		sessionList = append(sessionList, *s)
+	}
+	return sessionList, nil
+}
+
+func (repo *SessionRepo) DeleteByUser(ctx context.Context, userID int, exceptID string) error {
+	_, err := repo.db.Exec(ctx, `DELETE FROM user_session WHERE user_id = $1 AND id <> $2`, userID, exceptID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (repo *SessionRepo) Touch(ctx context.Context, id string, lastSeen time.Time) error {
+	_, err := repo.db.Exec(ctx, `UPDATE user_session SET last_seen = $1 WHERE id = $2`, lastSeen, id)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (repo *SessionRepo) DeleteExpired(ctx context.Context, expiredBefore, idleBefore time.Time) (int64, error) {
+	tag, err := repo.db.Exec(ctx, `DELETE FROM user_session WHERE expires_at <= $1 OR last_seen <= $2`, expiredBefore, idleBefore)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
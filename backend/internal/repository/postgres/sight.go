@@ -8,12 +8,23 @@ import (
 	"homework_ipl/internal/entities"
 	"homework_ipl/utils/logger"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
 )
 
+// Запись не найдена (нет комментария, поездки и т.п.)
+var ErrNotFound = errors.New("not found")
+
+// Коды ошибок postgres при нарушении внешнего ключа и уникальности
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
 // Структура вызывальщика
 type SightRepo struct {
 	// технология пулов
@@ -27,14 +38,18 @@ func NewSightRepo(db *pgxpool.Pool) *SightRepo {
 	}
 }
 
-// возвращает (четкие) поля ВСЕХ достопримечательностей (sight)
+// возвращает (четкие) поля ВСЕХ достопримечательностей (sight), в том числе без картинок
 func (repo *SightRepo) GetSightsList() ([]entities.Sight, error) {
 	// такая переменная создается везде - в нее будет записано через &
 	var sights []*entities.Sight
 	ctx := context.Background()
 
-	// через & записываем результат
-	err := pgxscan.Select(ctx, repo.db, &sights, `SELECT sight.id, rating, name, description, city_id, country_id, im.path FROM sight INNER JOIN image_data AS im ON sight.id = im.sight_id`)
+	// через & записываем результат, картинка - первая из загруженных
+	err := pgxscan.Select(ctx, repo.db, &sights, `SELECT sight.id, rating, name, COALESCE(description, '') AS description, city_id, country_id,
+			COALESCE(im.path, '') AS path
+		FROM sight
+		LEFT JOIN LATERAL (SELECT path FROM image_data WHERE sight_id = sight.id ORDER BY id LIMIT 1) AS im ON true
+		WHERE sight.archived_at IS NULL`)
 	if err != nil {
 		logger.Logger().Error(err.Error())
 		return nil, err
@@ -47,43 +62,32 @@ func (repo *SightRepo) GetSightsList() ([]entities.Sight, error) {
 	return sightList, nil
 }
 
-// Возвращает данные ОДНОЙ достопримечательности по айди
+// Возвращает данные ОДНОЙ достопримечательности по айди, ErrNotFound - если её нет.
+// Импортированные и созданные в админке могут быть без картинки и координат:
+// тогда путь пустой, а координаты 0, 0
 func (repo *SightRepo) GetSightByID(id int) (entities.Sight, error) {
 	// такая переменная создается везде - в нее будет записано через &
 	var sight []*entities.Sight
 	ctx := context.Background()
 
-	err := pgxscan.Select(ctx, repo.db, &sight, `SELECT sight.id, rating, sight.name, description, city_id, sight.country_id, im.path, city.city, country.country, latitude, longitude FROM sight INNER JOIN image_data AS im ON sight.id = im.sight_id INNER JOIN city ON sight.city_id = city.id INNER JOIN country ON sight.country_id = country.id WHERE sight.id = $1`, id)
+	err := pgxscan.Select(ctx, repo.db, &sight, `SELECT sight.id, sight.rating, sight.name, COALESCE(sight.description, '') AS description,
+			COALESCE(sight.city_id, 0) AS city_id, COALESCE(sight.country_id, 0) AS country_id, COALESCE(im.path, '') AS path,
+			COALESCE(city.city, '') AS city, COALESCE(country.country, '') AS country,
+			COALESCE(sight.latitude, 0) AS latitude, COALESCE(sight.longitude, 0) AS longitude
+		FROM sight
+		LEFT JOIN LATERAL (SELECT path FROM image_data WHERE sight_id = sight.id ORDER BY id LIMIT 1) AS im ON true
+		LEFT JOIN city ON city.id = sight.city_id
+		LEFT JOIN country ON country.id = sight.country_id
+		WHERE sight.id = $1`, id)
 	if err != nil {
 		logger.Logger().Error(err.Error())
 		return entities.Sight{}, err
 	}
-
-	return *sight[0], nil
-}
-
-// Возвращает данные НЕСКОЛЬКИХ достопримечательностей, которые были отфильтрованы по ключу
-// (в данном случае ключи: либо город, либо страна, но можно добавить еще кучу вариантов)
-func (repo *SightRepo) GetFilteredSights(key string) ([]entities.Sight, error) {
-	// такая переменная создается везде - в нее будет записано через &
-	var sights []*entities.Sight
-	ctx := context.Background()
-
-	// если хочешь добавить еще ключи - OR поле IN (SELECT вт_поле FROM таблица WHERE условие)
-	err := pgxscan.Select(ctx, repo.db, &sights, `SELECT sight.id, rating, name, description, city_id, country_id, im.path FROM sight INNER JOIN image_data AS im ON sight.id = im.sight_id
-		WHERE city_id IN (SELECT id FROM city WHERE city = $1)
-   		OR country_id IN (SELECT id FROM country WHERE country = $1)`, key)
-
-	if err != nil {
-		logger.Logger().Error(err.Error())
-		return []entities.Sight{}, err
+	if len(sight) == 0 {
+		return entities.Sight{}, ErrNotFound
 	}
 
-	var sightList []entities.Sight
-	for _, s := range sights {
-		sightList = append(sightList, *s)
-	}
-	return sightList, nil
+	return *sight[0], nil
 }
 
 // Комментарии по айди
@@ -144,6 +148,23 @@ func (repo *SightRepo) DeleteCommentByCommentID(dataInt map[string]int) error {
 	return nil
 }
 
+// Айди автора комментария (для проверки прав на редактирование/удаление)
+func (repo *SightRepo) GetCommentOwner(commentID int) (int, error) {
+	var owners []int
+	ctx := context.Background()
+
+	err := pgxscan.Select(ctx, repo.db, &owners, `SELECT user_id FROM feedback WHERE id = $1`, commentID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, err
+	}
+	if len(owners) == 0 {
+		return 0, ErrNotFound
+	}
+
+	return owners[0], nil
+}
+
 // Создание Поездки
 func (repo *SightRepo) CreateJourney(dataInt map[string]int, dataStr map[string]string) (entities.Journey, error) {
 	var journey entities.Journey
@@ -180,6 +201,23 @@ func (repo *SightRepo) DeleteJourneyByID(dataInt map[string]int) error {
 	return nil
 }
 
+// Айди владельца поездки (для проверки прав на изменение)
+func (repo *SightRepo) GetJourneyOwner(journeyID int) (int, error) {
+	var owners []int
+	ctx := context.Background()
+
+	err := pgxscan.Select(ctx, repo.db, &owners, `SELECT user_id FROM journey WHERE id = $1`, journeyID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, err
+	}
+	if len(owners) == 0 {
+		return 0, ErrNotFound
+	}
+
+	return owners[0], nil
+}
+
 // Возвращает поездки по айди пользователя
 func (repo *SightRepo) GetJourneys(userID int) ([]entities.Journey, error) {
 	var journey []*entities.Journey
@@ -246,7 +284,7 @@ func (repo *SightRepo) GetJourneySights(journeyID int) ([]entities.Sight, error)
 	var idList []*int
 	ctx := context.Background()
 
-	err := pgxscan.Select(ctx, repo.db, &idList, `SELECT js.sight_id FROM journey_sight AS js WHERE js.journey_id = $1`, journeyID)
+	err := pgxscan.Select(ctx, repo.db, &idList, `SELECT js.sight_id FROM journey_sight AS js WHERE js.journey_id = $1 ORDER BY js.priority, js.id`, journeyID)
 	if err != nil {
 		logger.Logger().Error(err.Error())
 		return nil, err
@@ -279,3 +317,41 @@ func (repo *SightRepo) GetJourney(journeyID int) (entities.Journey, error) {
 
 	return *journey[0], nil
 }
+
+// Удаление достопримечательности вместе с картинками, отзывами и вхождениями в поездки
+func (repo *SightRepo) DeleteSight(sightID, version int) error {
+	ctx := context.Background()
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// строка блокируется до удаления связанных данных, чтобы версию не поменяли в процессе
+	var found bool
+	err = tx.QueryRow(ctx, `SELECT true FROM sight WHERE id = $1 AND ($2 = 0 OR version = $2) FOR UPDATE`, sightID, version).Scan(&found)
+	if err == pgx.ErrNoRows {
+		tx.Rollback(ctx)
+		return repo.explainNoUpdate(ctx, sightID, 0)
+	}
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	for _, query := range []string{
+		`DELETE FROM journey_sight WHERE sight_id = $1`,
+		`DELETE FROM feedback WHERE sight_id = $1`,
+		`DELETE FROM image_data WHERE sight_id = $1`,
+		`DELETE FROM sight WHERE id = $1`,
+	} {
+		if _, err = tx.Exec(ctx, query, sightID); err != nil {
+			logger.Logger().Error(err.Error())
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
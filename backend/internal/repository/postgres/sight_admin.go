@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+var (
+	// Достопримечательность изменили после того, как редактор её загрузил
+	ErrVersionConflict = errors.New("sight version conflict")
+	ErrUnknownCity     = errors.New("unknown city")
+	// В городе уже есть достопримечательность с таким названием
+	ErrSightExists = errors.New("sight already exists in this city")
+)
+
+const adminSightColumns = `sight.id, sight.rating, sight.name, COALESCE(sight.description, '') AS description,
+	sight.city_id, sight.country_id, COALESCE(city.city, '') AS city, COALESCE(country.country, '') AS country,
+	COALESCE(im.path, '') AS path, COALESCE(sight.latitude, 0) AS latitude, COALESCE(sight.longitude, 0) AS longitude,
+	COALESCE(sight.category, '') AS category, sight.version, sight.archived_at`
+
+// Координаты 0, 0 в entities.Sight - их отсутствие, в базе это NULL
+func sightCoordinates(sight entities.Sight) (*float32, *float32) {
+	if sight.Latitude == 0 && sight.Longitude == 0 {
+		return nil, nil
+	}
+	return &sight.Latitude, &sight.Longitude
+}
+
+// Достопримечательность для админки, в том числе архивная и без картинок
+func (repo *SightRepo) GetSightForAdmin(ctx context.Context, sightID int) (entities.Sight, error) {
+	var sights []*entities.Sight
+	err := pgxscan.Select(ctx, repo.db, &sights, `SELECT `+adminSightColumns+`
+		FROM sight
+		LEFT JOIN LATERAL (SELECT path FROM image_data WHERE sight_id = sight.id ORDER BY id LIMIT 1) AS im ON true
+		LEFT JOIN city ON city.id = sight.city_id
+		LEFT JOIN country ON country.id = sight.country_id
+		WHERE sight.id = $1`, sightID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.Sight{}, err
+	}
+	if len(sights) == 0 {
+		return entities.Sight{}, ErrNotFound
+	}
+
+	return *sights[0], nil
+}
+
+// Новая достопримечательность, страна берётся из города
+func (repo *SightRepo) CreateSight(ctx context.Context, sight entities.Sight) (entities.Sight, error) {
+	latitude, longitude := sightCoordinates(sight)
+
+	var sightID int
+	err := repo.db.QueryRow(ctx, `INSERT INTO sight(rating, name, description, city_id, country_id, latitude, longitude, category)
+		SELECT $1, $2, NULLIF($3, ''), city.id, city.country_id, $5, $6, NULLIF($7, '') FROM city WHERE city.id = $4
+		RETURNING id`,
+		sight.Rating, sight.Name, sight.Description, sight.CityID, latitude, longitude, sight.Category).Scan(&sightID)
+	if err == pgx.ErrNoRows {
+		return entities.Sight{}, ErrUnknownCity
+	}
+	if isUniqueSightViolation(err) {
+		return entities.Sight{}, ErrSightExists
+	}
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.Sight{}, err
+	}
+
+	return repo.GetSightForAdmin(ctx, sightID)
+}
+
+// Сохраняет поля достопримечательности, если её версия всё ещё version
+func (repo *SightRepo) UpdateSight(ctx context.Context, sight entities.Sight, version int) (entities.Sight, error) {
+	latitude, longitude := sightCoordinates(sight)
+
+	tag, err := repo.db.Exec(ctx, `UPDATE sight SET rating = $1, name = $2, description = NULLIF($3, ''),
+			city_id = city.id, country_id = city.country_id, latitude = $5, longitude = $6, category = NULLIF($7, ''),
+			version = sight.version + 1
+		FROM city WHERE city.id = $4 AND sight.id = $8 AND sight.version = $9`,
+		sight.Rating, sight.Name, sight.Description, sight.CityID, latitude, longitude, sight.Category, sight.ID, version)
+	if isUniqueSightViolation(err) {
+		return entities.Sight{}, ErrSightExists
+	}
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.Sight{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.Sight{}, repo.explainNoUpdate(ctx, sight.ID, sight.CityID)
+	}
+
+	return repo.GetSightForAdmin(ctx, sight.ID)
+}
+
+// Убирает достопримечательность из списков и поиска. version == 0 - без проверки версии
+func (repo *SightRepo) ArchiveSight(ctx context.Context, sightID, version int) (entities.Sight, error) {
+	tag, err := repo.db.Exec(ctx, `UPDATE sight SET archived_at = COALESCE(archived_at, now()), version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)`, sightID, version)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.Sight{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.Sight{}, repo.explainNoUpdate(ctx, sightID, 0)
+	}
+
+	return repo.GetSightForAdmin(ctx, sightID)
+}
+
+// Возвращает архивную достопримечательность в списки
+func (repo *SightRepo) UnarchiveSight(ctx context.Context, sightID, version int) (entities.Sight, error) {
+	tag, err := repo.db.Exec(ctx, `UPDATE sight SET archived_at = NULL, version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)`, sightID, version)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.Sight{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.Sight{}, repo.explainNoUpdate(ctx, sightID, 0)
+	}
+
+	return repo.GetSightForAdmin(ctx, sightID)
+}
+
+// Нарушение UNIQUE (name, city_id)
+func isUniqueSightViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// Почему UPDATE не затронул строк: нет достопримечательности, нет города или другая версия
+func (repo *SightRepo) explainNoUpdate(ctx context.Context, sightID, cityID int) error {
+	var sightExists, cityExists bool
+	err := repo.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM sight WHERE id = $1),
+			$2 = 0 OR EXISTS (SELECT 1 FROM city WHERE id = $2)`, sightID, cityID).Scan(&sightExists, &cityExists)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	switch {
+	case !sightExists:
+		return ErrNotFound
+	case !cityExists:
+		return ErrUnknownCity
+	}
+	return ErrVersionConflict
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// Радиус земли, который использует earthdistance (функция earth()), в километрах
+const earthRadiusKM = 6378.168
+
+// Расстояние по большому кругу (haversine) между двумя точками в километрах
+func haversineKM(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKM * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Ширина прямоугольника по долготе с учётом перехода через 180-й меридиан
+func bboxLonSpan(box entities.BoundingBox) float64 {
+	if box.MinLon <= box.MaxLon {
+		return box.MaxLon - box.MinLon
+	}
+	return box.MaxLon - box.MinLon + 360
+}
+
+// Центр прямоугольника, от него считается distance_km
+func bboxCenter(box entities.BoundingBox) (float64, float64) {
+	lon := box.MinLon + bboxLonSpan(box)/2
+	if lon > 180 {
+		lon -= 360
+	}
+	return (box.MinLat + box.MaxLat) / 2, lon
+}
+
+// Радиус круга с центром в центре прямоугольника, накрывающего его целиком:
+// по нему условие попадает в индекс, точная проверка - по координатам.
+// Для прямоугольников шире полушария круга нет, ok == false
+func bboxCoveringRadiusKM(box entities.BoundingBox) (float64, bool) {
+	if bboxLonSpan(box) > 180 {
+		return 0, false
+	}
+	lat, lon := bboxCenter(box)
+	var radius float64
+	for _, cornerLat := range []float64{box.MinLat, box.MaxLat} {
+		for _, cornerLon := range []float64{box.MinLon, box.MaxLon} {
+			radius = math.Max(radius, haversineKM(lat, lon, cornerLat, cornerLon))
+		}
+	}
+	// запас на погрешность вычислений
+	return radius*1.01 + 1, true
+}
+
+const geoSightColumns = `sight.id, sight.rating, sight.name, COALESCE(sight.description, '') AS description,
+	sight.city_id, sight.country_id, COALESCE(city.city, '') AS city, COALESCE(country.country, '') AS country,
+	COALESCE(sight.category, '') AS category, COALESCE(im.path, '') AS path, sight.latitude, sight.longitude`
+
+// Достопримечательности без картинок тоже на карте, путь у них пустой
+const geoSightJoins = `LEFT JOIN LATERAL (SELECT path FROM image_data WHERE sight_id = sight.id ORDER BY id LIMIT 1) AS im ON true
+	LEFT JOIN city ON city.id = sight.city_id
+	LEFT JOIN country ON country.id = sight.country_id`
+
+// Достопримечательности в радиусе от точки, ближайшие первыми
+func (repo *SightRepo) GetSightsNearby(ctx context.Context, lat, lon, radiusKM float64, limit int) ([]entities.NearbySight, error) {
+	var sights []*entities.NearbySight
+	err := pgxscan.Select(ctx, repo.db, &sights, `SELECT `+geoSightColumns+`,
+			earth_distance(ll_to_earth($1, $2), ll_to_earth(sight.latitude, sight.longitude)) / 1000 AS distance_km
+		FROM sight `+geoSightJoins+`
+		WHERE sight.latitude IS NOT NULL AND sight.longitude IS NOT NULL AND sight.archived_at IS NULL
+			AND earth_box(ll_to_earth($1, $2), $3 * 1000) @> ll_to_earth(sight.latitude, sight.longitude)
+			AND earth_distance(ll_to_earth($1, $2), ll_to_earth(sight.latitude, sight.longitude)) <= $3 * 1000
+		ORDER BY distance_km, sight.id
+		LIMIT $4`, lat, lon, radiusKM, limit)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	return derefNearbySights(sights), nil
+}
+
+// Условия попадания в прямоугольник. Если его накрывает круг, добавляется
+// условие по earth_box - по нему работает индекс sight_earth_idx
+func bboxConditions(box entities.BoundingBox, param func(value interface{}) string) []string {
+	conditions := []string{
+		"sight.latitude IS NOT NULL AND sight.longitude IS NOT NULL",
+		"sight.archived_at IS NULL",
+		"sight.latitude BETWEEN " + param(box.MinLat) + " AND " + param(box.MaxLat),
+	}
+	if box.MinLon <= box.MaxLon {
+		conditions = append(conditions, "sight.longitude BETWEEN "+param(box.MinLon)+" AND "+param(box.MaxLon))
+	} else {
+		conditions = append(conditions, "(sight.longitude >= "+param(box.MinLon)+" OR sight.longitude <= "+param(box.MaxLon)+")")
+	}
+	if radius, ok := bboxCoveringRadiusKM(box); ok {
+		lat, lon := bboxCenter(box)
+		conditions = append(conditions, "earth_box(ll_to_earth("+param(lat)+", "+param(lon)+"), "+param(radius*1000)+
+			") @> ll_to_earth(sight.latitude, sight.longitude)")
+	}
+	return conditions
+}
+
+// Достопримечательности внутри прямоугольника карты, ближайшие к его центру первыми
+func (repo *SightRepo) GetSightsInBBox(ctx context.Context, box entities.BoundingBox, limit int) ([]entities.NearbySight, error) {
+	var queryParams []interface{}
+	param := func(value interface{}) string {
+		queryParams = append(queryParams, value)
+		return "$" + strconv.Itoa(len(queryParams))
+	}
+
+	lat, lon := bboxCenter(box)
+	center := "ll_to_earth(" + param(lat) + ", " + param(lon) + ")"
+	conditions := bboxConditions(box, param)
+
+	var sights []*entities.NearbySight
+	err := pgxscan.Select(ctx, repo.db, &sights, `SELECT `+geoSightColumns+`,
+			earth_distance(`+center+`, ll_to_earth(sight.latitude, sight.longitude)) / 1000 AS distance_km
+		FROM sight `+geoSightJoins+`
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY distance_km, sight.id
+		LIMIT `+param(limit), queryParams...)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	return derefNearbySights(sights), nil
+}
+
+// Все достопримечательности в прямоугольнике для кластеризации, только id, рейтинг
+// и координаты. Без ограничения числа, иначе кластеры недосчитаются: размер
+// прямоугольника ограничивает обработчик (cluster.MaxSpan)
+func (repo *SightRepo) GetSightPointsInBBox(ctx context.Context, box entities.BoundingBox) ([]entities.Sight, error) {
+	var queryParams []interface{}
+	param := func(value interface{}) string {
+		queryParams = append(queryParams, value)
+		return "$" + strconv.Itoa(len(queryParams))
+	}
+	conditions := bboxConditions(box, param)
+
+	var sights []*entities.Sight
+	err := pgxscan.Select(ctx, repo.db, &sights, `SELECT sight.id, sight.rating, sight.latitude, sight.longitude
+		FROM sight
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY sight.id`, queryParams...)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	return derefSights(sights), nil
+}
+
+// Достопримечательности по списку id для карты (представители кластеров), в порядке id
+func (repo *SightRepo) GetGeoSightsByIDs(ctx context.Context, ids []int) ([]entities.Sight, error) {
+	var sights []*entities.Sight
+	err := pgxscan.Select(ctx, repo.db, &sights, `SELECT `+geoSightColumns+`
+		FROM sight `+geoSightJoins+`
+		WHERE sight.id = ANY($1)
+		ORDER BY sight.id`, ids)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	return derefSights(sights), nil
+}
+
+func derefSights(sights []*entities.Sight) []entities.Sight {
+	sightList := make([]entities.Sight, 0, len(sights))
+	for _, s := range sights {
+		sightList = append(sightList, *s)
+	}
+	return sightList
+}
+
+func derefNearbySights(sights []*entities.NearbySight) []entities.NearbySight {
+	sightList := make([]entities.NearbySight, 0, len(sights))
+	for _, s := range sights {
+		sightList = append(sightList, *s)
+	}
+	return sightList
+}
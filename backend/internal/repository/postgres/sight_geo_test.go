@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHaversineKM(t *testing.T) {
+	// Москва - Санкт-Петербург
+	assert.InDelta(t, 634, haversineKM(55.7558, 37.6173, 59.9343, 30.3351), 3)
+	assert.Zero(t, haversineKM(10, 20, 10, 20))
+}
+
+func TestBBoxCenter(t *testing.T) {
+	lat, lon := bboxCenter(entities.BoundingBox{MinLat: 50, MinLon: 30, MaxLat: 60, MaxLon: 40})
+	assert.Equal(t, 55.0, lat)
+	assert.Equal(t, 35.0, lon)
+
+	// прямоугольник через 180-й меридиан
+	_, lon = bboxCenter(entities.BoundingBox{MinLat: 60, MinLon: 170, MaxLat: 70, MaxLon: -170})
+	assert.Equal(t, 180.0, lon)
+	_, lon = bboxCenter(entities.BoundingBox{MinLat: 60, MinLon: 175, MaxLat: 70, MaxLon: -165})
+	assert.Equal(t, -175.0, lon)
+}
+
+func TestBBoxCoveringRadiusKM(t *testing.T) {
+	box := entities.BoundingBox{MinLat: 55.5, MinLon: 37.3, MaxLat: 56, MaxLon: 37.9}
+	radius, ok := bboxCoveringRadiusKM(box)
+	assert.True(t, ok)
+
+	lat, lon := bboxCenter(box)
+	for _, corner := range [][2]float64{{55.5, 37.3}, {55.5, 37.9}, {56, 37.3}, {56, 37.9}} {
+		assert.Less(t, haversineKM(lat, lon, corner[0], corner[1]), radius)
+	}
+
+	_, ok = bboxCoveringRadiusKM(entities.BoundingBox{MinLat: -10, MinLon: -120, MaxLat: 10, MaxLon: 120})
+	assert.False(t, ok, "boxes wider than a hemisphere are not covered by a circle")
+}
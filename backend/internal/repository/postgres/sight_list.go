@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Позиция последней выданной записи. Курсор привязан к сортировке:
+// с другой сортировкой он не принимается
+type sightCursor struct {
+	Sort   string  `json:"s"`
+	ID     int     `json:"i"`
+	Rating float64 `json:"r,omitempty"`
+	Name   string  `json:"n,omitempty"`
+}
+
+func encodeSightCursor(c sightCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeSightCursor(cursor, sort string) (sightCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return sightCursor{}, ErrInvalidCursor
+	}
+	var c sightCursor
+	if err = json.Unmarshal(raw, &c); err != nil || c.Sort != sort || c.ID <= 0 {
+		return sightCursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Запрос страницы. Значения фильтров передаются только параметрами,
+// в текст запроса попадают лишь заранее известные фрагменты
+func buildSightsQuery(filter entities.SightFilter) (string, []interface{}, error) {
+	// архивные достопримечательности в списке не показываются
+	conditions := []string{"sight.archived_at IS NULL"}
+	var queryParams []interface{}
+	param := func(value interface{}) string {
+		queryParams = append(queryParams, value)
+		return "$" + strconv.Itoa(len(queryParams))
+	}
+
+	if filter.MinRating > 0 {
+		conditions = append(conditions, "sight.rating >= "+param(filter.MinRating))
+	}
+	if filter.CityID != 0 {
+		conditions = append(conditions, "sight.city_id = "+param(filter.CityID))
+	}
+	if filter.CountryID != 0 {
+		conditions = append(conditions, "sight.country_id = "+param(filter.CountryID))
+	}
+	if filter.Category != "" {
+		conditions = append(conditions, "sight.category = "+param(filter.Category))
+	}
+
+	var order string
+	switch filter.Sort {
+	case entities.SortRating:
+		order = "sight.rating DESC, sight.id DESC"
+	case entities.SortName:
+		order = "sight.name ASC, sight.id ASC"
+	case entities.SortNewest:
+		order = "sight.id DESC"
+	default:
+		return "", nil, errors.Errorf("unknown sort %q", filter.Sort)
+	}
+
+	if filter.Cursor != "" {
+		c, err := decodeSightCursor(filter.Cursor, filter.Sort)
+		if err != nil {
+			return "", nil, err
+		}
+		switch filter.Sort {
+		case entities.SortRating:
+			conditions = append(conditions, "(sight.rating, sight.id) < ("+param(c.Rating)+", "+param(c.ID)+")")
+		case entities.SortName:
+			conditions = append(conditions, "(sight.name, sight.id) > ("+param(c.Name)+", "+param(c.ID)+")")
+		case entities.SortNewest:
+			conditions = append(conditions, "sight.id < "+param(c.ID))
+		}
+	}
+
+	// у достопримечательности может быть несколько картинок, в списке - первая,
+	// созданные в админке и импортированные без картинок тоже показываются, с пустым путём
+	query := `SELECT sight.id, sight.rating, sight.rating AS cursor_rating, sight.name, COALESCE(sight.description, '') AS description,
+		sight.city_id, sight.country_id, COALESCE(city.city, '') AS city, COALESCE(country.country, '') AS country,
+		COALESCE(sight.category, '') AS category, COALESCE(im.path, '') AS path,
+		COALESCE(sight.latitude, 0) AS latitude, COALESCE(sight.longitude, 0) AS longitude
+		FROM sight LEFT JOIN LATERAL (SELECT path FROM image_data WHERE sight_id = sight.id ORDER BY id LIMIT 1) AS im ON true
+		LEFT JOIN city ON city.id = sight.city_id
+		LEFT JOIN country ON country.id = sight.country_id`
+	query += " WHERE " + strings.Join(conditions, " AND ")
+	// на одну запись больше, чтобы понять, есть ли следующая страница
+	query += " ORDER BY " + order + " LIMIT " + param(filter.Limit+1)
+
+	return query, queryParams, nil
+}
+
+// rating в entities.Sight - float32, а для курсора нужно точное значение из бд,
+// иначе записи с тем же рейтингом попадут на следующую страницу повторно
+type sightRow struct {
+	entities.Sight
+	CursorRating float64
+}
+
+// Страница списка достопримечательностей и курсор следующей
+func (repo *SightRepo) GetSightsPage(ctx context.Context, filter entities.SightFilter) ([]entities.Sight, string, error) {
+	query, queryParams, err := buildSightsQuery(filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var sights []*sightRow
+	err = pgxscan.Select(ctx, repo.db, &sights, query, queryParams...)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(sights) > filter.Limit {
+		sights = sights[:filter.Limit]
+		last := sights[len(sights)-1]
+		nextCursor = encodeSightCursor(sightCursor{
+			Sort:   filter.Sort,
+			ID:     last.ID,
+			Rating: last.CursorRating,
+			Name:   last.Name,
+		})
+	}
+
+	sightList := make([]entities.Sight, 0, len(sights))
+	for _, s := range sights {
+		sightList = append(sightList, s.Sight)
+	}
+	return sightList, nextCursor, nil
+}
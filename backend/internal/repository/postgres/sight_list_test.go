@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSightCursorRoundTrip(t *testing.T) {
+	cursor := encodeSightCursor(sightCursor{Sort: entities.SortRating, ID: 42, Rating: 4.7})
+
+	decoded, err := decodeSightCursor(cursor, entities.SortRating)
+	require.NoError(t, err)
+	assert.Equal(t, 42, decoded.ID)
+	assert.Equal(t, 4.7, decoded.Rating)
+
+	_, err = decodeSightCursor(cursor, entities.SortName)
+	assert.Equal(t, ErrInvalidCursor, err, "cursor is bound to its sort")
+
+	_, err = decodeSightCursor("not a cursor", entities.SortRating)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestBuildSightsQuery(t *testing.T) {
+	query, params, err := buildSightsQuery(entities.SightFilter{
+		Limit:     20,
+		Sort:      entities.SortRating,
+		MinRating: 4,
+		CityID:    3,
+		Category:  "museum",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, query, "sight.rating >= $1 AND sight.city_id = $2 AND sight.category = $3")
+	assert.True(t, strings.HasSuffix(query, "ORDER BY sight.rating DESC, sight.id DESC LIMIT $4"))
+	assert.Equal(t, []interface{}{4.0, 3, "museum", 21}, params)
+
+	cursor := encodeSightCursor(sightCursor{Sort: entities.SortName, ID: 7, Name: "Кремль'; DROP TABLE sight"})
+	query, params, err = buildSightsQuery(entities.SightFilter{Limit: 10, Sort: entities.SortName, Cursor: cursor})
+	require.NoError(t, err)
+	assert.Contains(t, query, "(sight.name, sight.id) > ($1, $2)")
+	assert.NotContains(t, query, "DROP TABLE")
+	assert.Equal(t, []interface{}{"Кремль'; DROP TABLE sight", 7, 11}, params)
+
+	_, _, err = buildSightsQuery(entities.SightFilter{Limit: 10, Sort: entities.SortNewest, Cursor: cursor})
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	_, _, err = buildSightsQuery(entities.SightFilter{Limit: 10, Sort: "price"})
+	assert.Error(t, err)
+}
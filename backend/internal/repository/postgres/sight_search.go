@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+// Слова запроса как префиксы: "москв" находит "Москва" и "Москвы".
+// Оставляются только буквы и цифры, поэтому синтаксис tsquery из запроса не проходит
+func buildPrefixTSQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// ts_headline не экранирует текст, поэтому экранируем всё, кроме своих меток
+func highlightToHTML(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = strings.ReplaceAll(escaped, html.EscapeString(highlightStart), highlightStart)
+	return strings.ReplaceAll(escaped, html.EscapeString(highlightStop), highlightStop)
+}
+
+// Пороги pg_trgm для поиска с опечатками. word_similarity - для названия достопримечательности
+// (запрос похож на часть названия), similarity - для города и страны целиком.
+// Задаются на транзакцию, операторы <% и % используют их вместе с триграммными индексами
+const (
+	searchWordSimilarityThreshold = 0.5
+	searchSimilarityThreshold     = 0.3
+)
+
+const searchThresholdsQuery = `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true),
+	set_config('pg_trgm.similarity_threshold', $2, true)`
+
+// Параметры searchThresholdsQuery: set_config принимает текст
+func searchThresholdsParams() []interface{} {
+	return []interface{}{
+		strconv.FormatFloat(searchWordSimilarityThreshold, 'f', -1, 64),
+		strconv.FormatFloat(searchSimilarityThreshold, 'f', -1, 64),
+	}
+}
+
+// Запрос поиска и его параметры, ok == false - в запросе нет ни одного слова.
+// Ранг - ts_rank_cd по tsquery из префиксов плюс похожесть названия, города и страны.
+// Текст пользователя передаётся только параметрами: $1 - tsquery, $2 - сам запрос для pg_trgm
+func buildSearchQuery(query string, limit int) (string, []interface{}, bool) {
+	tsQuery := buildPrefixTSQuery(query)
+	if tsQuery == "" {
+		return "", nil, false
+	}
+
+	return `WITH q AS (SELECT to_tsquery('russian', $1) AS tsq)
+		SELECT sight.id, sight.rating, sight.name, COALESCE(sight.description, '') AS description, sight.city_id, sight.country_id,
+			COALESCE(city.city, '') AS city, COALESCE(country.country, '') AS country,
+			COALESCE(sight.category, '') AS category, COALESCE(im.path, '') AS path,
+			COALESCE(sight.latitude, 0) AS latitude, COALESCE(sight.longitude, 0) AS longitude,
+			ts_rank_cd(sight.search_vector, q.tsq) + word_similarity($2, sight.name)
+				+ COALESCE(similarity(city.city, $2), 0) / 2 + COALESCE(similarity(country.country, $2), 0) / 2 AS rank,
+			ts_headline('russian', sight.name, q.tsq, 'HighlightAll=true, StartSel="<mark>", StopSel="</mark>"') AS name_highlight,
+			ts_headline('russian', COALESCE(sight.description, ''), q.tsq,
+				'StartSel="<mark>", StopSel="</mark>", MaxWords=35, MinWords=15, MaxFragments=2') AS snippet
+		FROM sight CROSS JOIN q
+		LEFT JOIN LATERAL (SELECT path FROM image_data WHERE sight_id = sight.id ORDER BY id LIMIT 1) AS im ON true
+		LEFT JOIN city ON city.id = sight.city_id
+		LEFT JOIN country ON country.id = sight.country_id
+		WHERE sight.archived_at IS NULL
+			AND (sight.search_vector @@ q.tsq OR $2 <% sight.name OR city.city % $2 OR country.country % $2)
+		ORDER BY rank DESC, sight.rating DESC, sight.id
+		LIMIT $3`, []interface{}{tsQuery, query, limit}, true
+}
+
+// Поиск по названию и описанию с русской морфологией. Опечатки в названии
+// достопримечательности, города или страны находятся через pg_trgm
+func (repo *SightRepo) SearchSights(ctx context.Context, query string, limit int) ([]entities.SightSearchResult, error) {
+	searchQuery, params, ok := buildSearchQuery(query, limit)
+	if !ok {
+		return []entities.SightSearchResult{}, nil
+	}
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, searchThresholdsQuery, searchThresholdsParams()...); err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	var results []*entities.SightSearchResult
+	err = pgxscan.Select(ctx, tx, &results, searchQuery, params...)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	resultList := make([]entities.SightSearchResult, 0, len(results))
+	for _, r := range results {
+		r.NameHighlight = highlightToHTML(r.NameHighlight)
+		r.Snippet = highlightToHTML(r.Snippet)
+		resultList = append(resultList, *r)
+	}
+	return resultList, nil
+}
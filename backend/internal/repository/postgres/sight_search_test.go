@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPrefixTSQuery(t *testing.T) {
+	assert.Equal(t, "москв:*", buildPrefixTSQuery("Москв"))
+	assert.Equal(t, "красная:* & площадь:*", buildPrefixTSQuery("  Красная площадь! "))
+	assert.Equal(t, "a:* & b:*", buildPrefixTSQuery("a:* | !b"), "tsquery syntax is stripped")
+	assert.Empty(t, buildPrefixTSQuery("&|!()"))
+}
+
+func TestHighlightToHTML(t *testing.T) {
+	assert.Equal(t, "<mark>Кремль</mark> &lt;script&gt;", highlightToHTML("<mark>Кремль</mark> <script>"))
+}
+
+func TestBuildSearchQuery(t *testing.T) {
+	query, params, ok := buildSearchQuery("москв", 20)
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"москв:*", "москв", 20}, params)
+
+	// префиксный полнотекстовый поиск и ранг из ts_rank_cd и похожести триграмм
+	assert.Contains(t, query, "to_tsquery('russian', $1) AS tsq")
+	assert.Contains(t, query, "sight.search_vector @@ q.tsq")
+	assert.Contains(t, query, "ts_rank_cd(sight.search_vector, q.tsq) + word_similarity($2, sight.name)")
+	assert.Contains(t, query, "similarity(city.city, $2)")
+	assert.Contains(t, query, "similarity(country.country, $2)")
+	assert.True(t, strings.HasSuffix(query, "ORDER BY rank DESC, sight.rating DESC, sight.id\n\t\tLIMIT $3"))
+
+	// опечатки - через операторы pg_trgm с параметром
+	assert.Contains(t, query, "$2 <% sight.name OR city.city % $2 OR country.country % $2")
+	assert.Contains(t, query, "sight.archived_at IS NULL")
+
+	injection := "Кремль'; DROP TABLE sight; --"
+	query, params, ok = buildSearchQuery(injection, 5)
+	require.True(t, ok)
+	assert.NotContains(t, query, "DROP TABLE")
+	assert.NotContains(t, query, "Кремль")
+	assert.Equal(t, []interface{}{"кремль:* & drop:* & table:* & sight:*", injection, 5}, params)
+
+	_, _, ok = buildSearchQuery(" !&| ", 5)
+	assert.False(t, ok, "query without words is not sent")
+}
+
+func TestSearchThresholds(t *testing.T) {
+	assert.Contains(t, searchThresholdsQuery, "set_config('pg_trgm.word_similarity_threshold', $1, true)")
+	assert.Contains(t, searchThresholdsQuery, "set_config('pg_trgm.similarity_threshold', $2, true)")
+	assert.Equal(t, []interface{}{"0.5", "0.3"}, searchThresholdsParams())
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TOTPRepo - секреты двухфакторной аутентификации (user_totp) и коды восстановления (totp_recovery_code)
+type TOTPRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewTOTPRepo creates totp repo
+func NewTOTPRepo(db *pgxpool.Pool) *TOTPRepo {
+	return &TOTPRepo{
+		db: db,
+	}
+}
+
+func (repo *TOTPRepo) GetTOTP(ctx context.Context, userID int) (entities.TOTP, bool, error) {
+	var totp []*entities.TOTP
+
+	err := pgxscan.Select(ctx, repo.db, &totp, `SELECT user_id, secret, enabled, last_step FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.TOTP{}, false, err
+	}
+	if len(totp) == 0 {
+		return entities.TOTP{}, false, nil
+	}
+
+	return *totp[0], true, nil
+}
+
+// Включённый секрет не перезаписывается
+func (repo *TOTPRepo) SaveTOTPSecret(ctx context.Context, userID int, secret string) error {
+	_, err := repo.db.Exec(ctx, `INSERT INTO user_totp(user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_step = 0, created_at = now()
+		WHERE user_totp.enabled = false`, userID, secret)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (repo *TOTPRepo) EnableTOTP(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `UPDATE user_totp SET enabled = true, last_step = $2 WHERE user_id = $1`, userID, step); err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM totp_recovery_code WHERE user_id = $1`, userID); err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err = tx.Exec(ctx, `INSERT INTO totp_recovery_code(user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			logger.Logger().Error(err.Error())
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// Удаляет секрет вместе с кодами восстановления
+func (repo *TOTPRepo) DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `DELETE FROM totp_recovery_code WHERE user_id = $1`, userID); err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+// Условие last_step < $2 защищает от повторного использования кода параллельными запросами
+func (repo *TOTPRepo) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	tag, err := repo.db.Exec(ctx, `UPDATE user_totp SET last_step = $2 WHERE user_id = $1 AND last_step < $2`, userID, step)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (repo *TOTPRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	tag, err := repo.db.Exec(ctx, `DELETE FROM totp_recovery_code WHERE user_id = $1 AND code_hash = $2`, userID, codeHash)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
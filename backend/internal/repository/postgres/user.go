@@ -6,14 +6,18 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"homework_ipl/internal/entities"
+	"homework_ipl/internal/password"
 	"homework_ipl/utils/errors"
 	"homework_ipl/utils/logger"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/crypto/bcrypt"
+	pkgErrors "github.com/pkg/errors"
 )
 
 var (
@@ -35,30 +39,72 @@ func NewUserRepo(db *pgxpool.Pool) *UserRepo {
 	}
 }
 
-func (repo *UserRepo) CreateUser(dataStr map[string]string) (entities.User, error) {
-	ctx := context.Background()
+// Новый пользователь вместе с профилем в одной транзакции.
+// user.Passwrd - уже посчитанный хэш пароля
+func (repo *UserRepo) CreateUser(ctx context.Context, user entities.User) (entities.User, error) {
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.User{}, err
+	}
+	defer tx.Rollback(ctx)
 
-	var userID int
-	query := `INSERT INTO "user_data"(email, passwrd) VALUES ($1, $2) RETURNING id`
-	err := repo.db.QueryRow(ctx, query, dataStr["email"], dataStr["passwrd"]).Scan(&userID)
+	user.ID, err = insertUser(ctx, tx, user)
 	if err != nil {
+		return entities.User{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
 		logger.Logger().Error(err.Error())
 		return entities.User{}, err
 	}
 
-	newUser := entities.User{
-		ID:    userID,
-		Email: dataStr["email"],
+	user.Passwrd = ""
+	return user, nil
+}
+
+// Вставка user_data и profile_data внутри транзакции. Имя профиля по умолчанию - email.
+// Нарушение уникальности возвращается как entities.ErrEmailTaken / ErrUsernameTaken
+func insertUser(ctx context.Context, tx pgx.Tx, user entities.User) (int, error) {
+	if user.Role == "" {
+		user.Role = entities.RoleUser
 	}
 
-	return newUser, nil
+	var userID int
+	err := tx.QueryRow(ctx, `INSERT INTO user_data(email, passwrd, role, email_verified) VALUES ($1, $2, $3, $4) RETURNING id`,
+		user.Email, user.Passwrd, user.Role, user.EmailVerified).Scan(&userID)
+	if err != nil {
+		return 0, uniqueUserError(err)
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO profile_data(user_id, username, bio, avatar) VALUES ($1, $2, '', '')`, userID, user.Email)
+	if err != nil {
+		return 0, uniqueUserError(err)
+	}
+
+	return userID, nil
 }
 
-// Запрос в бд для авторизации - поиск по email, сравнение найденного хэша в бд с указанным паролем
+func uniqueUserError(err error) error {
+	var pgErr *pgconn.PgError
+	if pkgErrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		switch pgErr.ConstraintName {
+		case "user_data_email_key":
+			return entities.ErrEmailTaken
+		case "profile_data_username_key":
+			return entities.ErrUsernameTaken
+		}
+	}
+	logger.Logger().Error(err.Error())
+	return err
+}
+
+// Запрос в бд для авторизации - поиск по email, сравнение найденного хэша в бд с указанным паролем.
+// Хэш старого формата (bcrypt или argon2id с прежними параметрами) после успешного входа пересчитывается
 func (repo *UserRepo) AuthorizeUser(dataStr map[string]string) (entities.User, error) {
 	var user []*entities.User
 	ctx := context.Background()
-	err := pgxscan.Select(ctx, repo.db, &user, `SELECT id, email, passwrd FROM user_data WHERE email = $1`, dataStr["email"])
+	err := pgxscan.Select(ctx, repo.db, &user, `SELECT id, email, passwrd, role FROM user_data WHERE email = $1`, dataStr["email"])
 
 	if err != nil {
 		logger.Logger().Error(err.Error())
@@ -70,21 +116,44 @@ func (repo *UserRepo) AuthorizeUser(dataStr map[string]string) (entities.User, e
 		return entities.User{}, err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user[0].Passwrd), []byte(dataStr["passwrd"]))
-
+	match, err := password.Verify(dataStr["passwrd"], user[0].Passwrd)
 	if err != nil {
-		fmt.Println("Passwords not match!")
+		logger.Logger().Error(err.Error())
 		return entities.User{}, err
 	}
+	if !match {
+		fmt.Println("Passwords not match!")
+		return entities.User{}, nil
+	}
 
+	// неудачный перехэш не мешает входу, попробуем в следующий раз
+	if password.NeedsRehash(user[0].Passwrd) {
+		if err = repo.setPasswordHash(ctx, user[0].ID, user[0].Passwrd, dataStr["passwrd"]); err != nil {
+			logger.Logger().Error("Error while rehashing password", "userID", user[0].ID, "error", err)
+		}
+	}
+
+	user[0].Passwrd = ""
 	return *user[0], nil
 }
 
+// Замена хэша на новый, только если в бд всё ещё старый: параллельная смена пароля не затирается
+func (repo *UserRepo) setPasswordHash(ctx context.Context, userID int, oldHash, pw string) error {
+	hash, err := password.Hash(pw)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.Exec(ctx, `UPDATE user_data SET passwrd = $1 WHERE id = $2 AND passwrd = $3`, hash, userID, oldHash)
+	return err
+}
+
 func (repo *UserRepo) GetUserProfile(dataInt map[string]int) (entities.UserProfile, error) {
 	var user []entities.UserProfile
 	ctx := context.Background()
 
-	err := pgxscan.Select(ctx, repo.db, &user, `SELECT user_id, username, bio, avatar FROM profile_data WHERE user_id = $1`, dataInt["userID"])
+	err := pgxscan.Select(ctx, repo.db, &user, `SELECT p.user_id, p.username, p.bio, p.avatar FROM profile_data p
+		JOIN user_data u ON u.id = p.user_id WHERE p.user_id = $1 AND u.deleted_at IS NULL`, dataInt["userID"])
 
 	if err != nil {
 		logger.Logger().Error(err.Error())
@@ -99,23 +168,6 @@ func (repo *UserRepo) GetUserProfile(dataInt map[string]int) (entities.UserProfi
 	return user[0], nil
 }
 
-func (repo *UserRepo) DeleteUserProfile(dataInt map[string]int) error {
-	ctx := context.Background()
-
-	_, err := repo.db.Exec(ctx, `DELETE FROM profile_data WHERE user_id = $1`, dataInt["userID"])
-	if err != nil {
-		logger.Logger().Error(err.Error())
-		return err
-	}
-	_, err = repo.db.Exec(ctx, `DELETE FROM user_data WHERE id = $1`, dataInt["userID"])
-	if err != nil {
-		logger.Logger().Error(err.Error())
-		return err
-	}
-
-	return nil
-}
-
 func (repo *UserRepo) EditUserProfile(dataInt map[string]int, dataStr map[string]string) (entities.UserProfile, error) {
 	ctx := context.Background()
 
@@ -174,14 +226,14 @@ func (repo *UserRepo) UpdateUserPassword(userID int, newPassword string) error {
 	logger := logger.Logger()
 
 	// хэширование пароля (в бд не хранятся пароли, а закодированные пароли - хэши)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := password.Hash(newPassword)
 	if err != nil {
 		logger.Error(err.Error())
 		return err
 	}
 
 	// запись хэша по айди пользователя
-	_, err = repo.db.Exec(ctx, `UPDATE user_data SET passwrd = $1 WHERE id = $2`, string(hashedPassword), userID)
+	_, err = repo.db.Exec(ctx, `UPDATE user_data SET passwrd = $1 WHERE id = $2`, hashedPassword, userID)
 	if err != nil {
 		logger.Error(err.Error())
 		return err
@@ -209,3 +261,260 @@ func (repo *UserRepo) GetHashPassword(userID int) (string, error) {
 
 	return hash[0], nil
 }
+
+// Список пользователей для админки
+func (repo *UserRepo) GetUsers() ([]entities.User, error) {
+	var users []*entities.User
+	ctx := context.Background()
+
+	err := pgxscan.Select(ctx, repo.db, &users, `SELECT id, email, role FROM user_data ORDER BY id`)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	var userList []entities.User
+	for _, u := range users {
+		userList = append(userList, *u)
+	}
+	return userList, nil
+}
+
+// Смена роли пользователя, возвращает false, если пользователя нет
+func (repo *UserRepo) SetUserRole(userID int, role string) (bool, error) {
+	ctx := context.Background()
+
+	tag, err := repo.db.Exec(ctx, `UPDATE user_data SET role = $1 WHERE id = $2`, role, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// Назначение администратора при старте: существующий пользователь получает
+// роль admin, иначе создаётся новый с указанным паролем
+func (repo *UserRepo) SeedAdmin(email, adminPassword string) error {
+	ctx := context.Background()
+
+	tag, err := repo.db.Exec(ctx, `UPDATE user_data SET role = $1, email_verified = true WHERE email = $2`, entities.RoleAdmin, email)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	if adminPassword == "" {
+		return fmt.Errorf("admin password is required to create admin %s", email)
+	}
+
+	hashedPassword, err := password.Hash(adminPassword)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	_, err = repo.CreateUser(ctx, entities.User{
+		Email:         email,
+		Passwrd:       hashedPassword,
+		Role:          entities.RoleAdmin,
+		EmailVerified: true,
+	})
+	return err
+}
+
+// Данные для входа по айди (email, роль, подтверждена ли почта)
+func (repo *UserRepo) GetUserByID(userID int) (entities.User, error) {
+	var users []*entities.User
+	ctx := context.Background()
+
+	err := pgxscan.Select(ctx, repo.db, &users, `SELECT id, email, role, email_verified FROM user_data WHERE id = $1`, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.User{}, err
+	}
+	if len(users) == 0 {
+		return entities.User{}, ErrNotFound
+	}
+
+	return *users[0], nil
+}
+
+// Поиск по email, ok == false, если пользователя нет
+func (repo *UserRepo) GetUserByEmail(email string) (entities.User, bool, error) {
+	var users []*entities.User
+	ctx := context.Background()
+
+	err := pgxscan.Select(ctx, repo.db, &users, `SELECT id, email, role, email_verified FROM user_data WHERE email = $1`, email)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return entities.User{}, false, err
+	}
+	if len(users) == 0 {
+		return entities.User{}, false, nil
+	}
+
+	return *users[0], true, nil
+}
+
+func (repo *UserRepo) IsEmailVerified(userID int) (bool, error) {
+	user, err := repo.GetUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerified, nil
+}
+
+// Мягкое удаление: аккаунт помечается удалённым, данные остаются до окончания периода восстановления
+func (repo *UserRepo) SoftDeleteUser(ctx context.Context, userID int, at time.Time) (bool, error) {
+	tag, err := repo.db.Exec(ctx, `UPDATE user_data SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`, at, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (repo *UserRepo) GetDeletedAt(ctx context.Context, userID int) (*time.Time, error) {
+	var deletedAt []*time.Time
+
+	err := pgxscan.Select(ctx, repo.db, &deletedAt, `SELECT deleted_at FROM user_data WHERE id = $1`, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+	if len(deletedAt) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return deletedAt[0], nil
+}
+
+func (repo *UserRepo) RestoreUser(ctx context.Context, userID int) error {
+	_, err := repo.db.Exec(ctx, `UPDATE user_data SET deleted_at = NULL WHERE id = $1`, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (repo *UserRepo) DeletedUsersBefore(ctx context.Context, before time.Time) ([]int, error) {
+	var userIDs []int
+
+	err := pgxscan.Select(ctx, repo.db, &userIDs, `SELECT id FROM user_data WHERE deleted_at < $1 ORDER BY deleted_at`, before)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// Все выгрузки пользователя, чтобы перед окончательным удалением убрать их архивы
+func (repo *UserRepo) UserExports(ctx context.Context, userID int) ([]entities.DataExport, error) {
+	var exports []*entities.DataExport
+
+	err := pgxscan.Select(ctx, repo.db, &exports, `SELECT `+exportColumns+` FROM data_export WHERE user_id = $1`, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return nil, err
+	}
+
+	result := make([]entities.DataExport, 0, len(exports))
+	for _, e := range exports {
+		result = append(result, *e)
+	}
+	return result, nil
+}
+
+// Строки, которые ссылаются на user_data без ON DELETE CASCADE: без них user_data не удалить.
+// Отзывы и ответы на опросы переходят анонимному пользователю ($1), чтобы рейтинги
+// и статистика не менялись, поездки и профиль удаляются
+var (
+	purgeReassignQueries = []string{
+		`UPDATE feedback SET user_id = $1 WHERE user_id = $2`,
+		`UPDATE quiz SET user_id = $1 WHERE user_id = $2`,
+	}
+	purgeDeleteQueries = []string{
+		`DELETE FROM journey_sight WHERE journey_id IN (SELECT id FROM journey WHERE user_id = $1)`,
+		`DELETE FROM journey WHERE user_id = $1`,
+		`DELETE FROM profile_data WHERE user_id = $1`,
+		`DELETE FROM user_data WHERE id = $1`,
+	}
+)
+
+// Окончательное удаление в одной транзакции (см. purgeReassignQueries и purgeDeleteQueries),
+// остальное (сессии, токены, 2FA, привязки OIDC, выгрузки) - каскадом вместе с user_data.
+// Журнал аудита сохраняется. Возвращает путь к аватарке
+func (repo *UserRepo) PurgeUser(ctx context.Context, userID int) (string, error) {
+	logger := logger.Logger()
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	anonymousID, err := anonymousUser(ctx, tx)
+	if err != nil {
+		return "", err
+	}
+	if anonymousID == userID {
+		return "", fmt.Errorf("cannot purge the anonymous user")
+	}
+
+	var avatar string
+	err = tx.QueryRow(ctx, `SELECT COALESCE(MAX(avatar), '') FROM profile_data WHERE user_id = $1`, userID).Scan(&avatar)
+	if err != nil {
+		logger.Error(err.Error())
+		return "", err
+	}
+
+	for _, query := range purgeReassignQueries {
+		if _, err = tx.Exec(ctx, query, anonymousID, userID); err != nil {
+			logger.Error(err.Error())
+			return "", err
+		}
+	}
+	for _, query := range purgeDeleteQueries {
+		if _, err = tx.Exec(ctx, query, userID); err != nil {
+			logger.Error(err.Error())
+			return "", err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error(err.Error())
+		return "", err
+	}
+
+	return avatar, nil
+}
+
+// Айди анонимного пользователя, создаётся при первом окончательном удалении
+func anonymousUser(ctx context.Context, tx pgx.Tx) (int, error) {
+	var userIDs []int
+	err := pgxscan.Select(ctx, tx, &userIDs, `SELECT id FROM user_data WHERE email = $1 AND passwrd = $2`,
+		entities.AnonymousEmail, entities.AnonymousPassword)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, err
+	}
+	if len(userIDs) > 0 {
+		return userIDs[0], nil
+	}
+
+	return insertUser(ctx, tx, entities.User{
+		Email:         entities.AnonymousEmail,
+		Passwrd:       entities.AnonymousPassword,
+		Role:          entities.RoleUser,
+		EmailVerified: true,
+	})
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var createTableRe = regexp.MustCompile(`(?i)CREATE TABLE (?:IF NOT EXISTS )?(\w+)`)
+
+// Таблицы со ссылкой на user_data без ON DELETE CASCADE из миграций и init.sql
+func userReferencesWithoutCascade(t *testing.T) map[string]bool {
+	t.Helper()
+	files, err := filepath.Glob("../../../db/migrations/*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	files = append(files, "../../../init.sql")
+
+	tables := make(map[string]bool)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		table := ""
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := createTableRe.FindStringSubmatch(line); m != nil {
+				table = m[1]
+			}
+			if strings.Contains(line, "REFERENCES user_data") && !strings.Contains(line, "ON DELETE CASCADE") {
+				tables[table] = true
+			}
+		}
+	}
+	return tables
+}
+
+func TestPurgeQueriesCoverUserReferences(t *testing.T) {
+	tables := userReferencesWithoutCascade(t)
+	assert.Contains(t, tables, "quiz")
+
+	last := purgeDeleteQueries[len(purgeDeleteQueries)-1]
+	require.Equal(t, `DELETE FROM user_data WHERE id = $1`, last, "user_data is deleted last")
+
+	handled := append(append([]string{}, purgeReassignQueries...), purgeDeleteQueries[:len(purgeDeleteQueries)-1]...)
+	for table := range tables {
+		covered := false
+		for _, query := range handled {
+			if strings.HasPrefix(query, "UPDATE "+table+" ") || strings.HasPrefix(query, "DELETE FROM "+table+" ") {
+				covered = true
+			}
+		}
+		assert.True(t, covered, "purge must reassign or delete %s rows before user_data", table)
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"homework_ipl/utils/logger"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// VerificationRepo - токены подтверждения почты (email_verification)
+type VerificationRepo struct {
+	db *pgxpool.Pool
+}
+
+// NewVerificationRepo creates verification repo
+func NewVerificationRepo(db *pgxpool.Pool) *VerificationRepo {
+	return &VerificationRepo{
+		db: db,
+	}
+}
+
+func (repo *VerificationRepo) CreateToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := repo.db.Exec(ctx, `INSERT INTO email_verification(token_hash, user_id, expires_at) VALUES ($1, $2, $3)`, tokenHash, userID, expiresAt)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Когда пользователю последний раз отправлялась ссылка (ok == false, если не отправлялась)
+func (repo *VerificationRepo) LastSentAt(ctx context.Context, userID int) (time.Time, bool, error) {
+	var created []time.Time
+
+	err := pgxscan.Select(ctx, repo.db, &created, `SELECT created_at FROM email_verification WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`, userID)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return time.Time{}, false, err
+	}
+	if len(created) == 0 {
+		return time.Time{}, false, nil
+	}
+
+	return created[0], true, nil
+}
+
+// Погашение токена: пользователь помечается подтверждённым, все его токены удаляются.
+// ok == false, если токена нет или он истёк
+func (repo *VerificationRepo) ConsumeToken(ctx context.Context, tokenHash string) (int, bool, error) {
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userIDs []int
+	err = pgxscan.Select(ctx, tx, &userIDs, `DELETE FROM email_verification WHERE token_hash = $1 AND expires_at > now() RETURNING user_id`, tokenHash)
+	if err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+	if len(userIDs) == 0 {
+		return 0, false, nil
+	}
+	userID := userIDs[0]
+
+	if _, err = tx.Exec(ctx, `UPDATE user_data SET email_verified = true WHERE id = $1`, userID); err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM email_verification WHERE user_id = $1`, userID); err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Logger().Error(err.Error())
+		return 0, false, err
+	}
+	return userID, true, nil
+}
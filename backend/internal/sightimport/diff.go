@@ -0,0 +1,90 @@
+package sightimport
+
+import (
+	"math"
+	"strconv"
+
+	"homework_ipl/internal/entities"
+)
+
+// Координаты в sight хранятся как REAL, сравниваем с точностью float32
+const coordinateTolerance = 1e-5
+
+// Existing - достопримечательность в базе до импорта
+type Existing struct {
+	Description string
+	Rating      float64
+	// Есть отзывы: рейтинг - их среднее (триггер update_sight_rating), импорт его не трогает
+	HasFeedback bool
+	Category    string
+	Latitude    *float64
+	Longitude   *float64
+	Images      []string
+}
+
+// Что изменит строка импорта в существующей достопримечательности.
+// Картинки только добавляются: импорт не удаляет загруженные раньше
+func Diff(existing Existing, row entities.ImportRow) []entities.ImportChange {
+	var changes []entities.ImportChange
+	change := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, entities.ImportChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	change("description", existing.Description, row.Description)
+	if rating := UpdatedRating(existing, row); existing.Rating != rating {
+		change("rating", formatFloat(&existing.Rating), formatFloat(&rating))
+	}
+	change("category", existing.Category, row.Category)
+	if !sameCoordinate(existing.Latitude, row.Latitude) {
+		change("latitude", formatFloat(existing.Latitude), formatFloat(row.Latitude))
+	}
+	if !sameCoordinate(existing.Longitude, row.Longitude) {
+		change("longitude", formatFloat(existing.Longitude), formatFloat(row.Longitude))
+	}
+
+	for _, image := range NewImages(existing.Images, row.Images) {
+		change("images", "", image)
+	}
+
+	return changes
+}
+
+// Рейтинг после импорта: из строки, только пока у достопримечательности нет отзывов
+func UpdatedRating(existing Existing, row entities.ImportRow) float64 {
+	if existing.HasFeedback {
+		return existing.Rating
+	}
+	return row.Rating
+}
+
+// Картинки строки, которых ещё нет у достопримечательности, без повторов
+func NewImages(existing, images []string) []string {
+	known := make(map[string]bool, len(existing)+len(images))
+	for _, image := range existing {
+		known[image] = true
+	}
+	var added []string
+	for _, image := range images {
+		if !known[image] {
+			known[image] = true
+			added = append(added, image)
+		}
+	}
+	return added
+}
+
+func sameCoordinate(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return math.Abs(*a-*b) < coordinateTolerance
+}
+
+func formatFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
@@ -0,0 +1,235 @@
+// Разбор и проверка файлов импорта достопримечательностей (CSV и GeoJSON).
+// Запись в базу - repository.ImportRepo, здесь только то, что не требует базы
+package sightimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"homework_ipl/internal/entities"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatGeoJSON = "geojson"
+
+	// Разделитель путей картинок в колонке images CSV
+	imageSeparator = "|"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown import format, use csv or geojson")
+	// Файл нельзя разобрать целиком: нет заголовка, битый JSON и т.п.
+	ErrMalformedFile = errors.New("malformed import file")
+)
+
+// Колонки CSV, name, city и country обязательны
+var csvColumns = map[string]bool{
+	"name": true, "description": true, "city": true, "country": true, "rating": true,
+	"category": true, "latitude": true, "longitude": true, "images": true,
+}
+
+// Формат по расширению файла: .csv, .geojson или .json
+func FormatFromFilename(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV
+	case ".geojson", ".json":
+		return FormatGeoJSON
+	}
+	return ""
+}
+
+// Разбирает файл. Строки, которые не удалось разобрать (например, рейтинг не число),
+// попадают в ошибки, а не в результат. error - файл не разобран целиком
+func Parse(r io.Reader, format string) ([]entities.ImportRow, []entities.ImportRowError, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatGeoJSON:
+		return parseGeoJSON(r)
+	}
+	return nil, nil, ErrUnknownFormat
+}
+
+func parseCSV(r io.Reader) ([]entities.ImportRow, []entities.ImportRowError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Excel сохраняет CSV в UTF-8 с BOM
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, errors.Wrap(ErrMalformedFile, "csv header")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !csvColumns[name] {
+			return nil, nil, errors.Wrapf(ErrMalformedFile, "unknown column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"name", "city", "country"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, errors.Wrapf(ErrMalformedFile, "missing column %q", required)
+		}
+	}
+
+	var rows []entities.ImportRow
+	var rowErrors []entities.ImportRowError
+	// строка 1 - заголовок
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(ErrMalformedFile, "line %d: %v", line, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := entities.ImportRow{
+			Line:        line,
+			Name:        field("name"),
+			Description: field("description"),
+			City:        field("city"),
+			Country:     field("country"),
+			Category:    field("category"),
+		}
+		var problems []string
+		if value := field("rating"); value != "" {
+			if row.Rating, err = strconv.ParseFloat(value, 64); err != nil {
+				problems = append(problems, "rating is not a number")
+			}
+		}
+		if row.Latitude, err = parseOptionalFloat(field("latitude")); err != nil {
+			problems = append(problems, "latitude is not a number")
+		}
+		if row.Longitude, err = parseOptionalFloat(field("longitude")); err != nil {
+			problems = append(problems, "longitude is not a number")
+		}
+		for _, image := range strings.Split(field("images"), imageSeparator) {
+			if image = strings.TrimSpace(image); image != "" {
+				row.Images = append(row.Images, image)
+			}
+		}
+
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, entities.ImportRowError{Line: line, Errors: problems})
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+func parseOptionalFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
+
+type geoJSONFeature struct {
+	Geometry *struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties json.RawMessage `json:"properties"`
+}
+
+type geoJSONProperties struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	City        string   `json:"city"`
+	Country     string   `json:"country"`
+	Rating      float64  `json:"rating"`
+	Category    string   `json:"category"`
+	Images      []string `json:"images"`
+	// одна картинка, как в выгрузке geoexport
+	ImageURL string `json:"image_url"`
+}
+
+// FeatureCollection из точек, Line - номер объекта в features
+func parseGeoJSON(r io.Reader) ([]entities.ImportRow, []entities.ImportRowError, error) {
+	var collection struct {
+		Type     string            `json:"type"`
+		Features []json.RawMessage `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, nil, errors.Wrap(ErrMalformedFile, err.Error())
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, nil, errors.Wrap(ErrMalformedFile, "expected a FeatureCollection")
+	}
+
+	var rows []entities.ImportRow
+	var rowErrors []entities.ImportRowError
+	for i, raw := range collection.Features {
+		line := i + 1
+
+		var feature geoJSONFeature
+		var properties geoJSONProperties
+		if err := json.Unmarshal(raw, &feature); err != nil {
+			rowErrors = append(rowErrors, entities.ImportRowError{Line: line, Errors: []string{"invalid feature"}})
+			continue
+		}
+		if len(feature.Properties) > 0 {
+			if err := json.Unmarshal(feature.Properties, &properties); err != nil {
+				rowErrors = append(rowErrors, entities.ImportRowError{Line: line, Errors: []string{"invalid properties"}})
+				continue
+			}
+		}
+
+		row := entities.ImportRow{
+			Line:        line,
+			Name:        strings.TrimSpace(properties.Name),
+			Description: strings.TrimSpace(properties.Description),
+			City:        strings.TrimSpace(properties.City),
+			Country:     strings.TrimSpace(properties.Country),
+			Rating:      properties.Rating,
+			Category:    strings.TrimSpace(properties.Category),
+			Images:      properties.Images,
+		}
+		if properties.ImageURL != "" {
+			row.Images = append(row.Images, properties.ImageURL)
+		}
+
+		if feature.Geometry != nil {
+			if feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) < 2 {
+				rowErrors = append(rowErrors, entities.ImportRowError{Line: line, Errors: []string{"geometry must be a Point"}})
+				continue
+			}
+			// в GeoJSON сначала долгота
+			lon, lat := feature.Geometry.Coordinates[0], feature.Geometry.Coordinates[1]
+			row.Latitude, row.Longitude = &lat, &lon
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
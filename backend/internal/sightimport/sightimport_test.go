@@ -0,0 +1,130 @@
+package sightimport
+
+import (
+	"strings"
+	"testing"
+
+	"homework_ipl/internal/entities"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	input := "\xef\xbb\xbfName,City,Country,Rating,Latitude,Longitude,Images,Description\n" +
+		"Кремль,Москва,Россия,4.9,55.752,37.6175,kremlin.jpg|kremlin2.jpg,\"Крепость, музей\"\n" +
+		"Эрмитаж,Санкт-Петербург,Россия,много,,,,\n" +
+		"Парк,Москва,Россия,4.5,,,,\n"
+
+	rows, rowErrors, err := Parse(strings.NewReader(input), FormatCSV)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	kremlin := rows[0]
+	assert.Equal(t, 2, kremlin.Line)
+	assert.Equal(t, "Кремль", kremlin.Name)
+	assert.Equal(t, "Крепость, музей", kremlin.Description)
+	assert.Equal(t, 4.9, kremlin.Rating)
+	require.NotNil(t, kremlin.Latitude)
+	assert.Equal(t, 55.752, *kremlin.Latitude)
+	assert.Equal(t, []string{"kremlin.jpg", "kremlin2.jpg"}, kremlin.Images)
+
+	assert.Nil(t, rows[1].Latitude)
+	assert.Equal(t, []entities.ImportRowError{{Line: 3, Errors: []string{"rating is not a number"}}}, rowErrors)
+}
+
+func TestParseCSVHeader(t *testing.T) {
+	_, _, err := Parse(strings.NewReader("name,city,price\n"), FormatCSV)
+	assert.True(t, errors.Is(err, ErrMalformedFile))
+
+	_, _, err = Parse(strings.NewReader("name,city\n"), FormatCSV)
+	assert.True(t, errors.Is(err, ErrMalformedFile), "country column is required")
+
+	_, _, err = Parse(strings.NewReader(""), "xlsx")
+	assert.Equal(t, ErrUnknownFormat, err)
+}
+
+func TestParseGeoJSON(t *testing.T) {
+	input := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [37.6175, 55.752]},
+		 "properties": {"name": "Кремль", "city": "Москва", "country": "Россия", "rating": 4.9, "image_url": "kremlin.jpg"}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}, "properties": {}},
+		{"type": "Feature", "geometry": null, "properties": {"name": "Парк", "rating": "high"}}
+	]}`
+
+	rows, rowErrors, err := Parse(strings.NewReader(input), FormatGeoJSON)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, 1, rows[0].Line)
+	assert.Equal(t, 55.752, *rows[0].Latitude, "coordinates are lon, lat")
+	assert.Equal(t, 37.6175, *rows[0].Longitude)
+	assert.Equal(t, []string{"kremlin.jpg"}, rows[0].Images)
+
+	require.Len(t, rowErrors, 2)
+	assert.Equal(t, 2, rowErrors[0].Line)
+	assert.Equal(t, 3, rowErrors[1].Line)
+
+	_, _, err = Parse(strings.NewReader(`{"type": "Feature"}`), FormatGeoJSON)
+	assert.True(t, errors.Is(err, ErrMalformedFile))
+}
+
+func TestValidate(t *testing.T) {
+	lat, lon := 95.0, 10.0
+	rows := []entities.ImportRow{
+		{Line: 2, Name: "Кремль", City: "Москва", Country: "Россия", Rating: 4.9},
+		{Line: 3, Name: "Кремль", City: "Москва", Country: "Россия", Rating: 4.9},
+		{Line: 4, Name: "", City: "Москва", Country: "Россия", Rating: 0},
+		{Line: 5, Name: "Полюс", City: "Нигде", Country: "Нигде", Rating: 3, Latitude: &lat, Longitude: &lon},
+		{Line: 6, Name: "Половина", City: "Москва", Country: "Россия", Rating: 3, Latitude: &lat},
+	}
+
+	rowErrors := Validate(rows)
+	require.Len(t, rowErrors, 4)
+	assert.Equal(t, []string{"duplicate of line 2"}, rowErrors[0].Errors)
+	assert.ElementsMatch(t, []string{"name is required", "rating must be greater than 0 and at most 5"}, rowErrors[1].Errors)
+	assert.Equal(t, []string{"latitude must be between -90 and 90"}, rowErrors[2].Errors)
+	assert.Contains(t, rowErrors[3].Errors, "latitude and longitude must be set together")
+}
+
+func TestDiff(t *testing.T) {
+	lat, lon := 55.752, 37.6175
+	// REAL в базе хранит координаты с точностью float32
+	storedLat, storedLon := float64(float32(lat)), float64(float32(lon))
+	existing := Existing{
+		Description: "Крепость",
+		Rating:      4.8,
+		Latitude:    &storedLat,
+		Longitude:   &storedLon,
+		Images:      []string{"kremlin.jpg"},
+	}
+	row := entities.ImportRow{
+		Description: "Крепость",
+		Rating:      4.8,
+		Latitude:    &lat,
+		Longitude:   &lon,
+		Images:      []string{"kremlin.jpg"},
+	}
+	assert.Empty(t, Diff(existing, row))
+
+	row.Rating = 4.9
+	row.Category = "museum"
+	row.Latitude, row.Longitude = nil, nil
+	row.Images = []string{"kremlin.jpg", "kremlin2.jpg", "kremlin2.jpg"}
+	assert.Equal(t, []entities.ImportChange{
+		{Field: "rating", Old: "4.8", New: "4.9"},
+		{Field: "category", Old: "", New: "museum"},
+		{Field: "latitude", Old: formatFloat(&storedLat), New: ""},
+		{Field: "longitude", Old: formatFloat(&storedLon), New: ""},
+		{Field: "images", Old: "", New: "kremlin2.jpg"},
+	}, Diff(existing, row))
+
+	// рейтинг из отзывов файл не перезаписывает и в изменения не попадает
+	existing.HasFeedback = true
+	row = entities.ImportRow{Description: "Крепость", Rating: 3, Latitude: &lat, Longitude: &lon}
+	assert.Empty(t, Diff(existing, row))
+	assert.Equal(t, 4.8, UpdatedRating(existing, row))
+
+	existing.HasFeedback = false
+	assert.Equal(t, 3.0, UpdatedRating(existing, row))
+}
@@ -0,0 +1,72 @@
+package sightimport
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"homework_ipl/internal/entities"
+)
+
+// Проверяет строки: обязательные поля, рейтинг как в CHECK таблицы sight,
+// координаты и повторы одной достопримечательности в файле
+func Validate(rows []entities.ImportRow) []entities.ImportRowError {
+	var rowErrors []entities.ImportRowError
+	seen := make(map[string]int)
+
+	for _, row := range rows {
+		var problems []string
+		if row.Name == "" {
+			problems = append(problems, "name is required")
+		}
+		if row.City == "" {
+			problems = append(problems, "city is required")
+		}
+		if row.Country == "" {
+			problems = append(problems, "country is required")
+		}
+		if !(row.Rating > 0 && row.Rating <= 5) {
+			problems = append(problems, "rating must be greater than 0 and at most 5")
+		}
+		if (row.Latitude == nil) != (row.Longitude == nil) {
+			problems = append(problems, "latitude and longitude must be set together")
+		}
+		if row.Latitude != nil && !(math.Abs(*row.Latitude) <= 90) {
+			problems = append(problems, "latitude must be between -90 and 90")
+		}
+		if row.Longitude != nil && !(math.Abs(*row.Longitude) <= 180) {
+			problems = append(problems, "longitude must be between -180 and 180")
+		}
+		for _, image := range row.Images {
+			if strings.ContainsAny(image, "\n\r") {
+				problems = append(problems, "image path must be a single line")
+				break
+			}
+		}
+
+		// в базе уникальна пара (name, city_id)
+		key := row.Name + "\x00" + row.City + "\x00" + row.Country
+		if first, ok := seen[key]; ok && row.Name != "" {
+			problems = append(problems, "duplicate of line "+strconv.Itoa(first))
+		} else {
+			seen[key] = row.Line
+		}
+
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, entities.ImportRowError{Line: row.Line, Errors: problems})
+		}
+	}
+
+	return rowErrors
+}
+
+// Ошибки разбора и проверки вместе, по порядку строк
+func MergeErrors(lists ...[]entities.ImportRowError) []entities.ImportRowError {
+	var merged []entities.ImportRowError
+	for _, list := range lists {
+		merged = append(merged, list...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Line < merged[j].Line })
+	return merged
+}
@@ -0,0 +1,22 @@
+// Конфиг для тестов. Пакет logger читает конфиг в init и без него завершает процесс,
+// поэтому тесты, которые его подтягивают, импортируют этот пакет:
+//
+//	import _ "homework_ipl/internal/testenv"
+//
+// Go инициализирует готовые пакеты в порядке путей импорта, а здесь нет зависимостей
+// кроме os и runtime, поэтому init ниже выполняется раньше init логгера
+package testenv
+
+import (
+	"os"
+	"runtime"
+)
+
+func init() {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return
+	}
+	dir := file[:len(file)-len("testenv.go")]
+	os.Setenv("CONFIG_PATH", dir+"config.yaml")
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/pkg/errors"
+)
+
+// Сколько удалённый аккаунт можно восстановить входом, потом данные удаляются насовсем
+var deletionGracePeriod = 30 * 24 * time.Hour
+
+var ErrAccountDeleted = errors.New("account has been deleted")
+
+// AccountStore - мягкое удаление и окончательная очистка аккаунтов (repository.UserRepo)
+type AccountStore interface {
+	// Помечает аккаунт удалённым, ok == false, если пользователя нет или он уже удалён
+	SoftDeleteUser(ctx context.Context, userID int, at time.Time) (bool, error)
+	// nil - аккаунт не удалён
+	GetDeletedAt(ctx context.Context, userID int) (*time.Time, error)
+	RestoreUser(ctx context.Context, userID int) error
+	// Аккаунты, удалённые раньше before
+	DeletedUsersBefore(ctx context.Context, before time.Time) ([]int, error)
+	// Выгрузки пользователя, записи удаляются каскадом вместе с ним, а архивы - в Purge
+	UserExports(ctx context.Context, userID int) ([]entities.DataExport, error)
+	// Удаляет личные данные, отзывы переходят анонимному пользователю.
+	// Возвращает путь к аватарке, чтобы удалить и файл
+	PurgeUser(ctx context.Context, userID int) (string, error)
+}
+
+func InitAccountDeletion(cfg config.AccountDeletion) {
+	if cfg.GracePeriod > 0 {
+		deletionGracePeriod = cfg.GracePeriod
+	}
+}
+
+// AccountDeletion - удаление аккаунта с периодом, в течение которого его можно восстановить
+type AccountDeletion struct {
+	store AccountStore
+	now   func() time.Time
+}
+
+func NewAccountDeletion(store AccountStore) *AccountDeletion {
+	return &AccountDeletion{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Мягкое удаление: аккаунт скрывается, все сессии и API-токены отзываются
+func (d *AccountDeletion) Delete(ctx context.Context, userID int) (bool, error) {
+	found, err := d.store.SoftDeleteUser(ctx, userID, d.now())
+	if err != nil || !found {
+		return found, err
+	}
+
+	if err = RevokeUserSessions(ctx, userID); err != nil {
+		return true, err
+	}
+
+	tokens, err := ListAPITokens(ctx, userID)
+	if err != nil {
+		return true, err
+	}
+	for _, token := range tokens {
+		if err = RevokeAPIToken(ctx, userID, token.ID); err != nil && err != ErrAPITokenNotFound {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// Вызывается после успешного входа: удалённый аккаунт восстанавливается,
+// если период ещё не истёк, иначе ErrAccountDeleted
+func (d *AccountDeletion) Restore(ctx context.Context, userID int) (bool, error) {
+	deletedAt, err := d.store.GetDeletedAt(ctx, userID)
+	if err != nil || deletedAt == nil {
+		return false, err
+	}
+	if d.now().After(deletedAt.Add(deletionGracePeriod)) {
+		return false, ErrAccountDeleted
+	}
+
+	if err = d.store.RestoreUser(ctx, userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Окончательное удаление сразу, без периода восстановления (из админки).
+// Архивы выгрузок удаляются до записей о них, иначе файлы с личными данными останутся без хозяина
+func (d *AccountDeletion) Purge(ctx context.Context, userID int) error {
+	exports, err := d.store.UserExports(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if err = removeExportFile(export); err != nil {
+			return err
+		}
+	}
+
+	avatar, err := d.store.PurgeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	removeAvatar(avatar)
+	return nil
+}
+
+// Удаляет аккаунты, у которых истёк период восстановления, возвращает их число
+func (d *AccountDeletion) PurgeExpired(ctx context.Context) (int, error) {
+	userIDs, err := d.store.DeletedUsersBefore(ctx, d.now().Add(-deletionGracePeriod))
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, userID := range userIDs {
+		if err = d.Purge(ctx, userID); err != nil {
+			logger.Logger().Error("Error while purging account", "userID", userID, "error", err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// Аватарка лежит в каталоге загрузок, в бд - "/public/avatars/<файл>"
+func removeAvatar(avatar string) {
+	if avatar == "" || avatarDir == "" {
+		return
+	}
+	path := filepath.Join(avatarDir, filepath.Base(avatar))
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Logger().Error("Error while removing avatar", "path", path, "error", err)
+	}
+}
+
+// У незавершённой выгрузки путь ещё не записан, но архив может уже лежать под её id
+func removeExportFile(export entities.DataExport) error {
+	path := export.FilePath
+	if path == "" {
+		path = filepath.Join(exportDir, export.ID+".zip")
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Logger().Error("Error while removing export file", "path", path, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Фоновая очистка аккаунтов с истекшим периодом восстановления, работает до отмены ctx
+func StartAccountPurger(ctx context.Context, store AccountStore, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	deletion := NewAccountDeletion(store)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, err := deletion.PurgeExpired(ctx)
+				if err != nil {
+					logger.Logger().Error("Error while purging deleted accounts", "error", err)
+				} else if purged > 0 {
+					logger.Logger().Info("Deleted accounts purged", "count", purged)
+				}
+			}
+		}
+	}()
+}
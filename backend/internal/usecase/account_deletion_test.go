@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAccountStore struct {
+	deletedAt map[int]*time.Time
+	avatars   map[int]string
+	exports   map[int][]entities.DataExport
+	// ответы на опросы по пользователям, как и отзывы переходят анонимному (anonymousUserID)
+	quizAnswers map[int]int
+	purged      []int
+}
+
+const anonymousUserID = 1
+
+func (s *fakeAccountStore) SoftDeleteUser(_ context.Context, userID int, at time.Time) (bool, error) {
+	deletedAt, ok := s.deletedAt[userID]
+	if !ok || deletedAt != nil {
+		return false, nil
+	}
+	s.deletedAt[userID] = &at
+	return true, nil
+}
+
+func (s *fakeAccountStore) GetDeletedAt(_ context.Context, userID int) (*time.Time, error) {
+	return s.deletedAt[userID], nil
+}
+
+func (s *fakeAccountStore) RestoreUser(_ context.Context, userID int) error {
+	s.deletedAt[userID] = nil
+	return nil
+}
+
+func (s *fakeAccountStore) DeletedUsersBefore(_ context.Context, before time.Time) ([]int, error) {
+	var userIDs []int
+	for userID, deletedAt := range s.deletedAt {
+		if deletedAt != nil && deletedAt.Before(before) {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+func (s *fakeAccountStore) UserExports(_ context.Context, userID int) ([]entities.DataExport, error) {
+	return s.exports[userID], nil
+}
+
+func (s *fakeAccountStore) PurgeUser(_ context.Context, userID int) (string, error) {
+	if s.quizAnswers != nil {
+		s.quizAnswers[anonymousUserID] += s.quizAnswers[userID]
+		delete(s.quizAnswers, userID)
+	}
+	delete(s.deletedAt, userID)
+	s.purged = append(s.purged, userID)
+	return s.avatars[userID], nil
+}
+
+func TestAccountDeletion(t *testing.T) {
+	uploads := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(uploads, "4_me.png"), []byte("avatar"), 0o600))
+	exports := t.TempDir()
+	readyExport := filepath.Join(exports, "ready.zip")
+	pendingExport := filepath.Join(exports, "pending.zip")
+	for _, path := range []string{readyExport, pendingExport} {
+		require.NoError(t, os.WriteFile(path, []byte("zip"), 0o600))
+	}
+
+	prevSessions, prevTokens, prevAvatars, prevExports, prevGrace := Sessions, APITokens, avatarDir, exportDir, deletionGracePeriod
+	Sessions, APITokens, avatarDir, exportDir, deletionGracePeriod = NewMemorySessionStore(), NewMemoryAPITokenStore(), uploads, exports, 30*24*time.Hour
+	defer func() {
+		Sessions, APITokens, avatarDir, exportDir, deletionGracePeriod = prevSessions, prevTokens, prevAvatars, prevExports, prevGrace
+	}()
+
+	ctx := context.Background()
+	store := &fakeAccountStore{
+		deletedAt: map[int]*time.Time{3: nil, 4: nil},
+		avatars:   map[int]string{4: "/public/avatars/4_me.png"},
+		exports: map[int][]entities.DataExport{4: {
+			{ID: "ready", Status: entities.ExportReady, FilePath: readyExport},
+			{ID: "pending", Status: entities.ExportPending},
+			{ID: "failed", Status: entities.ExportFailed},
+		}},
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	deletion := NewAccountDeletion(store)
+	deletion.now = func() time.Time { return now }
+
+	require.NoError(t, Sessions.Create(ctx, entities.Session{ID: "phone", UserID: 3, ExpiresAt: time.Now().Add(time.Hour)}))
+	_, _, err := CreateAPIToken(ctx, 3, entities.APITokenRequest{Name: "script", Scopes: []string{entities.ScopeRead}})
+	require.NoError(t, err)
+
+	found, err := deletion.Delete(ctx, 3)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	sessions, err := Sessions.ListByUser(ctx, 3)
+	require.NoError(t, err)
+	assert.Empty(t, sessions, "sessions are revoked")
+	tokens, err := ListAPITokens(ctx, 3)
+	require.NoError(t, err)
+	assert.Empty(t, tokens, "api tokens are revoked")
+
+	found, err = deletion.Delete(ctx, 3)
+	require.NoError(t, err)
+	assert.False(t, found, "already deleted")
+
+	// вход во время периода восстановления возвращает аккаунт
+	now = now.Add(29 * 24 * time.Hour)
+	restored, err := deletion.Restore(ctx, 3)
+	require.NoError(t, err)
+	assert.True(t, restored)
+	assert.Nil(t, store.deletedAt[3])
+
+	restored, err = deletion.Restore(ctx, 3)
+	require.NoError(t, err)
+	assert.False(t, restored, "active account is left as is")
+
+	// после периода восстановления вход не проходит, аккаунт удаляется насовсем
+	_, err = deletion.Delete(ctx, 4)
+	require.NoError(t, err)
+	now = now.Add(31 * 24 * time.Hour)
+
+	_, err = deletion.Restore(ctx, 4)
+	assert.Equal(t, ErrAccountDeleted, err)
+
+	purged, err := deletion.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, []int{4}, store.purged)
+	_, err = os.Stat(filepath.Join(uploads, "4_me.png"))
+	assert.True(t, os.IsNotExist(err), "avatar file is removed")
+	for _, path := range []string{readyExport, pendingExport} {
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err), "export archive %s is removed", path)
+	}
+
+	purged, err = deletion.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+}
+
+func TestPurgeUserWithQuizAnswers(t *testing.T) {
+	prevExports := exportDir
+	exportDir = t.TempDir()
+	defer func() { exportDir = prevExports }()
+
+	ctx := context.Background()
+	store := &fakeAccountStore{
+		deletedAt:   map[int]*time.Time{5: nil},
+		quizAnswers: map[int]int{anonymousUserID: 2, 5: 3},
+	}
+
+	require.NoError(t, NewAccountDeletion(store).Purge(ctx, 5))
+	assert.Equal(t, []int{5}, store.purged)
+	assert.Equal(t, map[int]int{anonymousUserID: 5}, store.quizAnswers, "answers are kept for statistics")
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/pkg/errors"
+)
+
+// Префикс помогает узнать токен в логах и сканерах утёкших секретов
+const apiTokenPrefix = "hipl_"
+
+var APITokens APITokenStore = NewMemoryAPITokenStore()
+
+var (
+	ErrAPITokenNotFound = errors.New("api token not found")
+	ErrAPITokenExpiry   = errors.New("token expiry must be in the future")
+)
+
+// APITokenStore - хранилище персональных токенов (repository.APITokenRepo)
+type APITokenStore interface {
+	// Заполняет ID и CreatedAt
+	Create(ctx context.Context, token entities.APIToken) (entities.APIToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (entities.APIToken, bool, error)
+	ListByUser(ctx context.Context, userID int) ([]entities.APIToken, error)
+	// ok == false, если у пользователя нет такого токена
+	Delete(ctx context.Context, userID, tokenID int) (bool, error)
+	Touch(ctx context.Context, tokenID int, lastUsed time.Time) error
+}
+
+// Выпускает токен, возвращает его (показывается один раз) и сохранённые данные
+func CreateAPIToken(ctx context.Context, userID int, request entities.APITokenRequest) (string, entities.APIToken, error) {
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return "", entities.APIToken{}, ErrAPITokenExpiry
+	}
+
+	secret, err := NewToken()
+	if err != nil {
+		return "", entities.APIToken{}, err
+	}
+	token := apiTokenPrefix + secret
+
+	created, err := APITokens.Create(ctx, entities.APIToken{
+		UserID:    userID,
+		Name:      request.Name,
+		Scopes:    request.Scopes,
+		TokenHash: HashToken(token),
+		ExpiresAt: request.ExpiresAt,
+	})
+	if err != nil {
+		return "", entities.APIToken{}, err
+	}
+	return token, created, nil
+}
+
+func ListAPITokens(ctx context.Context, userID int) ([]entities.APIToken, error) {
+	return APITokens.ListByUser(ctx, userID)
+}
+
+func RevokeAPIToken(ctx context.Context, userID, tokenID int) error {
+	found, err := APITokens.Delete(ctx, userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// Токен из заголовка Authorization: Bearer. hasBearer == true, если заголовок
+// есть - тогда неверный токен должен давать 401, а не анонимный запрос
+func AuthenticateBearer(r *http.Request) (token entities.APIToken, hasBearer bool, ok bool) {
+	header := r.Header.Get("Authorization")
+	scheme, value, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return entities.APIToken{}, false, false
+	}
+
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, apiTokenPrefix) {
+		return entities.APIToken{}, true, false
+	}
+
+	token, ok, err := APITokens.GetByHash(r.Context(), HashToken(value))
+	if err != nil || !ok {
+		return entities.APIToken{}, true, false
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+		return entities.APIToken{}, true, false
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= touchInterval {
+		if err = APITokens.Touch(r.Context(), token.ID, now); err != nil {
+			logger.Logger().Error("Error while touching api token", "error", err)
+		}
+	}
+
+	return token, true, true
+}
+
+// MemoryAPITokenStore хранит токены в памяти процесса (для тестов)
+type MemoryAPITokenStore struct {
+	mu     sync.Mutex
+	nextID int
+	tokens map[int]entities.APIToken
+}
+
+func NewMemoryAPITokenStore() *MemoryAPITokenStore {
+	return &MemoryAPITokenStore{
+		tokens: make(map[int]entities.APIToken),
+	}
+}
+
+func (s *MemoryAPITokenStore) Create(_ context.Context, token entities.APIToken) (entities.APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	token.ID = s.nextID
+	token.CreatedAt = time.Now()
+	s.tokens[token.ID] = token
+	return token, nil
+}
+
+func (s *MemoryAPITokenStore) GetByHash(_ context.Context, tokenHash string) (entities.APIToken, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, token := range s.tokens {
+		if token.TokenHash == tokenHash {
+			return token, true, nil
+		}
+	}
+	return entities.APIToken{}, false, nil
+}
+
+func (s *MemoryAPITokenStore) ListByUser(_ context.Context, userID int) ([]entities.APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tokens []entities.APIToken
+	for _, token := range s.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].ID > tokens[j].ID
+	})
+	return tokens, nil
+}
+
+func (s *MemoryAPITokenStore) Delete(_ context.Context, userID, tokenID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[tokenID]
+	if !ok || token.UserID != userID {
+		return false, nil
+	}
+	delete(s.tokens, tokenID)
+	return true, nil
+}
+
+func (s *MemoryAPITokenStore) Touch(_ context.Context, tokenID int, lastUsed time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token, ok := s.tokens[tokenID]; ok {
+		token.LastUsedAt = &lastUsed
+		s.tokens[tokenID] = token
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// Хранилище журнала аудита, в main заменяется на repository.AuditRepo
+var AuditLog AuditStore = NewMemoryAuditStore()
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditStore - журнал аудита (repository.AuditRepo)
+type AuditStore interface {
+	Create(ctx context.Context, event entities.AuditEvent) error
+	// События по фильтру, новые первыми
+	List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error)
+}
+
+// Записывает событие с IP и айди запроса (middleware.RequestID).
+// Ошибка записи только логируется: из-за журнала действие пользователя не должно падать
+func Audit(ctx context.Context, r *http.Request, actorID int, action, target string) {
+	event := entities.AuditEvent{
+		ActorID:   actorID,
+		Action:    action,
+		Target:    target,
+		IP:        ClientIP(r),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+
+	// запись не должна теряться, если клиент уже закрыл соединение
+	if err := AuditLog.Create(context.WithoutCancel(ctx), event); err != nil {
+		logger.Logger().Error("Error while writing audit event", "action", action, "error", err)
+	}
+}
+
+// Выборка для админки, лимит по умолчанию 100, не больше 1000
+func ListAuditEvents(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	return AuditLog.List(ctx, filter)
+}
+
+// MemoryAuditStore хранит журнал в памяти процесса (для тестов)
+type MemoryAuditStore struct {
+	mu     sync.Mutex
+	events []entities.AuditEvent
+}
+
+func NewMemoryAuditStore() *MemoryAuditStore {
+	return &MemoryAuditStore{}
+}
+
+func (s *MemoryAuditStore) Create(_ context.Context, event entities.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = len(s.events) + 1
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *MemoryAuditStore) List(_ context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []entities.AuditEvent
+	for _, e := range s.events {
+		if filter.UserID != 0 && e.ActorID != filter.UserID && e.Target != entities.AuditUser(filter.UserID) {
+			continue
+		}
+		if filter.Action != "" && e.Action != filter.Action {
+			continue
+		}
+		if !filter.From.IsZero() && e.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !e.CreatedAt.Before(filter.To) {
+			continue
+		}
+		result = append(result, e)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/entities"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	prev := AuditLog
+	store := NewMemoryAuditStore()
+	AuditLog = store
+	defer func() { AuditLog = prev }()
+
+	ctx := context.Background()
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = "10.0.0.1:5555"
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "host/req-000001"))
+
+	Audit(ctx, req, 0, entities.AuditLoginFailed, entities.AuditEmail("user@mail.ru"))
+	Audit(ctx, req, 5, entities.AuditLogin, entities.AuditUser(5))
+	Audit(ctx, req, 1, entities.AuditRoleChange, entities.AuditUser(5))
+	Audit(ctx, req, 7, entities.AuditCommentDelete, entities.AuditComment(3))
+
+	events, err := ListAuditEvents(ctx, entities.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Equal(t, entities.AuditCommentDelete, events[0].Action, "newest first")
+	assert.Equal(t, "10.0.0.1", events[3].IP)
+	assert.Equal(t, "host/req-000001", events[3].RequestID)
+	assert.Zero(t, events[3].ActorID)
+
+	// пользователь 5 - автор входа и цель смены роли
+	events, err = ListAuditEvents(ctx, entities.AuditFilter{UserID: 5})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, entities.AuditRoleChange, events[0].Action)
+	assert.Equal(t, entities.AuditLogin, events[1].Action)
+
+	events, err = ListAuditEvents(ctx, entities.AuditFilter{Action: entities.AuditLoginFailed})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "email:user@mail.ru", events[0].Target)
+
+	events, err = ListAuditEvents(ctx, entities.AuditFilter{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	events, err = ListAuditEvents(ctx, entities.AuditFilter{To: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	events, err = ListAuditEvents(ctx, entities.AuditFilter{From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Len(t, events, 4)
+}
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/securecookie"
+	"golang.org/x/crypto/hkdf"
+)
+
+const CSRFHeader = "X-CSRF-Token"
+
+// Ключ подписи CSRF-токенов, InitSessions выводит его из ключа куки
+var csrfKey = securecookie.GenerateRandomKey(32)
+
+// Отдельный ключ для CSRF из ключа подписи куки (HKDF с меткой "csrf"),
+// чтобы один ключ не использовался для двух разных подписей
+func deriveCSRFKey(hashKey []byte) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, hashKey, nil, []byte("csrf")), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// CSRF-токен привязан к текущей сессии (synchronizer token без хранения):
+// это HMAC от айди сессии, поэтому после ротации сессии старый токен не подходит
+func CSRFToken(r *http.Request) (string, bool) {
+	sessionID, ok := currentSessionID(r)
+	if !ok {
+		return "", false
+	}
+	return csrfTokenFor(sessionID), true
+}
+
+func ValidCSRFToken(r *http.Request, token string) bool {
+	expected, ok := CSRFToken(r)
+	if !ok || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(token))
+}
+
+func csrfTokenFor(sessionID string) string {
+	mac := hmac.New(sha256.New, csrfKey)
+	mac.Write([]byte("csrf:" + sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
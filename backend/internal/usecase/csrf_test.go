@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveCSRFKey(t *testing.T) {
+	hashKey := []byte("0123456789abcdef0123456789abcdef")
+
+	key, err := deriveCSRFKey(hashKey)
+	assert.NoError(t, err)
+	assert.Len(t, key, 32)
+	assert.NotEqual(t, hashKey, key, "csrf key differs from the cookie key")
+
+	again, err := deriveCSRFKey(hashKey)
+	assert.NoError(t, err)
+	assert.Equal(t, key, again, "all instances derive the same key")
+}
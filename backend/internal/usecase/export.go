@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
+	"github.com/pkg/errors"
+)
+
+// Настройки выгрузки, задаются в main через InitExport.
+// avatarDir - каталог загрузок, из него же удаляются аватарки удалённых аккаунтов
+var (
+	exportDir = "./exports"
+	exportTTL = 24 * time.Hour
+	avatarDir = ""
+)
+
+var (
+	ErrExportNotFound = errors.New("export not found")
+	ErrExportExpired  = errors.New("export has expired")
+	ErrExportNotReady = errors.New("export is not ready")
+)
+
+// ExportStore - задания на выгрузку и сами данные пользователя (repository.ExportRepo)
+type ExportStore interface {
+	CreateExport(ctx context.Context, export entities.DataExport) error
+	GetExport(ctx context.Context, id string) (entities.DataExport, bool, error)
+	// Незавершённая выгрузка пользователя, чтобы не собирать архив дважды
+	PendingExport(ctx context.Context, userID int) (entities.DataExport, bool, error)
+	// Сохраняет Status, FilePath и ExpiresAt
+	FinishExport(ctx context.Context, export entities.DataExport) error
+	ExpiredExports(ctx context.Context, now time.Time) ([]entities.DataExport, error)
+	DeleteExport(ctx context.Context, id string) error
+	// Всё, что хранится о пользователе, кроме журнала аудита
+	GetUserExportData(ctx context.Context, userID int) (entities.UserExportData, error)
+}
+
+// uploadPath - каталог с аватарками (config.FileUploadPath)
+func InitExport(cfg config.Export, uploadPath string) {
+	if cfg.Dir != "" {
+		exportDir = cfg.Dir
+	}
+	if cfg.TTL > 0 {
+		exportTTL = cfg.TTL
+	}
+	avatarDir = uploadPath
+}
+
+// Ставит выгрузку в очередь и сразу возвращает задание, архив собирается в фоне.
+// Если выгрузка уже собирается, возвращает её
+func StartExport(ctx context.Context, store ExportStore, userID int) (entities.DataExport, error) {
+	pending, ok, err := store.PendingExport(ctx, userID)
+	if err != nil {
+		return entities.DataExport{}, err
+	}
+	if ok {
+		return pending, nil
+	}
+
+	id, err := NewToken()
+	if err != nil {
+		return entities.DataExport{}, err
+	}
+
+	export := entities.DataExport{
+		ID:        id,
+		UserID:    userID,
+		Status:    entities.ExportPending,
+		CreatedAt: time.Now(),
+	}
+	if err = store.CreateExport(ctx, export); err != nil {
+		return entities.DataExport{}, err
+	}
+
+	go buildExport(context.WithoutCancel(ctx), store, export)
+
+	return export, nil
+}
+
+func buildExport(ctx context.Context, store ExportStore, export entities.DataExport) {
+	path, err := writeExport(ctx, store, export)
+	if err != nil {
+		logger.Logger().Error("Error while building data export", "userID", export.UserID, "error", err)
+		export.Status = entities.ExportFailed
+		if path != "" {
+			os.Remove(path)
+		}
+	} else {
+		expiresAt := time.Now().Add(exportTTL)
+		export.Status = entities.ExportReady
+		export.FilePath = path
+		export.ExpiresAt = &expiresAt
+	}
+
+	if err = store.FinishExport(ctx, export); err != nil {
+		logger.Logger().Error("Error while saving data export", "userID", export.UserID, "error", err)
+	}
+}
+
+func writeExport(ctx context.Context, store ExportStore, export entities.DataExport) (string, error) {
+	data, err := store.GetUserExportData(ctx, export.UserID)
+	if err != nil {
+		return "", err
+	}
+	// весь журнал, без лимита админки
+	data.Audit, err = AuditLog.List(ctx, entities.AuditFilter{UserID: export.UserID})
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(exportDir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(exportDir, export.ID+".zip")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	err = WriteExportArchive(file, data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return path, err
+}
+
+// Архив: по json-файлу на раздел и аватарка в media/
+func WriteExportArchive(w io.Writer, data entities.UserExportData) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"account.json", data.Account},
+		{"profile.json", data.Profile},
+		{"feedback.json", data.Feedback},
+		{"journeys.json", data.Journeys},
+		{"identities.json", data.Identities},
+		{"api_tokens.json", data.APITokens},
+		{"audit.json", data.Audit},
+	}
+	for _, f := range files {
+		if err := writeJSONFile(archive, f.name, f.value); err != nil {
+			return err
+		}
+	}
+
+	if err := writeAvatar(archive, data.Profile.Avatar); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func writeJSONFile(archive *zip.Writer, name string, value interface{}) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// Аватарка хранится как "/public/avatars/<файл>", сам файл лежит в avatarDir.
+// Берётся только имя файла, чтобы путь из бд не вывел за пределы каталога
+func writeAvatar(archive *zip.Writer, avatar string) error {
+	if avatar == "" || avatarDir == "" {
+		return nil
+	}
+	name := filepath.Base(avatar)
+
+	file, err := os.Open(filepath.Join(avatarDir, name))
+	if os.IsNotExist(err) {
+		logger.Logger().Info("Avatar file is missing, skipping", "avatar", avatar)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w, err := archive.Create("media/" + name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// Выгрузка пользователя по айди: чужая не находится, истекшая - ErrExportExpired
+func GetExport(ctx context.Context, store ExportStore, userID int, id string) (entities.DataExport, error) {
+	export, ok, err := store.GetExport(ctx, id)
+	if err != nil {
+		return entities.DataExport{}, err
+	}
+	if !ok || export.UserID != userID {
+		return entities.DataExport{}, ErrExportNotFound
+	}
+	if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+		return entities.DataExport{}, ErrExportExpired
+	}
+
+	if export.Status == entities.ExportReady {
+		export.DownloadURL = "/profile/" + strconv.Itoa(userID) + "/export/" + export.ID + "/download"
+	}
+	return export, nil
+}
+
+// Открывает готовый архив для скачивания
+func OpenExport(ctx context.Context, store ExportStore, userID int, id string) (*os.File, entities.DataExport, error) {
+	export, err := GetExport(ctx, store, userID, id)
+	if err != nil {
+		return nil, entities.DataExport{}, err
+	}
+	if export.Status != entities.ExportReady {
+		return nil, entities.DataExport{}, ErrExportNotReady
+	}
+
+	file, err := os.Open(export.FilePath)
+	if os.IsNotExist(err) {
+		return nil, entities.DataExport{}, ErrExportExpired
+	}
+	if err != nil {
+		return nil, entities.DataExport{}, err
+	}
+	return file, export, nil
+}
+
+// Фоновое удаление истекших архивов, работает до отмены ctx
+func StartExportSweeper(ctx context.Context, store ExportStore, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				SweepExports(ctx, store)
+			}
+		}
+	}()
+}
+
+func SweepExports(ctx context.Context, store ExportStore) {
+	expired, err := store.ExpiredExports(ctx, time.Now())
+	if err != nil {
+		logger.Logger().Error("Error while listing expired exports", "error", err)
+		return
+	}
+
+	for _, export := range expired {
+		if export.FilePath != "" {
+			if err = os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				logger.Logger().Error("Error while removing export file", "path", export.FilePath, "error", err)
+				continue
+			}
+		}
+		if err = store.DeleteExport(ctx, export.ID); err != nil {
+			logger.Logger().Error("Error while deleting export", "id", export.ID, "error", err)
+		}
+	}
+}
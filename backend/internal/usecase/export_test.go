@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeExportStore struct {
+	mu      sync.Mutex
+	exports map[string]entities.DataExport
+	data    entities.UserExportData
+}
+
+func (s *fakeExportStore) CreateExport(_ context.Context, export entities.DataExport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exports[export.ID] = export
+	return nil
+}
+
+func (s *fakeExportStore) GetExport(_ context.Context, id string) (entities.DataExport, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	export, ok := s.exports[id]
+	return export, ok, nil
+}
+
+func (s *fakeExportStore) PendingExport(_ context.Context, userID int) (entities.DataExport, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, export := range s.exports {
+		if export.UserID == userID && export.Status == entities.ExportPending {
+			return export, true, nil
+		}
+	}
+	return entities.DataExport{}, false, nil
+}
+
+func (s *fakeExportStore) FinishExport(_ context.Context, export entities.DataExport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exports[export.ID] = export
+	return nil
+}
+
+func (s *fakeExportStore) ExpiredExports(_ context.Context, now time.Time) ([]entities.DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []entities.DataExport
+	for _, export := range s.exports {
+		if export.ExpiresAt != nil && export.ExpiresAt.Before(now) {
+			expired = append(expired, export)
+		}
+	}
+	return expired, nil
+}
+
+func (s *fakeExportStore) DeleteExport(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.exports, id)
+	return nil
+}
+
+func (s *fakeExportStore) GetUserExportData(_ context.Context, userID int) (entities.UserExportData, error) {
+	return s.data, nil
+}
+
+func readZip(t *testing.T, path string) map[string][]byte {
+	archive, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer archive.Close()
+
+	files := make(map[string][]byte)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+	}
+	return files
+}
+
+func TestExport(t *testing.T) {
+	uploads := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(uploads, "7_me.png"), []byte("\x89PNG avatar"), 0o600))
+
+	prevDir, prevTTL, prevAvatars, prevAudit := exportDir, exportTTL, avatarDir, AuditLog
+	defer func() { exportDir, exportTTL, avatarDir, AuditLog = prevDir, prevTTL, prevAvatars, prevAudit }()
+	InitExport(config.Export{Dir: t.TempDir(), TTL: time.Hour}, uploads)
+	AuditLog = NewMemoryAuditStore()
+
+	ctx := context.Background()
+	require.NoError(t, AuditLog.Create(ctx, entities.AuditEvent{ActorID: 7, Action: entities.AuditLogin, Target: entities.AuditUser(7)}))
+	require.NoError(t, AuditLog.Create(ctx, entities.AuditEvent{ActorID: 8, Action: entities.AuditLogin, Target: entities.AuditUser(8)}))
+
+	store := &fakeExportStore{
+		exports: make(map[string]entities.DataExport),
+		data: entities.UserExportData{
+			Account:  entities.ExportAccount{ID: 7, Email: "user@mail.ru", Role: entities.RoleUser},
+			Profile:  entities.UserProfile{UserID: 7, Username: "user", Avatar: "/public/avatars/7_me.png"},
+			Feedback: []entities.Comment{{ID: 1, UserID: 7, SightID: 3, Rating: 5, Feedback: "Отлично"}},
+			Journeys: []entities.ExportJourney{{
+				Journey: entities.Journey{ID: 2, UserID: 7, Name: "Казань"},
+				Sights:  []entities.JourneySight{{JourneyID: 2, SightID: 3, Priority: 1}, {JourneyID: 2, SightID: 5, Priority: 2}},
+			}},
+		},
+	}
+
+	export, err := StartExport(ctx, store, 7)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ExportPending, export.Status)
+
+	require.Eventually(t, func() bool {
+		e, err := GetExport(ctx, store, 7, export.ID)
+		return err == nil && e.Status == entities.ExportReady
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ready, err := GetExport(ctx, store, 7, export.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "/profile/7/export/"+export.ID+"/download", ready.DownloadURL)
+
+	_, err = GetExport(ctx, store, 8, export.ID)
+	assert.Equal(t, ErrExportNotFound, err, "other users cannot see the export")
+
+	file, _, err := OpenExport(ctx, store, 7, export.ID)
+	require.NoError(t, err)
+	file.Close()
+
+	files := readZip(t, file.Name())
+	assert.Equal(t, []byte("\x89PNG avatar"), files["media/7_me.png"])
+	for _, name := range []string{"account.json", "profile.json", "feedback.json", "journeys.json", "identities.json", "api_tokens.json", "audit.json"} {
+		assert.Contains(t, files, name)
+	}
+
+	var journeys []entities.ExportJourney
+	require.NoError(t, json.Unmarshal(files["journeys.json"], &journeys))
+	require.Len(t, journeys, 1)
+	assert.Len(t, journeys[0].Sights, 2)
+
+	var audit []entities.AuditEvent
+	require.NoError(t, json.Unmarshal(files["audit.json"], &audit))
+	require.Len(t, audit, 1, "only the user's own audit trail")
+	assert.Equal(t, 7, audit[0].ActorID)
+
+	// ссылка истекла: архив удаляется при очистке
+	expired := time.Now().Add(-time.Minute)
+	ready.ExpiresAt = &expired
+	require.NoError(t, store.FinishExport(ctx, ready))
+	_, err = GetExport(ctx, store, 7, export.ID)
+	assert.Equal(t, ErrExportExpired, err)
+
+	SweepExports(ctx, store)
+	_, err = os.Stat(ready.FilePath)
+	assert.True(t, os.IsNotExist(err))
+	_, err = GetExport(ctx, store, 7, export.ID)
+	assert.Equal(t, ErrExportNotFound, err)
+}
@@ -0,0 +1,236 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+)
+
+// LoginAttemptStore - хранилище счётчиков неудачных входов
+// (repository.LoginAttemptRepo в проде, MemoryLoginAttemptStore локально и в тестах)
+type LoginAttemptStore interface {
+	// Для неизвестного ключа возвращает пустой счётчик без ошибки
+	Get(ctx context.Context, key string) (entities.LoginAttempt, error)
+	// Атомарно учитывает ошибку и возвращает новый счётчик, чтобы параллельные
+	// попытки не затирали друг друга. Если прошлая ошибка была раньше
+	// resetBefore, счётчик начинается заново
+	AddFailure(ctx context.Context, key string, at, resetBefore time.Time) (entities.LoginAttempt, error)
+	// Блокирует ключ до lockedUntil, более позднюю блокировку не сокращает
+	Lock(ctx context.Context, key string, lockedUntil time.Time) error
+	Delete(ctx context.Context, key string) error
+	// Удаляет счётчики без ошибок после failedBefore и без действующей блокировки
+	DeleteStale(ctx context.Context, failedBefore, now time.Time) (int64, error)
+}
+
+// LoginLimiter считает неудачные входы по email и по IP. После limit ошибок
+// ключ блокируется, и каждая следующая ошибка удваивает время блокировки
+type LoginLimiter struct {
+	store         LoginAttemptStore
+	emailAttempts int
+	ipAttempts    int
+	baseLockout   time.Duration
+	maxLockout    time.Duration
+	resetAfter    time.Duration
+	now           func() time.Time
+}
+
+var Logins = NewLoginLimiter(NewMemoryLoginAttemptStore(), config.LoginLimit{
+	EmailAttempts: 5,
+	IPAttempts:    20,
+	BaseLockout:   30 * time.Second,
+	MaxLockout:    15 * time.Minute,
+	ResetAfter:    time.Hour,
+})
+
+func NewLoginLimiter(store LoginAttemptStore, cfg config.LoginLimit) *LoginLimiter {
+	return &LoginLimiter{
+		store:         store,
+		emailAttempts: cfg.EmailAttempts,
+		ipAttempts:    cfg.IPAttempts,
+		baseLockout:   cfg.BaseLockout,
+		maxLockout:    cfg.MaxLockout,
+		resetAfter:    cfg.ResetAfter,
+		now:           time.Now,
+	}
+}
+
+// Сколько ещё ждать до следующей попытки (0 - можно пробовать)
+func (l *LoginLimiter) RetryAfter(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := l.now()
+
+	var wait time.Duration
+	for _, key := range l.keys(email, ip) {
+		attempt, err := l.store.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if left := attempt.LockedUntil.Sub(now); left > wait {
+			wait = left
+		}
+	}
+	return wait, nil
+}
+
+// Учитывает неудачный вход, возвращает время блокировки (0 - ещё не заблокирован)
+func (l *LoginLimiter) RegisterFailure(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := l.now()
+	limits := []int{l.emailAttempts, l.ipAttempts}
+
+	var wait time.Duration
+	for i, key := range l.keys(email, ip) {
+		attempt, err := l.store.AddFailure(ctx, key, now, now.Add(-l.resetAfter))
+		if err != nil {
+			return 0, err
+		}
+
+		lockout := l.lockout(attempt.Failures, limits[i])
+		if lockout <= 0 {
+			continue
+		}
+		if err = l.store.Lock(ctx, key, now.Add(lockout)); err != nil {
+			return 0, err
+		}
+		if lockout > wait {
+			wait = lockout
+		}
+	}
+	return wait, nil
+}
+
+// Успешный вход сбрасывает счётчик по email. Счётчик по IP не сбрасывается,
+// иначе перебор можно было бы обнулять входом в собственный аккаунт
+func (l *LoginLimiter) RegisterSuccess(ctx context.Context, email string) error {
+	return l.store.Delete(ctx, emailKey(email))
+}
+
+// Фоновая очистка старых счётчиков, работает до отмены ctx
+func StartLoginAttemptSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				Logins.Sweep(ctx)
+			}
+		}
+	}()
+}
+
+// Удаляет счётчики, которые всё равно обнулились бы при следующей ошибке
+func (l *LoginLimiter) Sweep(ctx context.Context) {
+	now := l.now()
+	deleted, err := l.store.DeleteStale(ctx, now.Add(-l.resetAfter), now)
+	if err != nil {
+		logger.Logger().Error("Error while sweeping login attempts", "error", err)
+		return
+	}
+	if deleted > 0 {
+		logger.Logger().Info("Stale login attempts removed", "count", deleted)
+	}
+}
+
+func (l *LoginLimiter) lockout(failures, limit int) time.Duration {
+	if limit <= 0 || failures < limit {
+		return 0
+	}
+
+	lockout := l.baseLockout
+	for i := limit; i < failures && lockout < l.maxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > l.maxLockout {
+		lockout = l.maxLockout
+	}
+	return lockout
+}
+
+func (l *LoginLimiter) keys(email, ip string) []string {
+	return []string{emailKey(email), "ip:" + ip}
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// MemoryLoginAttemptStore хранит счётчики в памяти процесса
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]entities.LoginAttempt
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		attempts: make(map[string]entities.LoginAttempt),
+	}
+}
+
+func (s *MemoryLoginAttemptStore) Get(_ context.Context, key string) (entities.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return entities.LoginAttempt{Key: key}, nil
+	}
+	return attempt, nil
+}
+
+func (s *MemoryLoginAttemptStore) AddFailure(_ context.Context, key string, at, resetBefore time.Time) (entities.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok || attempt.LastFailure.Before(resetBefore) {
+		attempt = entities.LoginAttempt{Key: key, LockedUntil: attempt.LockedUntil}
+	}
+	attempt.Failures++
+	attempt.LastFailure = at
+	s.attempts[key] = attempt
+	return attempt, nil
+}
+
+func (s *MemoryLoginAttemptStore) Lock(_ context.Context, key string, lockedUntil time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok && lockedUntil.After(attempt.LockedUntil) {
+		attempt.LockedUntil = lockedUntil
+		s.attempts[key] = attempt
+	}
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) DeleteStale(_ context.Context, failedBefore, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, attempt := range s.attempts {
+		if attempt.LastFailure.Before(failedBefore) && !attempt.LockedUntil.After(now) {
+			delete(s.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *MemoryLoginAttemptStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginLimiterBackoff(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	limiter := NewLoginLimiter(NewMemoryLoginAttemptStore(), config.LoginLimit{
+		EmailAttempts: 3,
+		IPAttempts:    100,
+		BaseLockout:   time.Second,
+		MaxLockout:    5 * time.Second,
+		ResetAfter:    time.Hour,
+	})
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		lockout, err := limiter.RegisterFailure(ctx, "User@Mail.ru", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.Zero(t, lockout)
+	}
+
+	// третья ошибка блокирует, дальше блокировка удваивается до максимума
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		lockout, err := limiter.RegisterFailure(ctx, "user@mail.ru", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, lockout)
+
+		wait, err := limiter.RetryAfter(ctx, "user@mail.ru", "10.0.0.2")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, wait)
+	}
+
+	now = now.Add(5 * time.Second)
+	wait, err := limiter.RetryAfter(ctx, "user@mail.ru", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+
+	assert.NoError(t, limiter.RegisterSuccess(ctx, "user@mail.ru"))
+	lockout, err := limiter.RegisterFailure(ctx, "user@mail.ru", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, lockout)
+}
+
+func TestLoginLimiterPerIP(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLoginLimiter(NewMemoryLoginAttemptStore(), config.LoginLimit{
+		EmailAttempts: 100,
+		IPAttempts:    2,
+		BaseLockout:   time.Minute,
+		MaxLockout:    time.Hour,
+		ResetAfter:    time.Hour,
+	})
+
+	_, _ = limiter.RegisterFailure(ctx, "a@mail.ru", "10.0.0.1")
+	lockout, err := limiter.RegisterFailure(ctx, "b@mail.ru", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, lockout)
+
+	wait, err := limiter.RetryAfter(ctx, "c@mail.ru", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.True(t, wait > 0)
+
+	wait, err = limiter.RetryAfter(ctx, "c@mail.ru", "10.0.0.2")
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+}
+
+func TestLoginLimiterConcurrentFailures(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLoginAttemptStore()
+	limiter := NewLoginLimiter(store, config.LoginLimit{
+		EmailAttempts: 1000,
+		IPAttempts:    1000,
+		BaseLockout:   time.Second,
+		MaxLockout:    time.Minute,
+		ResetAfter:    time.Hour,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := limiter.RegisterFailure(ctx, "user@mail.ru", "10.0.0.1")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// параллельные попытки не теряются
+	attempt, err := store.Get(ctx, emailKey("user@mail.ru"))
+	assert.NoError(t, err)
+	assert.Equal(t, 50, attempt.Failures)
+}
+
+func TestLoginLimiterSweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryLoginAttemptStore()
+	limiter := NewLoginLimiter(store, config.LoginLimit{
+		EmailAttempts: 1,
+		IPAttempts:    100,
+		BaseLockout:   2 * time.Hour,
+		MaxLockout:    2 * time.Hour,
+		ResetAfter:    time.Hour,
+	})
+	limiter.now = func() time.Time { return now }
+
+	_, err := limiter.RegisterFailure(ctx, "locked@mail.ru", "10.0.0.1")
+	assert.NoError(t, err)
+	now = now.Add(90 * time.Minute)
+	_, err = limiter.RegisterFailure(ctx, "fresh@mail.ru", "10.0.0.2")
+	assert.NoError(t, err)
+
+	// старый счётчик по IP удаляется, действующая блокировка и свежие ошибки остаются
+	limiter.Sweep(ctx)
+	assert.Len(t, store.attempts, 3)
+	_, ok := store.attempts["ip:10.0.0.1"]
+	assert.False(t, ok)
+
+	now = now.Add(2 * time.Hour)
+	limiter.Sweep(ctx)
+	assert.Empty(t, store.attempts)
+}
@@ -0,0 +1,293 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/password"
+	"homework_ipl/utils/logger"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+// Настроенные провайдеры по имени из адреса /oauth/{provider}/...
+var OIDCProviders = map[string]*OIDCProvider{}
+
+var oidcSuccessURL = "/"
+
+var (
+	ErrOIDCProviderNotFound = errors.New("unknown login provider")
+	ErrOIDCState            = errors.New("invalid or expired login state")
+	ErrOIDCEmailNotVerified = errors.New("provider did not confirm the email")
+	// Локальный аккаунт с этим email не подтверждён: его мог зарегистрировать
+	// кто угодно, поэтому провайдер привязывается только после входа по паролю
+	ErrOIDCAccountNotVerified = errors.New("account with this email is not verified, log in with password to link the provider")
+)
+
+// OIDCStore - связи аккаунтов провайдеров с пользователями (repository.IdentityRepo)
+type OIDCStore interface {
+	// Пользователь, привязанный к аккаунту провайдера
+	GetUserByIdentity(ctx context.Context, provider, subject string) (entities.User, bool, error)
+	FindUserByEmail(ctx context.Context, email string) (entities.User, bool, error)
+	LinkIdentity(ctx context.Context, userID int, identity entities.OIDCIdentity) error
+	// Новый пользователь с подтверждённой почтой и привязанным аккаунтом
+	CreateOIDCUser(ctx context.Context, identity entities.OIDCIdentity, passwordHash string) (entities.User, error)
+}
+
+// OIDCProvider - authorization code flow с PKCE для одного провайдера
+type OIDCProvider struct {
+	name     string
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// Данные между редиректом на провайдера и возвратом с него, хранятся в подписанной куке
+type oidcState struct {
+	Provider     string
+	State        string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    int64
+}
+
+// Загружает discovery-документ каждого провайдера. Провайдеры с ошибкой
+// пропускаются, чтобы недоступность одного не мешала запуску сервиса
+func InitOIDC(ctx context.Context, cfg config.OIDC) {
+	if cfg.SuccessURL != "" {
+		oidcSuccessURL = cfg.SuccessURL
+	}
+
+	providers := make(map[string]*OIDCProvider)
+	for name, providerCfg := range cfg.Providers {
+		redirectURL := strings.TrimRight(cfg.RedirectBaseURL, "/") + "/oauth/" + name + "/callback"
+		provider, err := NewOIDCProvider(ctx, name, providerCfg, redirectURL)
+		if err != nil {
+			logger.Logger().Error("Failed to init login provider", "provider", name, "error", err)
+			continue
+		}
+		providers[name] = provider
+	}
+	OIDCProviders = providers
+}
+
+func NewOIDCProvider(ctx context.Context, name string, cfg config.OIDCProvider, redirectURL string) (*OIDCProvider, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, errors.Wrap(err, "oidc discovery")
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &OIDCProvider{
+		name: name,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+// Куда вернуть пользователя после входа. Если нужна 2FA, фронт получает challenge
+// в параметре two_factor_challenge и завершает вход через /login/2fa
+func OIDCSuccessURL(twoFactorChallenge string) string {
+	if twoFactorChallenge == "" {
+		return oidcSuccessURL
+	}
+
+	target, err := url.Parse(oidcSuccessURL)
+	if err != nil {
+		return oidcSuccessURL
+	}
+	query := target.Query()
+	query.Set("two_factor_challenge", twoFactorChallenge)
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+func GetOIDCProvider(name string) (*OIDCProvider, error) {
+	provider, ok := OIDCProviders[name]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+	return provider, nil
+}
+
+// Имена настроенных провайдеров для кнопок входа на фронте
+func OIDCProviderNames() []string {
+	names := make([]string, 0, len(OIDCProviders))
+	for name := range OIDCProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Начало входа: ставит куку с state, nonce и PKCE verifier и возвращает адрес провайдера
+func (p *OIDCProvider) StartLogin(w http.ResponseWriter) (string, error) {
+	state, err := NewToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := NewToken()
+	if err != nil {
+		return "", err
+	}
+	codeVerifier := oauth2.GenerateVerifier()
+
+	encoded, err := CookieHandler.Encode(oidcStateCookie, oidcState{
+		Provider:     p.name,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(oidcStateTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// SameSite=Lax обязателен: провайдер возвращает пользователя обычным переходом по ссылке
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    encoded,
+		Path:     "/oauth/" + p.name,
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		Secure:   cookieSecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return p.oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier), oidc.Nonce(nonce)), nil
+}
+
+// Возврат с провайдера: проверяет state, обменивает code на токены
+// и проверяет подпись, аудиторию и nonce id_token
+func (p *OIDCProvider) FinishLogin(w http.ResponseWriter, r *http.Request) (entities.OIDCIdentity, error) {
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		return entities.OIDCIdentity{}, ErrOIDCState
+	}
+	// кука одноразовая
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/oauth/" + p.name, MaxAge: -1})
+
+	var state oidcState
+	if err = CookieHandler.Decode(oidcStateCookie, cookie.Value, &state); err != nil {
+		return entities.OIDCIdentity{}, ErrOIDCState
+	}
+	if state.Provider != p.name || time.Now().Unix() >= state.ExpiresAt || state.State != r.URL.Query().Get("state") {
+		return entities.OIDCIdentity{}, ErrOIDCState
+	}
+
+	if providerErr := r.URL.Query().Get("error"); providerErr != "" {
+		return entities.OIDCIdentity{}, errors.Errorf("provider returned error: %s", providerErr)
+	}
+
+	token, err := p.oauth.Exchange(r.Context(), r.URL.Query().Get("code"), oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		return entities.OIDCIdentity{}, errors.Wrap(err, "code exchange")
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return entities.OIDCIdentity{}, errors.New("no id_token in token response")
+	}
+	idToken, err := p.verifier.Verify(r.Context(), rawIDToken)
+	if err != nil {
+		return entities.OIDCIdentity{}, errors.Wrap(err, "id_token verification")
+	}
+	if idToken.Nonce != state.Nonce {
+		return entities.OIDCIdentity{}, errors.New("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err = idToken.Claims(&claims); err != nil {
+		return entities.OIDCIdentity{}, errors.Wrap(err, "id_token claims")
+	}
+
+	return entities.OIDCIdentity{
+		Provider:      p.name,
+		Subject:       idToken.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: claims.EmailVerified,
+	}, nil
+}
+
+// Пользователь для аккаунта провайдера:
+//   - уже привязанный аккаунт - его владелец;
+//   - вход выполнен (currentUserID != 0) - аккаунт привязывается к текущему пользователю;
+//   - иначе по подтверждённому провайдером email находится или создаётся пользователь.
+//
+// Без подтверждённого email привязка запрещена, иначе можно было бы
+// войти в чужой аккаунт, указав у провайдера его адрес. Локальный аккаунт тоже
+// должен быть подтверждён: иначе злоумышленник заранее регистрирует чужой адрес
+// и получает доступ, когда владелец адреса впервые войдёт через провайдера
+func LoginWithOIDC(ctx context.Context, store OIDCStore, identity entities.OIDCIdentity, currentUserID int) (entities.User, error) {
+	user, found, err := store.GetUserByIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return entities.User{}, err
+	}
+	if found {
+		return user, nil
+	}
+
+	if currentUserID != 0 {
+		if err = store.LinkIdentity(ctx, currentUserID, identity); err != nil {
+			return entities.User{}, err
+		}
+		user, _, err = store.GetUserByIdentity(ctx, identity.Provider, identity.Subject)
+		return user, err
+	}
+
+	// служебный email не привязывается к анонимному пользователю и не занимается новым
+	if identity.Email == "" || !identity.EmailVerified || entities.IsReservedEmail(identity.Email) {
+		return entities.User{}, ErrOIDCEmailNotVerified
+	}
+
+	user, found, err = store.FindUserByEmail(ctx, identity.Email)
+	if err != nil {
+		return entities.User{}, err
+	}
+	if found {
+		if !user.EmailVerified {
+			return entities.User{}, ErrOIDCAccountNotVerified
+		}
+		if err = store.LinkIdentity(ctx, user.ID, identity); err != nil {
+			return entities.User{}, err
+		}
+		return user, nil
+	}
+
+	// случайный пароль, которого никто не знает: войти по паролю можно только после сброса
+	secret, err := NewToken()
+	if err != nil {
+		return entities.User{}, err
+	}
+	hashedPassword, err := password.Hash(secret)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	return store.CreateOIDCUser(ctx, identity, hashedPassword)
+}
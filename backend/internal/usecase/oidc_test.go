@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOIDCServer - минимальный OIDC-провайдер: discovery, JWKS, authorize и token с проверкой PKCE
+type mockOIDCServer struct {
+	*httptest.Server
+	t      *testing.T
+	key    *rsa.PrivateKey
+	mu     sync.Mutex
+	codes  map[string]mockAuthRequest
+	claims map[string]interface{}
+}
+
+type mockAuthRequest struct {
+	clientID  string
+	challenge string
+	nonce     string
+}
+
+func newMockOIDCServer(t *testing.T, claims map[string]interface{}) *mockOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockOIDCServer{t: t, key: key, codes: map[string]mockAuthRequest{}, claims: claims}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/keys", m.keys)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+func (m *mockOIDCServer) discovery(w http.ResponseWriter, _ *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                m.URL,
+		"authorization_endpoint":                m.URL + "/authorize",
+		"token_endpoint":                        m.URL + "/token",
+		"jwks_uri":                              m.URL + "/keys",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (m *mockOIDCServer) keys(w http.ResponseWriter, _ *http.Request) {
+	json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &m.key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+	}})
+}
+
+// Пользователь сразу "соглашается": провайдер редиректит обратно с кодом
+func (m *mockOIDCServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	assert.Equal(m.t, "code", query.Get("response_type"))
+	assert.Equal(m.t, "S256", query.Get("code_challenge_method"))
+
+	code := "code-" + query.Get("state")
+	m.mu.Lock()
+	m.codes[code] = mockAuthRequest{
+		clientID:  query.Get("client_id"),
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+	}
+	m.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	back := redirect.Query()
+	back.Set("code", code)
+	back.Set("state", query.Get("state"))
+	redirect.RawQuery = back.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (m *mockOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	require.NoError(m.t, r.ParseForm())
+
+	m.mu.Lock()
+	request, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != request.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := map[string]interface{}{
+		"iss":   m.URL,
+		"aud":   request.clientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": request.nonce,
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: m.key, KeyID: "test"}}, nil)
+	require.NoError(m.t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(m.t, err)
+	signed, err := signer.Sign(payload)
+	require.NoError(m.t, err)
+	idToken, err := signed.CompactSerialize()
+	require.NoError(m.t, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// Проходит весь редирект-цикл как браузер и возвращает запрос на callback с кукой state
+func runOIDCLogin(t *testing.T, provider *OIDCProvider) (*http.Request, *http.Cookie) {
+	start := httptest.NewRecorder()
+	authURL, err := provider.StartLogin(start)
+	require.NoError(t, err)
+	cookies := start.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback := httptest.NewRequest(http.MethodGet, resp.Header.Get("Location"), nil)
+	return callback, cookies[0]
+}
+
+func newTestOIDCProvider(t *testing.T, mock *mockOIDCServer) *OIDCProvider {
+	provider, err := NewOIDCProvider(context.Background(), "mock", config.OIDCProvider{
+		Issuer:       mock.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}, "http://localhost:8080/oauth/mock/callback")
+	require.NoError(t, err)
+	return provider
+}
+
+func TestOIDCLoginFlow(t *testing.T) {
+	mock := newMockOIDCServer(t, map[string]interface{}{
+		"sub":            "provider-user-1",
+		"email":          "User@Example.com",
+		"email_verified": true,
+	})
+	provider := newTestOIDCProvider(t, mock)
+
+	callback, cookie := runOIDCLogin(t, provider)
+	callback.AddCookie(cookie)
+
+	identity, err := provider.FinishLogin(httptest.NewRecorder(), callback)
+	require.NoError(t, err)
+	assert.Equal(t, entities.OIDCIdentity{
+		Provider:      "mock",
+		Subject:       "provider-user-1",
+		Email:         "user@example.com",
+		EmailVerified: true,
+	}, identity)
+}
+
+func TestOIDCRejectsBadState(t *testing.T) {
+	mock := newMockOIDCServer(t, map[string]interface{}{"sub": "provider-user-1"})
+	provider := newTestOIDCProvider(t, mock)
+
+	// без куки state
+	callback, _ := runOIDCLogin(t, provider)
+	_, err := provider.FinishLogin(httptest.NewRecorder(), callback)
+	assert.Equal(t, ErrOIDCState, err)
+
+	// кука от другого входа
+	callback, _ = runOIDCLogin(t, provider)
+	_, otherCookie := runOIDCLogin(t, provider)
+	callback.AddCookie(otherCookie)
+	_, err = provider.FinishLogin(httptest.NewRecorder(), callback)
+	assert.Equal(t, ErrOIDCState, err)
+}
+
+type fakeOIDCStore struct {
+	users      map[int]entities.User
+	identities map[string]int
+}
+
+func (s *fakeOIDCStore) GetUserByIdentity(_ context.Context, provider, subject string) (entities.User, bool, error) {
+	userID, ok := s.identities[provider+"/"+subject]
+	if !ok {
+		return entities.User{}, false, nil
+	}
+	return s.users[userID], true, nil
+}
+
+func (s *fakeOIDCStore) FindUserByEmail(_ context.Context, email string) (entities.User, bool, error) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, true, nil
+		}
+	}
+	return entities.User{}, false, nil
+}
+
+func (s *fakeOIDCStore) LinkIdentity(_ context.Context, userID int, identity entities.OIDCIdentity) error {
+	s.identities[identity.Provider+"/"+identity.Subject] = userID
+	return nil
+}
+
+func (s *fakeOIDCStore) CreateOIDCUser(_ context.Context, identity entities.OIDCIdentity, passwordHash string) (entities.User, error) {
+	user := entities.User{ID: len(s.users) + 1, Email: identity.Email, Passwrd: passwordHash, EmailVerified: true}
+	s.users[user.ID] = user
+	s.identities[identity.Provider+"/"+identity.Subject] = user.ID
+	return user, nil
+}
+
+func TestLoginWithOIDC(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeOIDCStore{
+		users: map[int]entities.User{
+			1: {ID: 1, Email: "existing@example.com", EmailVerified: true},
+			2: {ID: 2, Email: "unverified@example.com"},
+			3: {ID: 3, Email: entities.AnonymousEmail, Passwrd: entities.AnonymousPassword, EmailVerified: true},
+		},
+		identities: map[string]int{},
+	}
+
+	// новый пользователь создаётся
+	created, err := LoginWithOIDC(ctx, store, entities.OIDCIdentity{Provider: "mock", Subject: "a", Email: "new@example.com", EmailVerified: true}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", created.Email)
+	assert.NotEmpty(t, store.users[created.ID].Passwrd)
+
+	// повторный вход находит того же пользователя
+	again, err := LoginWithOIDC(ctx, store, entities.OIDCIdentity{Provider: "mock", Subject: "a"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, again.ID)
+
+	// существующий пользователь привязывается по подтверждённому email
+	linked, err := LoginWithOIDC(ctx, store, entities.OIDCIdentity{Provider: "mock", Subject: "b", Email: "existing@example.com", EmailVerified: true}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, linked.ID)
+
+	// неподтверждённый email не даёт войти в чужой аккаунт
+	_, err = LoginWithOIDC(ctx, store, entities.OIDCIdentity{Provider: "mock", Subject: "c", Email: "existing@example.com"}, 0)
+	assert.Equal(t, ErrOIDCEmailNotVerified, err)
+
+	// неподтверждённый локальный аккаунт мог зарегистрировать кто угодно,
+	// он не получает аккаунт провайдера по совпадению адреса
+	_, err = LoginWithOIDC(ctx, store, entities.OIDCIdentity{Provider: "mock", Subject: "e", Email: "unverified@example.com", EmailVerified: true}, 0)
+	assert.Equal(t, ErrOIDCAccountNotVerified, err)
+	_, found, _ := store.GetUserByIdentity(ctx, "mock", "e")
+	assert.False(t, found)
+
+	// провайдер не может выдать себя за служебного анонимного пользователя
+	_, err = LoginWithOIDC(ctx, store, entities.OIDCIdentity{Provider: "mock", Subject: "f", Email: entities.AnonymousEmail, EmailVerified: true}, 0)
+	assert.Equal(t, ErrOIDCEmailNotVerified, err)
+	_, found, _ = store.GetUserByIdentity(ctx, "mock", "f")
+	assert.False(t, found)
+
+	// вошедший пользователь привязывает аккаунт к себе независимо от email
+	own, err := LoginWithOIDC(ctx, store, entities.OIDCIdentity{Provider: "other", Subject: "d", Email: "whatever@example.com"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, own.ID)
+}
+
+func TestOIDCSuccessURL(t *testing.T) {
+	prev := oidcSuccessURL
+	oidcSuccessURL = "http://localhost:3000/?from=oauth"
+	defer func() { oidcSuccessURL = prev }()
+
+	assert.Equal(t, "http://localhost:3000/?from=oauth", OIDCSuccessURL(""))
+	assert.Equal(t, "http://localhost:3000/?from=oauth&two_factor_challenge=abc", OIDCSuccessURL("abc"))
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/mailer"
+	"homework_ipl/internal/password"
+
+	"github.com/pkg/errors"
+)
+
+var ErrWeakPassword = errors.New("password is not complex")
+
+// PasswordResetStore - токены сброса пароля (repository.PasswordResetRepo)
+type PasswordResetStore interface {
+	CreateToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	LastSentAt(ctx context.Context, userID int) (time.Time, bool, error)
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, bool, error)
+}
+
+// Отправляет пользователю одноразовую ссылку для сброса пароля
+func SendPasswordReset(ctx context.Context, store PasswordResetStore, user entities.User) error {
+	lastSent, ok, err := store.LastSentAt(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if ok && time.Since(lastSent) < resendInterval {
+		return ErrResendTooSoon
+	}
+
+	token, err := NewToken()
+	if err != nil {
+		return err
+	}
+
+	if err = store.CreateToken(ctx, user.ID, HashToken(token), time.Now().Add(resetTTL)); err != nil {
+		return err
+	}
+
+	// страница фронтенда сама отправляет токен с новым паролем на POST /password/reset
+	link := mailFrontendURL + "/password/reset?token=" + url.QueryEscape(token)
+	return Mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: "Чтобы задать новый пароль, перейдите по ссылке:\n" + link + "\n\nСсылка действует " + resetTTL.String() +
+			". Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.",
+	})
+}
+
+// Сброс пароля по токену из письма. Все сессии пользователя завершаются,
+// возвращает айди пользователя
+func ResetPassword(ctx context.Context, store PasswordResetStore, token, newPassword string) (int, error) {
+	if token == "" {
+		return 0, ErrInvalidToken
+	}
+	if entities.ValidatePassword(newPassword) != nil {
+		return 0, ErrWeakPassword
+	}
+
+	hashedPassword, err := password.Hash(newPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	userID, ok, err := store.ResetPassword(ctx, HashToken(token), hashedPassword)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+
+	if err = RevokeUserSessions(ctx, userID); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePasswordResetStore struct {
+	fakeVerificationStore
+	passwords map[int]string
+}
+
+func (s *fakePasswordResetStore) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, bool, error) {
+	userID, ok, err := s.ConsumeToken(ctx, tokenHash)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+	s.passwords[userID] = passwordHash
+	return userID, true, nil
+}
+
+func TestPasswordReset(t *testing.T) {
+	mail := &fakeMailer{}
+	prevMail, prevSessions := Mail, Sessions
+	Mail, Sessions = mail, NewMemorySessionStore()
+	defer func() { Mail, Sessions = prevMail, prevSessions }()
+
+	ctx := context.Background()
+	store := &fakePasswordResetStore{
+		fakeVerificationStore: fakeVerificationStore{tokens: map[string]verificationToken{}},
+		passwords:             map[int]string{},
+	}
+	user := entities.User{ID: 3, Email: "user@example.com"}
+
+	for _, id := range []string{"phone", "laptop"} {
+		require.NoError(t, Sessions.Create(ctx, entities.Session{ID: id, UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}))
+	}
+
+	require.NoError(t, SendPasswordReset(ctx, store, user))
+	require.Len(t, mail.sent, 1)
+	// ссылка ведёт на страницу фронтенда, а не на POST-only ручку бэкенда
+	assert.Contains(t, mail.sent[0].Body, mailFrontendURL+"/password/reset?token=")
+	token := tokenFromMail(t, mail.sent[0])
+
+	_, err := ResetPassword(ctx, store, token, "weak")
+	assert.Equal(t, ErrWeakPassword, err)
+
+	_, err = ResetPassword(ctx, store, "wrong", "NewPassword1")
+	assert.Equal(t, ErrInvalidToken, err)
+
+	userID, err := ResetPassword(ctx, store, token, "NewPassword1")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+	ok, err := password.Verify("NewPassword1", store.passwords[user.ID])
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// все сессии пользователя завершены
+	sessions, err := Sessions.ListByUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	// повторно ссылкой воспользоваться нельзя
+	_, err = ResetPassword(ctx, store, token, "OtherPassword2")
+	assert.Equal(t, ErrInvalidToken, err)
+}
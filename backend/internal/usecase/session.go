@@ -1,57 +1,255 @@
 package usecase
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
 	"net/http"
-	"sync"
 	"time"
 
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+	"homework_ipl/utils/logger"
+
 	"github.com/google/uuid"
 	"github.com/gorilla/securecookie"
+	"github.com/pkg/errors"
 )
 
 const sessionId = "session_id"
 
+// Окружение, где допустимы настройки только для локального запуска
+const envLocal = "local"
+
+// Не чаще раза в минуту пишем last_seen, чтобы не нагружать бд на каждый запрос
+const touchInterval = time.Minute
+
+// Абсолютное время жизни и время простоя (0 - без ограничения простоя)
+var (
+	sessionLifetime    = 24 * time.Hour
+	sessionIdleTimeout time.Duration
+)
+
+// Атрибуты куки сессии, задаются в конфиге для каждого окружения
+var (
+	cookieSecure   bool
+	cookieHTTPOnly = true
+	cookieSameSite = http.SameSiteLaxMode
+)
+
+// По умолчанию ключи случайные, а сессии в памяти - InitSessions
+// заменяет их на ключи из конфига и постоянное хранилище
 var CookieHandler = securecookie.New(
 	securecookie.GenerateRandomKey(64),
 	securecookie.GenerateRandomKey(32))
 
-var SessionStore = make(map[string]int)
-var mu sync.Mutex
+var Sessions SessionStore = NewMemorySessionStore()
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Настройка сессий при старте сервиса: ключи куки берутся из конфига,
+// чтобы все инстансы бэкенда могли расшифровать одну и ту же куку
+func InitSessions(cfg *config.Config, store SessionStore) error {
+	hashKey, blockKey, err := sessionKeys(cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Session.Lifetime > 0 {
+		sessionLifetime = cfg.Session.Lifetime
+	}
+	sessionIdleTimeout = cfg.Session.IdleTimeout
+
+	sameSite, err := parseSameSite(cfg.Session.CookieSameSite)
+	if err != nil {
+		return err
+	}
+	// SameSite=None браузеры принимают только вместе с Secure
+	if sameSite == http.SameSiteNoneMode && !cfg.Session.CookieSecure {
+		return errors.New("cookie_same_site none requires cookie_secure")
+	}
+	cookieSameSite = sameSite
+	cookieSecure = cfg.Session.CookieSecure
+	cookieHTTPOnly = cfg.Session.CookieHTTPOnly
+
+	csrfKey, err = deriveCSRFKey(hashKey)
+	if err != nil {
+		return errors.Wrap(err, "cannot derive csrf key")
+	}
+	CookieHandler = securecookie.New(hashKey, blockKey)
+	CookieHandler.MaxAge(int(sessionLifetime.Seconds()))
+	Sessions = store
+
+	return nil
+}
+
+// Ключи куки из конфига. Локально без ключей генерируются случайные:
+// так в репозитории не лежат секреты, но сессии не переживают рестарт
+func sessionKeys(cfg *config.Config) ([]byte, []byte, error) {
+	if cfg.Session.HashKey == "" {
+		if cfg.Env != envLocal {
+			return nil, nil, errors.New("session hash key is not set, use SESSION_HASH_KEY")
+		}
+		logger.Logger().Info("Session keys are not set, using random keys")
+		return securecookie.GenerateRandomKey(64), securecookie.GenerateRandomKey(32), nil
+	}
+
+	hashKey, err := hex.DecodeString(cfg.Session.HashKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid session hash key")
+	}
+
+	blockKey, err := hex.DecodeString(cfg.Session.BlockKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid session block key")
+	}
+	if len(blockKey) == 0 {
+		blockKey = nil
+	}
+	return hashKey, blockKey, nil
+}
 
 // Запись сессии в куки, чтобы после авторизации можно было пользоваться
-// функционалом сайта
-func SetSession(w http.ResponseWriter, userID int) error {
-	sessionID := uuid.New().String()
-	mu.Lock()
-	SessionStore[sessionID] = userID
-	mu.Unlock()
-	encoded, err := CookieHandler.Encode(sessionId, sessionID)
+// функционалом сайта. Старая сессия из куки удаляется, так что при каждом
+// входе (и смене пароля) айди сессии меняется
+func SetSession(w http.ResponseWriter, r *http.Request, userID int, role string) error {
+	if oldID, ok := currentSessionID(r); ok {
+		if err := Sessions.Delete(r.Context(), oldID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	session := entities.Session{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Role:      role,
+		CreatedAt: now,
+		ExpiresAt: now.Add(sessionLifetime),
+		LastSeen:  now,
+		UserAgent: r.UserAgent(),
+		IP:        ClientIP(r),
+	}
+
+	if err := Sessions.Create(r.Context(), session); err != nil {
+		return err
+	}
+
+	encoded, err := CookieHandler.Encode(sessionId, session.ID)
 	if err != nil {
 		return err
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:    sessionId,
-		Value:   encoded,
-		Path:    "/",
-		Expires: time.Now().Add(24 * time.Hour),
-	})
+	http.SetCookie(w, sessionCookie(encoded, cookieExpiry(session, now), 0))
 	return nil
 }
 
 func GetSession(r *http.Request) int {
-	cookie, err := r.Cookie(sessionId)
-	if err != nil {
+	session, ok := GetSessionData(nil, r)
+	if !ok {
 		return 0
 	}
+	return session.UserID
+}
 
-	var sessionID string
-	if err = CookieHandler.Decode(sessionId, cookie.Value, &sessionID); err == nil {
-		mu.Lock()
-		defer mu.Unlock()
-		return SessionStore[sessionID]
+// Данные текущей сессии (айди пользователя и его роль). При продлении
+// сессии кука перевыпускается в w, w == nil - куку не трогать
+func GetSessionData(w http.ResponseWriter, r *http.Request) (entities.Session, bool) {
+	sessionID, ok := currentSessionID(r)
+	if !ok {
+		return entities.Session{}, false
+	}
+
+	session, ok, err := Sessions.Get(r.Context(), sessionID)
+	if err != nil || !ok {
+		return entities.Session{}, false
+	}
+
+	now := time.Now()
+	if sessionExpired(session, now) {
+		if err = Sessions.Delete(r.Context(), sessionID); err != nil {
+			logger.Logger().Error("Error while deleting expired session", "error", err)
+		}
+		return entities.Session{}, false
+	}
+
+	// скользящее продление: активность отодвигает истечение по простою
+	if now.Sub(session.LastSeen) >= touchInterval {
+		if err = Sessions.Touch(r.Context(), sessionID, now); err != nil {
+			logger.Logger().Error("Error while touching session", "error", err)
+		} else if w != nil {
+			refreshSessionCookie(w, session, now)
+		}
+		session.LastSeen = now
 	}
-	return 0
+
+	return session, true
+}
+
+// Фоновая очистка истекших сессий, работает до отмены ctx
+func StartSessionSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				SweepSessions(ctx)
+			}
+		}
+	}()
+}
+
+func SweepSessions(ctx context.Context) {
+	now := time.Now()
+
+	var idleBefore time.Time
+	if sessionIdleTimeout > 0 {
+		idleBefore = now.Add(-sessionIdleTimeout)
+	}
+
+	deleted, err := Sessions.DeleteExpired(ctx, now, idleBefore)
+	if err != nil {
+		logger.Logger().Error("Error while sweeping sessions", "error", err)
+		return
+	}
+	if deleted > 0 {
+		logger.Logger().Info("Expired sessions removed", "count", deleted)
+	}
+}
+
+// Кука живёт столько же, сколько сессия: до абсолютного истечения
+// или до истечения по простою, если он наступит раньше
+func cookieExpiry(session entities.Session, lastSeen time.Time) time.Time {
+	if sessionIdleTimeout > 0 && lastSeen.Add(sessionIdleTimeout).Before(session.ExpiresAt) {
+		return lastSeen.Add(sessionIdleTimeout)
+	}
+	return session.ExpiresAt
+}
+
+// Перевыпуск куки после продления, иначе браузер удалит её раньше сессии
+func refreshSessionCookie(w http.ResponseWriter, session entities.Session, lastSeen time.Time) {
+	encoded, err := CookieHandler.Encode(sessionId, session.ID)
+	if err != nil {
+		logger.Logger().Error("Error while encoding session cookie", "error", err)
+		return
+	}
+	http.SetCookie(w, sessionCookie(encoded, cookieExpiry(session, lastSeen), 0))
+}
+
+func sessionExpired(session entities.Session, now time.Time) bool {
+	if !now.Before(session.ExpiresAt) {
+		return true
+	}
+	return sessionIdleTimeout > 0 && now.Sub(session.LastSeen) > sessionIdleTimeout
 }
 
 func ClearSession(w http.ResponseWriter, r *http.Request) error {
@@ -66,15 +264,112 @@ func ClearSession(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	mu.Lock()
-	delete(SessionStore, sessionID)
-	mu.Unlock()
+	if err = Sessions.Delete(r.Context(), sessionID); err != nil {
+		return err
+	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:   sessionId,
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
-	})
+	http.SetCookie(w, sessionCookie("", time.Time{}, -1))
 	return nil
 }
+
+// Активные сессии пользователя. Вторым значением возвращается публичный
+// айди текущей сессии, чтобы фронт мог её пометить
+func ListSessions(r *http.Request, userID int) ([]entities.Session, string, error) {
+	sessions, err := Sessions.ListByUser(r.Context(), userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	var active []entities.Session
+	for _, session := range sessions {
+		if !sessionExpired(session, now) {
+			active = append(active, session)
+		}
+	}
+
+	current, _ := currentSessionID(r)
+	return active, PublicSessionID(current), nil
+}
+
+// Завершение одной сессии пользователя по публичному айди
+func RevokeSession(r *http.Request, userID int, publicID string) error {
+	sessions, err := Sessions.ListByUser(r.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if PublicSessionID(session.ID) == publicID {
+			return Sessions.Delete(r.Context(), session.ID)
+		}
+	}
+	return ErrSessionNotFound
+}
+
+// Завершение всех сессий пользователя, кроме текущей
+func RevokeOtherSessions(r *http.Request, userID int) error {
+	current, _ := currentSessionID(r)
+	return Sessions.DeleteByUser(r.Context(), userID, current)
+}
+
+// Завершение всех сессий пользователя (смена роли, удаление аккаунта)
+func RevokeUserSessions(ctx context.Context, userID int) error {
+	return Sessions.DeleteByUser(ctx, userID, "")
+}
+
+// Настоящий айди сессии - секрет, на фронт отдаём только его хэш
+func PublicSessionID(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
+
+func currentSessionID(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(sessionId)
+	if err != nil {
+		return "", false
+	}
+
+	var sessionID string
+	if err = CookieHandler.Decode(sessionId, cookie.Value, &sessionID); err != nil {
+		return "", false
+	}
+	return sessionID, true
+}
+
+func sessionCookie(value string, expires time.Time, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     sessionId,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   maxAge,
+		Secure:   cookieSecure,
+		HttpOnly: cookieHTTPOnly,
+		SameSite: cookieSameSite,
+	}
+}
+
+func parseSameSite(value string) (http.SameSite, error) {
+	switch value {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, errors.Errorf("unknown cookie_same_site %q", value)
+}
+
+// IP клиента (middleware.RealIP уже подставил X-Real-IP / X-Forwarded-For в RemoteAddr)
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
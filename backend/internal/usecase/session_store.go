@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"homework_ipl/internal/entities"
+)
+
+// SessionStore - хранилище серверных сессий.
+// В проде используется repository.SessionRepo (Postgres), чтобы сессии
+// переживали рестарт и были общими для нескольких инстансов бэкенда.
+type SessionStore interface {
+	Create(ctx context.Context, session entities.Session) error
+	Get(ctx context.Context, id string) (entities.Session, bool, error)
+	Delete(ctx context.Context, id string) error
+	ListByUser(ctx context.Context, userID int) ([]entities.Session, error)
+	// Удаляет все сессии пользователя, кроме exceptID (пустая строка - удалить все)
+	DeleteByUser(ctx context.Context, userID int, exceptID string) error
+	// Обновляет время последней активности (скользящее продление сессии)
+	Touch(ctx context.Context, id string, lastSeen time.Time) error
+	// Удаляет сессии, истекшие до expiredBefore или неактивные с idleBefore
+	DeleteExpired(ctx context.Context, expiredBefore, idleBefore time.Time) (int64, error)
+}
+
+// MemorySessionStore хранит сессии в памяти процесса (для тестов и локального запуска)
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]entities.Session
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]entities.Session),
+	}
+}
+
+func (s *MemorySessionStore) Create(_ context.Context, session entities.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *MemorySessionStore) Get(_ context.Context, id string) (entities.Session, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	return session, ok, nil
+}
+
+func (s *MemorySessionStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *MemorySessionStore) ListByUser(_ context.Context, userID int) ([]entities.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sessions []entities.Session
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+	return sessions, nil
+}
+
+func (s *MemorySessionStore) DeleteByUser(_ context.Context, userID int, exceptID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID && id != exceptID {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (s *MemorySessionStore) Touch(_ context.Context, id string, lastSeen time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok {
+		session.LastSeen = lastSeen
+		s.sessions[id] = session
+	}
+	return nil
+}
+
+func (s *MemorySessionStore) DeleteExpired(_ context.Context, expiredBefore, idleBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for id, session := range s.sessions {
+		if !session.ExpiresAt.After(expiredBefore) || !session.LastSeen.After(idleBefore) {
+			delete(s.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
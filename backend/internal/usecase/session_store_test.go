@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySessionStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemorySessionStore()
+
+	sessions := []entities.Session{
+		{ID: "a", UserID: 1, CreatedAt: now, ExpiresAt: now.Add(time.Hour), LastSeen: now},
+		{ID: "b", UserID: 1, CreatedAt: now, ExpiresAt: now.Add(time.Hour), LastSeen: now.Add(time.Minute)},
+		{ID: "c", UserID: 2, CreatedAt: now, ExpiresAt: now.Add(time.Hour), LastSeen: now},
+	}
+	for _, session := range sessions {
+		assert.NoError(t, store.Create(ctx, session))
+	}
+
+	session, ok, err := store.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, session.UserID)
+
+	_, ok, err = store.Get(ctx, "missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// последние использованные - первыми
+	list, err := store.ListByUser(ctx, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, "b", list[0].ID)
+		assert.Equal(t, "a", list[1].ID)
+	}
+
+	assert.NoError(t, store.Touch(ctx, "a", now.Add(2*time.Minute)))
+	session, _, _ = store.Get(ctx, "a")
+	assert.Equal(t, now.Add(2*time.Minute), session.LastSeen)
+
+	assert.NoError(t, store.DeleteByUser(ctx, 1, "a"))
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, "a")
+	assert.True(t, ok)
+	_, ok, _ = store.Get(ctx, "c")
+	assert.True(t, ok, "other users' sessions are kept")
+
+	assert.NoError(t, store.Delete(ctx, "a"))
+	_, ok, _ = store.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestMemorySessionStoreDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemorySessionStore()
+
+	assert.NoError(t, store.Create(ctx, entities.Session{ID: "expired", ExpiresAt: now, LastSeen: now}))
+	assert.NoError(t, store.Create(ctx, entities.Session{ID: "idle", ExpiresAt: now.Add(time.Hour), LastSeen: now.Add(-2 * time.Hour)}))
+	assert.NoError(t, store.Create(ctx, entities.Session{ID: "active", ExpiresAt: now.Add(time.Hour), LastSeen: now}))
+
+	deleted, err := store.DeleteExpired(ctx, now, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	_, ok, _ := store.Get(ctx, "active")
+	assert.True(t, ok)
+
+	// нулевой idleBefore - простой не ограничен
+	assert.NoError(t, store.Create(ctx, entities.Session{ID: "old", ExpiresAt: now.Add(time.Hour), LastSeen: now.Add(-24 * time.Hour)}))
+	deleted, err = store.DeleteExpired(ctx, now, time.Time{})
+	assert.NoError(t, err)
+	assert.Zero(t, deleted)
+}
+
+func TestSessionKeys(t *testing.T) {
+	cfg := &config.Config{Env: "prod"}
+	_, _, err := sessionKeys(cfg)
+	assert.Error(t, err, "keys are required outside local")
+
+	cfg.Env = envLocal
+	hashKey, blockKey, err := sessionKeys(cfg)
+	assert.NoError(t, err)
+	assert.Len(t, hashKey, 64)
+	assert.Len(t, blockKey, 32)
+
+	cfg = &config.Config{Env: "prod", Session: config.Session{HashKey: "zz"}}
+	_, _, err = sessionKeys(cfg)
+	assert.Error(t, err)
+
+	cfg.Session.HashKey = "0102"
+	hashKey, blockKey, err = sessionKeys(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, hashKey)
+	assert.Nil(t, blockKey)
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Сессии в памяти и заданные таймауты на время теста
+func setupSessionStore(t *testing.T, lifetime, idleTimeout time.Duration) *MemorySessionStore {
+	t.Helper()
+	store := NewMemorySessionStore()
+	prevStore, prevLifetime, prevIdle := Sessions, sessionLifetime, sessionIdleTimeout
+	Sessions, sessionLifetime, sessionIdleTimeout = store, lifetime, idleTimeout
+	t.Cleanup(func() {
+		Sessions, sessionLifetime, sessionIdleTimeout = prevStore, prevLifetime, prevIdle
+	})
+	return store
+}
+
+func requestWithSession(t *testing.T, sessionID string) *http.Request {
+	t.Helper()
+	encoded, err := CookieHandler.Encode(sessionId, sessionID)
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/profile/1", nil)
+	r.AddCookie(&http.Cookie{Name: sessionId, Value: encoded})
+	return r
+}
+
+func responseSessionCookie(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == sessionId {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestSetSessionRotates(t *testing.T) {
+	store := setupSessionStore(t, time.Hour, 10*time.Minute)
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, store.Create(ctx, entities.Session{ID: "old", UserID: 1, ExpiresAt: now.Add(time.Hour), LastSeen: now}))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, SetSession(w, requestWithSession(t, "old"), 1, entities.RoleUser))
+
+	_, ok, _ := store.Get(ctx, "old")
+	assert.False(t, ok, "previous session is removed on login")
+
+	sessions, err := store.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.NotEqual(t, "old", sessions[0].ID)
+
+	cookie := responseSessionCookie(w)
+	require.NotNil(t, cookie)
+	// кука истекает вместе с сессией по простою
+	assert.WithinDuration(t, now.Add(10*time.Minute), cookie.Expires, 2*time.Second)
+}
+
+func TestGetSessionDataExpiry(t *testing.T) {
+	store := setupSessionStore(t, time.Hour, 10*time.Minute)
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, store.Create(ctx, entities.Session{ID: "expired", UserID: 1, ExpiresAt: now.Add(-time.Second), LastSeen: now}))
+	require.NoError(t, store.Create(ctx, entities.Session{ID: "idle", UserID: 1, ExpiresAt: now.Add(time.Hour), LastSeen: now.Add(-11 * time.Minute)}))
+
+	for _, id := range []string{"expired", "idle"} {
+		_, ok := GetSessionData(httptest.NewRecorder(), requestWithSession(t, id))
+		assert.False(t, ok, id)
+		_, found, _ := store.Get(ctx, id)
+		assert.False(t, found, "%s session is deleted on access", id)
+	}
+
+	_, ok := GetSessionData(httptest.NewRecorder(), requestWithSession(t, "missing"))
+	assert.False(t, ok)
+}
+
+func TestGetSessionDataSlidingRenewal(t *testing.T) {
+	store := setupSessionStore(t, time.Hour, 10*time.Minute)
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, store.Create(ctx, entities.Session{ID: "fresh", UserID: 1, ExpiresAt: now.Add(time.Hour), LastSeen: now}))
+	require.NoError(t, store.Create(ctx, entities.Session{ID: "stale", UserID: 1, ExpiresAt: now.Add(time.Hour), LastSeen: now.Add(-5 * time.Minute)}))
+
+	// недавно продлённую сессию не трогаем
+	w := httptest.NewRecorder()
+	session, ok := GetSessionData(w, requestWithSession(t, "fresh"))
+	assert.True(t, ok)
+	assert.Equal(t, 1, session.UserID)
+	assert.Nil(t, responseSessionCookie(w))
+
+	w = httptest.NewRecorder()
+	_, ok = GetSessionData(w, requestWithSession(t, "stale"))
+	assert.True(t, ok)
+
+	stored, _, _ := store.Get(ctx, "stale")
+	assert.WithinDuration(t, time.Now(), stored.LastSeen, 2*time.Second)
+
+	cookie := responseSessionCookie(w)
+	require.NotNil(t, cookie, "cookie is re-issued when the session is extended")
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), cookie.Expires, 2*time.Second)
+}
+
+func TestCookieExpiry(t *testing.T) {
+	setupSessionStore(t, time.Hour, 10*time.Minute)
+	now := time.Now()
+
+	session := entities.Session{ExpiresAt: now.Add(time.Hour)}
+	assert.Equal(t, now.Add(10*time.Minute), cookieExpiry(session, now))
+
+	// простой не продлевает куку дальше абсолютного истечения
+	session.ExpiresAt = now.Add(5 * time.Minute)
+	assert.Equal(t, session.ExpiresAt, cookieExpiry(session, now))
+
+	sessionIdleTimeout = 0
+	session.ExpiresAt = now.Add(time.Hour)
+	assert.Equal(t, session.ExpiresAt, cookieExpiry(session, now))
+}
+
+func TestSweepSessions(t *testing.T) {
+	store := setupSessionStore(t, time.Hour, 10*time.Minute)
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, store.Create(ctx, entities.Session{ID: "expired", UserID: 1, ExpiresAt: now.Add(-time.Second), LastSeen: now}))
+	require.NoError(t, store.Create(ctx, entities.Session{ID: "idle", UserID: 1, ExpiresAt: now.Add(time.Hour), LastSeen: now.Add(-11 * time.Minute)}))
+	require.NoError(t, store.Create(ctx, entities.Session{ID: "active", UserID: 1, ExpiresAt: now.Add(time.Hour), LastSeen: now}))
+
+	SweepSessions(ctx)
+
+	sessions, err := store.ListByUser(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "active", sessions[0].ID)
+}
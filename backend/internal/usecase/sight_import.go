@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"io"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/sightimport"
+)
+
+// SightImportStore - запись строк импорта (repository.ImportRepo)
+type SightImportStore interface {
+	// Одна транзакция на все строки, при dryRun или ошибках в строках она откатывается
+	ImportSights(ctx context.Context, rows []entities.ImportRow, dryRun bool) (entities.ImportReport, error)
+}
+
+// Импорт достопримечательностей из CSV или GeoJSON. Если хоть одна строка с ошибкой,
+// ничего не записывается, но отчёт всё равно показывает изменения по остальным строкам.
+// error - файл не разобран или ошибка базы
+func ImportSights(ctx context.Context, store SightImportStore, r io.Reader, format string, dryRun bool) (entities.ImportReport, error) {
+	rows, parseErrors, err := sightimport.Parse(r, format)
+	if err != nil {
+		return entities.ImportReport{}, err
+	}
+	rowErrors := sightimport.MergeErrors(parseErrors, sightimport.Validate(rows))
+
+	invalid := make(map[int]bool, len(rowErrors))
+	for _, rowErr := range rowErrors {
+		invalid[rowErr.Line] = true
+	}
+	valid := make([]entities.ImportRow, 0, len(rows))
+	for _, row := range rows {
+		if !invalid[row.Line] {
+			valid = append(valid, row)
+		}
+	}
+
+	report, err := store.ImportSights(ctx, valid, dryRun || len(rowErrors) > 0)
+	if err != nil {
+		return entities.ImportReport{}, err
+	}
+	report.DryRun = dryRun
+	report.Errors = sightimport.MergeErrors(rowErrors, report.Errors)
+
+	if report.Applied {
+		ClearSuggestions()
+	}
+	return report, nil
+}
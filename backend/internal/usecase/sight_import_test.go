@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/sightimport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSightImportStore struct {
+	rows   []entities.ImportRow
+	dryRun bool
+}
+
+func (s *fakeSightImportStore) ImportSights(_ context.Context, rows []entities.ImportRow, dryRun bool) (entities.ImportReport, error) {
+	s.rows, s.dryRun = rows, dryRun
+	report := entities.ImportReport{DryRun: dryRun, Applied: !dryRun}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, entities.ImportRowResult{Line: row.Line, Name: row.Name, Action: entities.ImportCreate})
+		report.Created++
+	}
+	return report, nil
+}
+
+const importCSV = "name,city,country,rating\n" +
+	"Кремль,Москва,Россия,4.9\n"
+
+func TestImportSights(t *testing.T) {
+	store := &fakeSightImportStore{}
+	report, err := ImportSights(context.Background(), store, strings.NewReader(importCSV), sightimport.FormatCSV, false)
+	require.NoError(t, err)
+	assert.True(t, report.Applied)
+	assert.False(t, store.dryRun)
+	assert.Equal(t, 1, report.Created)
+
+	report, err = ImportSights(context.Background(), store, strings.NewReader(importCSV), sightimport.FormatCSV, true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.False(t, report.Applied)
+	assert.True(t, store.dryRun)
+}
+
+func TestImportSightsWithInvalidRows(t *testing.T) {
+	store := &fakeSightImportStore{}
+	input := importCSV + "Эрмитаж,,Россия,4.8\n"
+
+	report, err := ImportSights(context.Background(), store, strings.NewReader(input), sightimport.FormatCSV, false)
+	require.NoError(t, err)
+	assert.True(t, store.dryRun, "nothing is written when a row is invalid")
+	assert.False(t, report.Applied)
+	assert.False(t, report.DryRun)
+
+	require.Len(t, store.rows, 1, "valid rows are still reported")
+	assert.Equal(t, "Кремль", store.rows[0].Name)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 3, report.Errors[0].Line)
+}
+
+func TestImportSightsMalformedFile(t *testing.T) {
+	_, err := ImportSights(context.Background(), &fakeSightImportStore{}, strings.NewReader("{"), sightimport.FormatGeoJSON, true)
+	assert.ErrorIs(t, err, sightimport.ErrMalformedFile)
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+)
+
+// SuggestStore - подсказки строки поиска (repository.SearchRepo)
+type SuggestStore interface {
+	Suggest(ctx context.Context, query string, limit int) ([]entities.Suggestion, error)
+}
+
+// Кэш подсказок для частых префиксов, nil - без кэша
+var suggestCache *SuggestCache
+
+func InitSearch(cfg config.Search) {
+	if cfg.SuggestCacheSize <= 0 || cfg.SuggestCacheTTL <= 0 {
+		suggestCache = nil
+		return
+	}
+	suggestCache = NewSuggestCache(cfg.SuggestCacheSize, cfg.SuggestCacheTTL)
+}
+
+// Приводит запрос к виду, в котором он ищется и кэшируется:
+// "  Москва   Сити" и "москва сити" - один и тот же запрос
+func normalizeSuggestQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// Сброс кэша после изменения достопримечательностей, городов или стран
+func ClearSuggestions() {
+	suggestCache.Clear()
+}
+
+// Не больше entities.MaxSuggestions подсказок, пустой запрос - пустой список
+func Suggest(ctx context.Context, store SuggestStore, query string) ([]entities.Suggestion, error) {
+	query = normalizeSuggestQuery(query)
+	if query == "" {
+		return []entities.Suggestion{}, nil
+	}
+
+	if suggestions, ok := suggestCache.Get(query); ok {
+		return suggestions, nil
+	}
+
+	suggestions, err := store.Suggest(ctx, query, entities.MaxSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	suggestCache.Put(query, suggestions)
+	return suggestions, nil
+}
+
+// SuggestCache - LRU с временем жизни записей. Подсказки меняются
+// только вместе с достопримечательностями, так что небольшое устаревание допустимо
+type SuggestCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type suggestEntry struct {
+	query       string
+	suggestions []entities.Suggestion
+	expiresAt   time.Time
+}
+
+func NewSuggestCache(size int, ttl time.Duration) *SuggestCache {
+	return &SuggestCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *SuggestCache) Get(query string) ([]entities.Suggestion, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[query]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*suggestEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, query)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.suggestions, true
+}
+
+func (c *SuggestCache) Put(query string, suggestions []entities.Suggestion) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &suggestEntry{
+		query:       query,
+		suggestions: suggestions,
+		expiresAt:   c.now().Add(c.ttl),
+	}
+	if elem, ok := c.entries[query]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[query] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*suggestEntry).query)
+	}
+}
+
+func (c *SuggestCache) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
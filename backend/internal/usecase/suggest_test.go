@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSuggestStore struct {
+	queries []string
+}
+
+func (s *fakeSuggestStore) Suggest(_ context.Context, query string, limit int) ([]entities.Suggestion, error) {
+	s.queries = append(s.queries, query)
+	return []entities.Suggestion{{Type: entities.SuggestionCity, ID: 1, Label: "Москва"}}, nil
+}
+
+func TestSuggestCachesNormalizedQuery(t *testing.T) {
+	InitSearch(config.Search{SuggestCacheSize: 10, SuggestCacheTTL: time.Minute})
+	defer InitSearch(config.Search{})
+
+	store := &fakeSuggestStore{}
+	suggestions, err := Suggest(context.Background(), store, "  МОСК ")
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+
+	_, err = Suggest(context.Background(), store, "моск")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"моск"}, store.queries, "second query is served from cache")
+
+	ClearSuggestions()
+	_, err = Suggest(context.Background(), store, "моск")
+	require.NoError(t, err)
+	assert.Len(t, store.queries, 2)
+
+	suggestions, err = Suggest(context.Background(), store, "   ")
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+	assert.Len(t, store.queries, 2, "empty query does not hit the store")
+}
+
+func TestSuggestWithoutCache(t *testing.T) {
+	InitSearch(config.Search{})
+
+	store := &fakeSuggestStore{}
+	for i := 0; i < 2; i++ {
+		_, err := Suggest(context.Background(), store, "моск")
+		require.NoError(t, err)
+	}
+	assert.Len(t, store.queries, 2)
+}
+
+func TestSuggestCacheEviction(t *testing.T) {
+	now := time.Now()
+	cache := NewSuggestCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Put("a", nil)
+	cache.Put("b", nil)
+	_, ok := cache.Get("a")
+	require.True(t, ok)
+
+	// "b" использовался давнее всех
+	cache.Put("c", nil)
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get("c")
+	assert.False(t, ok, "entry expires after ttl")
+}
@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	recoveryCodeCount = 10
+	challengeName     = "two_factor_challenge"
+)
+
+var (
+	totpIssuer   = "homework_ipl"
+	challengeTTL = 5 * time.Minute
+)
+
+var (
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+	ErrInvalidChallenge   = errors.New("invalid or expired two-factor challenge")
+)
+
+// TOTPStore - секреты TOTP и коды восстановления (repository.TOTPRepo)
+type TOTPStore interface {
+	// ok == false, если пользователь ещё не начинал подключение
+	GetTOTP(ctx context.Context, userID int) (entities.TOTP, bool, error)
+	// Новый неподтверждённый секрет, заменяет предыдущий неподтверждённый
+	SaveTOTPSecret(ctx context.Context, userID int, secret string) error
+	// Включает 2FA и заменяет коды восстановления на новые
+	EnableTOTP(ctx context.Context, userID int, step int64, codeHashes []string) error
+	DisableTOTP(ctx context.Context, userID int) error
+	// Запоминает использованное окно, false - код из этого окна уже использовался
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	// Гасит код восстановления, false - такого кода нет
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+}
+
+func InitTwoFactor(cfg config.TwoFactor) {
+	if cfg.Issuer != "" {
+		totpIssuer = cfg.Issuer
+	}
+	if cfg.ChallengeTTL > 0 {
+		challengeTTL = cfg.ChallengeTTL
+	}
+}
+
+// TwoFactor - подключение, проверка и отключение TOTP для пользователей
+type TwoFactor struct {
+	store TOTPStore
+	now   func() time.Time
+}
+
+func NewTwoFactor(store TOTPStore) *TwoFactor {
+	return &TwoFactor{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+func (f *TwoFactor) Enabled(ctx context.Context, userID int) (bool, error) {
+	totp, ok, err := f.store.GetTOTP(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return ok && totp.Enabled, nil
+}
+
+// Первый шаг подключения: выдаёт секрет и otpauth-ссылку для QR-кода.
+// 2FA включится только после подтверждения кодом (Confirm)
+func (f *TwoFactor) Enroll(ctx context.Context, user entities.User) (string, string, error) {
+	enabled, err := f.Enabled(ctx, user.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if enabled {
+		return "", "", ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err = f.store.SaveTOTPSecret(ctx, user.ID, secret); err != nil {
+		return "", "", err
+	}
+
+	return secret, TOTPURI(totpIssuer, user.Email, secret), nil
+}
+
+// Подтверждение кодом из приложения: 2FA включается, возвращаются
+// коды восстановления (показываются пользователю один раз)
+func (f *TwoFactor) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	totp, ok, err := f.store.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTOTPNotEnrolled
+	}
+	if totp.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	step, valid := validateTOTP(totp.Secret, code, f.now())
+	if !valid {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	codes, hashes, err := newRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.store.EnableTOTP(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Отключение 2FA, нужен действующий код или код восстановления
+func (f *TwoFactor) Disable(ctx context.Context, userID int, code string) error {
+	if err := f.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return f.store.DisableTOTP(ctx, userID)
+}
+
+// Проверка кода при входе. Принимается код из приложения (каждый не больше
+// одного раза) или ещё не использованный код восстановления
+func (f *TwoFactor) Verify(ctx context.Context, userID int, code string) error {
+	totp, ok, err := f.store.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !ok || !totp.Enabled {
+		return ErrTOTPNotEnrolled
+	}
+
+	if step, valid := validateTOTP(totp.Secret, code, f.now()); valid {
+		if step <= totp.LastStep {
+			return ErrInvalidTOTPCode
+		}
+		fresh, err := f.store.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTOTPCode
+		}
+		return nil
+	}
+
+	used, err := f.store.UseRecoveryCode(ctx, userID, HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+type loginChallenge struct {
+	UserID    int
+	ExpiresAt int64
+}
+
+// Challenge между вводом пароля и вводом кода. Подписан и зашифрован
+// ключами сессионной куки, поэтому хранить его на сервере не нужно
+func (f *TwoFactor) NewChallenge(userID int) (string, error) {
+	return CookieHandler.Encode(challengeName, loginChallenge{
+		UserID:    userID,
+		ExpiresAt: f.now().Add(challengeTTL).Unix(),
+	})
+}
+
+// Айди пользователя из challenge
+func (f *TwoFactor) ParseChallenge(token string) (int, error) {
+	var challenge loginChallenge
+	if err := CookieHandler.Decode(challengeName, token, &challenge); err != nil {
+		return 0, ErrInvalidChallenge
+	}
+	if f.now().Unix() >= challenge.ExpiresAt {
+		return 0, ErrInvalidChallenge
+	}
+	return challenge.UserID, nil
+}
+
+// Коды вида xxxxx-xxxxx, в бд хранятся только хэши
+func newRecoveryCodes(n int) ([]string, []string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		for j := range buf {
+			buf[j] = alphabet[int(buf[j])%len(alphabet)]
+		}
+		code := string(buf[:5]) + "-" + string(buf[5:])
+
+		codes = append(codes, code)
+		hashes = append(hashes, HashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
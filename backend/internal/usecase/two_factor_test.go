@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// base32 от "12345678901234567890" - секрет из тестовых векторов RFC 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, v := range vectors {
+		code, err := totpCode(rfcSecret, totpStep(time.Unix(v.unix, 0)), 8)
+		require.NoError(t, err)
+		assert.Equal(t, v.code, code, "time %d", v.unix)
+	}
+
+	code, err := TOTPCode(rfcSecret, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := TOTPCode(rfcSecret, now)
+	require.NoError(t, err)
+
+	_, ok := validateTOTP(rfcSecret, code, now)
+	assert.True(t, ok)
+	_, ok = validateTOTP(rfcSecret, code, now.Add(totpPeriod*time.Second))
+	assert.True(t, ok, "code from the previous window is accepted")
+	_, ok = validateTOTP(rfcSecret, code, now.Add(3*totpPeriod*time.Second))
+	assert.False(t, ok)
+	_, ok = validateTOTP(rfcSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("homework_ipl", "user@example.com", rfcSecret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/homework_ipl:user@example.com", uri.Path)
+	assert.Equal(t, rfcSecret, uri.Query().Get("secret"))
+	assert.Equal(t, "homework_ipl", uri.Query().Get("issuer"))
+}
+
+type fakeTOTPStore struct {
+	totp     map[int]entities.TOTP
+	recovery map[int]map[string]bool
+}
+
+func newFakeTOTPStore() *fakeTOTPStore {
+	return &fakeTOTPStore{
+		totp:     map[int]entities.TOTP{},
+		recovery: map[int]map[string]bool{},
+	}
+}
+
+func (s *fakeTOTPStore) GetTOTP(_ context.Context, userID int) (entities.TOTP, bool, error) {
+	totp, ok := s.totp[userID]
+	return totp, ok, nil
+}
+
+func (s *fakeTOTPStore) SaveTOTPSecret(_ context.Context, userID int, secret string) error {
+	if s.totp[userID].Enabled {
+		return nil
+	}
+	s.totp[userID] = entities.TOTP{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (s *fakeTOTPStore) EnableTOTP(_ context.Context, userID int, step int64, codeHashes []string) error {
+	totp := s.totp[userID]
+	totp.Enabled = true
+	totp.LastStep = step
+	s.totp[userID] = totp
+
+	s.recovery[userID] = map[string]bool{}
+	for _, hash := range codeHashes {
+		s.recovery[userID][hash] = true
+	}
+	return nil
+}
+
+func (s *fakeTOTPStore) DisableTOTP(_ context.Context, userID int) error {
+	delete(s.totp, userID)
+	delete(s.recovery, userID)
+	return nil
+}
+
+func (s *fakeTOTPStore) UseTOTPStep(_ context.Context, userID int, step int64) (bool, error) {
+	totp := s.totp[userID]
+	if totp.LastStep >= step {
+		return false, nil
+	}
+	totp.LastStep = step
+	s.totp[userID] = totp
+	return true, nil
+}
+
+func (s *fakeTOTPStore) UseRecoveryCode(_ context.Context, userID int, codeHash string) (bool, error) {
+	if !s.recovery[userID][codeHash] {
+		return false, nil
+	}
+	delete(s.recovery[userID], codeHash)
+	return true, nil
+}
+
+func TestTwoFactorFlow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	store := newFakeTOTPStore()
+	twoFactor := NewTwoFactor(store)
+	twoFactor.now = func() time.Time { return now }
+
+	user := entities.User{ID: 5, Email: "user@example.com"}
+
+	secret, uri, err := twoFactor.Enroll(ctx, user)
+	require.NoError(t, err)
+	assert.Contains(t, uri, "secret="+secret)
+
+	enabled, err := twoFactor.Enabled(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled, "not enabled until confirmed")
+
+	_, err = twoFactor.Confirm(ctx, user.ID, "000000")
+	assert.Equal(t, ErrInvalidTOTPCode, err)
+
+	code, err := TOTPCode(secret, now)
+	require.NoError(t, err)
+	recovery, err := twoFactor.Confirm(ctx, user.ID, code)
+	require.NoError(t, err)
+	assert.Len(t, recovery, recoveryCodeCount)
+
+	enabled, err = twoFactor.Enabled(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	_, _, err = twoFactor.Enroll(ctx, user)
+	assert.Equal(t, ErrTOTPAlreadyEnabled, err)
+
+	// код, которым подтверждали подключение, для входа уже не годится
+	assert.Equal(t, ErrInvalidTOTPCode, twoFactor.Verify(ctx, user.ID, code))
+
+	now = now.Add(totpPeriod * time.Second)
+	code, err = TOTPCode(secret, now)
+	require.NoError(t, err)
+	assert.NoError(t, twoFactor.Verify(ctx, user.ID, code))
+	assert.Equal(t, ErrInvalidTOTPCode, twoFactor.Verify(ctx, user.ID, code), "replay in the same window")
+
+	// коды восстановления одноразовые, регистр и дефис не важны
+	assert.NoError(t, twoFactor.Verify(ctx, user.ID, strings.ToUpper(recovery[0])))
+	assert.Equal(t, ErrInvalidTOTPCode, twoFactor.Verify(ctx, user.ID, recovery[0]))
+
+	assert.NoError(t, twoFactor.Disable(ctx, user.ID, strings.ReplaceAll(recovery[1], "-", "")))
+	enabled, err = twoFactor.Enabled(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	assert.Equal(t, ErrTOTPNotEnrolled, twoFactor.Verify(ctx, user.ID, recovery[2]))
+}
+
+func TestLoginChallenge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	twoFactor := NewTwoFactor(newFakeTOTPStore())
+	twoFactor.now = func() time.Time { return now }
+
+	challenge, err := twoFactor.NewChallenge(5)
+	require.NoError(t, err)
+
+	userID, err := twoFactor.ParseChallenge(challenge)
+	require.NoError(t, err)
+	assert.Equal(t, 5, userID)
+
+	_, err = twoFactor.ParseChallenge(challenge + "x")
+	assert.Equal(t, ErrInvalidChallenge, err)
+
+	now = now.Add(challengeTTL)
+	_, err = twoFactor.ParseChallenge(challenge)
+	assert.Equal(t, ErrInvalidChallenge, err)
+}
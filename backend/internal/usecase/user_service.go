@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/password"
+)
+
+// UserStore - создание пользователей (repository.UserRepo)
+type UserStore interface {
+	// Создаёт user_data и profile_data в одной транзакции, user.Passwrd - хэш.
+	// При занятом email или имени профиля - entities.ErrEmailTaken / ErrUsernameTaken
+	CreateUser(ctx context.Context, user entities.User) (entities.User, error)
+}
+
+// UserService - единая точка создания пользователей
+type UserService struct {
+	store UserStore
+}
+
+func NewUserService(store UserStore) *UserService {
+	return &UserService{
+		store: store,
+	}
+}
+
+// Регистрация по email и паролю. Данные должны быть проверены entities.UserDataVerification
+func (s *UserService) SignUp(ctx context.Context, email, pw string) (entities.User, error) {
+	// служебный email выглядит для клиента как обычный занятый
+	if entities.IsReservedEmail(email) {
+		return entities.User{}, entities.ErrEmailTaken
+	}
+
+	hashedPassword, err := password.Hash(pw)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	return s.store.CreateUser(ctx, entities.User{
+		Email:   email,
+		Passwrd: hashedPassword,
+		Role:    entities.RoleUser,
+	})
+}
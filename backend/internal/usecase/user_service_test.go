@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Как в бд: имя профиля по умолчанию - email, и email, и имя уникальны
+type fakeUserStore struct {
+	users     []entities.User
+	usernames map[string]int
+}
+
+func (s *fakeUserStore) CreateUser(_ context.Context, user entities.User) (entities.User, error) {
+	for _, u := range s.users {
+		if u.Email == user.Email {
+			return entities.User{}, entities.ErrEmailTaken
+		}
+	}
+	if _, ok := s.usernames[user.Email]; ok {
+		return entities.User{}, entities.ErrUsernameTaken
+	}
+	if s.usernames == nil {
+		s.usernames = make(map[string]int)
+	}
+	user.ID = len(s.users) + 1
+	s.users = append(s.users, user)
+	s.usernames[user.Email] = user.ID
+	return user, nil
+}
+
+func TestUserServiceSignUp(t *testing.T) {
+	store := &fakeUserStore{}
+	service := NewUserService(store)
+
+	user, err := service.SignUp(context.Background(), "user@example.com", "Password1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	assert.Equal(t, entities.RoleUser, user.Role)
+	assert.False(t, user.EmailVerified)
+
+	// в хранилище попадает только хэш
+	require.Len(t, store.users, 1)
+	assert.NotEqual(t, "Password1", store.users[0].Passwrd)
+	ok, err := password.Verify("Password1", store.users[0].Passwrd)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = service.SignUp(context.Background(), "user@example.com", "Password2")
+	assert.Equal(t, entities.ErrEmailTaken, err)
+
+	// первый пользователь взял себе имя, совпадающее с чужим email
+	store.usernames["taken@example.com"] = 1
+	_, err = service.SignUp(context.Background(), "taken@example.com", "Password2")
+	assert.Equal(t, entities.ErrUsernameTaken, err)
+
+	// email служебного анонимного пользователя занять нельзя
+	_, err = service.SignUp(context.Background(), " Anonymous@Deleted.invalid", "Password3")
+	assert.Equal(t, entities.ErrEmailTaken, err)
+	assert.Len(t, store.users, 1)
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"homework_ipl/internal/config"
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/mailer"
+
+	"github.com/pkg/errors"
+)
+
+// Почта настраивается в main через InitMail, по умолчанию письма только пишутся в лог
+var (
+	Mail            mailer.Mailer = mailer.NewOutboxMailer("noreply@localhost", "")
+	mailBaseURL                   = "http://localhost:8080"
+	mailFrontendURL               = "http://localhost:3000"
+	verificationTTL               = 24 * time.Hour
+	resendInterval                = time.Minute
+	resetTTL                      = time.Hour
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid or expired token")
+	ErrResendTooSoon = errors.New("verification email was sent recently")
+)
+
+// VerificationStore - токены подтверждения почты (repository.VerificationRepo)
+type VerificationStore interface {
+	CreateToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	LastSentAt(ctx context.Context, userID int) (time.Time, bool, error)
+	ConsumeToken(ctx context.Context, tokenHash string) (int, bool, error)
+}
+
+func InitMail(cfg config.Mail, m mailer.Mailer) {
+	Mail = m
+	mailBaseURL = cfg.BaseURL
+	mailFrontendURL = strings.TrimSuffix(cfg.FrontendURL, "/")
+	if cfg.VerificationTTL > 0 {
+		verificationTTL = cfg.VerificationTTL
+	}
+	resendInterval = cfg.ResendInterval
+	if cfg.ResetTTL > 0 {
+		resetTTL = cfg.ResetTTL
+	}
+}
+
+// Создаёт токен подтверждения и отправляет ссылку на почту пользователя
+func SendVerification(ctx context.Context, store VerificationStore, user entities.User) error {
+	lastSent, ok, err := store.LastSentAt(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if ok && time.Since(lastSent) < resendInterval {
+		return ErrResendTooSoon
+	}
+
+	token, err := NewToken()
+	if err != nil {
+		return err
+	}
+
+	if err = store.CreateToken(ctx, user.ID, HashToken(token), time.Now().Add(verificationTTL)); err != nil {
+		return err
+	}
+
+	link := mailBaseURL + "/signup/verify?token=" + url.QueryEscape(token)
+	return Mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Подтверждение почты",
+		Body:    "Чтобы подтвердить почту, перейдите по ссылке:\n" + link + "\n\nСсылка действует " + verificationTTL.String() + ".",
+	})
+}
+
+// Подтверждение почты по токену из письма, возвращает айди пользователя
+func VerifyEmail(ctx context.Context, store VerificationStore, token string) (int, error) {
+	if token == "" {
+		return 0, ErrInvalidToken
+	}
+
+	userID, ok, err := store.ConsumeToken(ctx, HashToken(token))
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	return userID, nil
+}
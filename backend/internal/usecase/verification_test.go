@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/mailer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMailer struct {
+	sent []mailer.Message
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type verificationToken struct {
+	userID    int
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+type fakeVerificationStore struct {
+	tokens map[string]verificationToken
+}
+
+func (s *fakeVerificationStore) CreateToken(_ context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	s.tokens[tokenHash] = verificationToken{userID: userID, createdAt: time.Now(), expiresAt: expiresAt}
+	return nil
+}
+
+func (s *fakeVerificationStore) LastSentAt(_ context.Context, userID int) (time.Time, bool, error) {
+	var last time.Time
+	found := false
+	for _, t := range s.tokens {
+		if t.userID == userID && t.createdAt.After(last) {
+			last, found = t.createdAt, true
+		}
+	}
+	return last, found, nil
+}
+
+func (s *fakeVerificationStore) ConsumeToken(_ context.Context, tokenHash string) (int, bool, error) {
+	t, ok := s.tokens[tokenHash]
+	if !ok || time.Now().After(t.expiresAt) {
+		return 0, false, nil
+	}
+	delete(s.tokens, tokenHash)
+	return t.userID, true, nil
+}
+
+func tokenFromMail(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	i := strings.Index(msg.Body, "?token=")
+	require.NotEqual(t, -1, i)
+	raw := strings.Fields(msg.Body[i+len("?token="):])[0]
+	token, err := url.QueryUnescape(raw)
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerification(t *testing.T) {
+	mail := &fakeMailer{}
+	prevMail, prevInterval := Mail, resendInterval
+	Mail, resendInterval = mail, time.Minute
+	defer func() { Mail, resendInterval = prevMail, prevInterval }()
+
+	store := &fakeVerificationStore{tokens: map[string]verificationToken{}}
+	user := entities.User{ID: 7, Email: "user@example.com"}
+
+	require.NoError(t, SendVerification(context.Background(), store, user))
+	require.Len(t, mail.sent, 1)
+	assert.Equal(t, user.Email, mail.sent[0].To)
+
+	// в хранилище лежит только хэш, сам токен есть лишь в письме
+	token := tokenFromMail(t, mail.sent[0])
+	_, stored := store.tokens[token]
+	assert.False(t, stored)
+
+	err := SendVerification(context.Background(), store, user)
+	assert.Equal(t, ErrResendTooSoon, err)
+	assert.Len(t, mail.sent, 1)
+
+	_, err = VerifyEmail(context.Background(), store, "wrong")
+	assert.Equal(t, ErrInvalidToken, err)
+
+	userID, err := VerifyEmail(context.Background(), store, token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+
+	// токен одноразовый
+	_, err = VerifyEmail(context.Background(), store, token)
+	assert.Equal(t, ErrInvalidToken, err)
+}
@@ -5,6 +5,7 @@ import (
 
 	"homework_ipl/internal/config"
 	"homework_ipl/internal/entities"
+	"homework_ipl/internal/geoexport"
 
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
@@ -26,23 +27,32 @@ func SetupRouter(cfg *config.Config) *chi.Mux {
 	router.Use(middleware.Logger)
 	router.Use(cors.CorsMiddleware)
 	router.Use(middle.SessionMiddleware)
+	router.Use(middle.CSRFMiddleware)
 
 	router.Mount("/sights", SightRoutes())
 	router.Mount("/sights/search", FilteredSightRoutes())
+	router.Mount("/search", SearchRoutes())
 
 	// user authorization and registration
 	router.Mount("/signup", SignUpRoutes())
 	router.Mount("/login", AuthRoutes())
 	router.Mount("/logout", LogOutRoutes())
+	router.Mount("/csrf", CSRFRoutes())
+	router.Mount("/password", PasswordResetRoutes())
+	router.Mount("/oauth", OIDCRoutes())
 
 	// user profile
 	router.Mount("/profile/{id}", GetProfileRoutes())
 	router.Mount("/profile/{id}/edit", EditProfileRoutes())
 	router.Mount("/profile/{id}/delete", DeleteProfileRoutes())
 	router.Mount("/profile/{id}/reset_password", UpdateUserPasswordRoutes())
+	router.Mount("/profile/{id}/sessions", SessionsRoutes())
+	router.Mount("/profile/{id}/2fa", TwoFactorRoutes())
+	router.Mount("/profile/{id}/tokens", APITokenRoutes())
+	router.Mount("/profile/{id}/export", ExportRoutes())
 
 	handler := &user.ProfileHandler{}
-	router.Post("/profile/{id}/upload", func(w http.ResponseWriter, r *http.Request) {
+	router.With(middle.RequireAuth).Post("/profile/{id}/upload", func(w http.ResponseWriter, r *http.Request) {
 		handler.UploadFile(w, r)
 	})
 
@@ -62,27 +72,62 @@ func SetupRouter(cfg *config.Config) *chi.Mux {
 	router.Mount("/trip/{id}/sight/add", AddJourneySightRoutes())
 	router.Mount("/trip/{id}/sight/delete", DeleteJourneySightRoutes())
 
+	// admin
+	router.Mount("/admin", AdminRoutes())
+
 	return router
 }
 
 func SightRoutes() chi.Router {
 	router := chi.NewRouter()
 	sightsHandler := sight.SightsHandler{}
-	wrapperInstance := &wrapper.Wrapper[entities.Sight, entities.Sights]{ServeHTTP: sightsHandler.GetSights}
+	wrapperInstance := &wrapper.Wrapper[entities.Sight, entities.Sights]{
+		ServeHTTP: sightsHandler.GetSights,
+		Formats: geoexport.Formats(func(sights entities.Sights) geoexport.Document {
+			return geoexport.Document{Name: "Достопримечательности", Sights: sights.Sight}
+		}),
+	}
 	router.Get("/", wrapperInstance.HandlerWrapper)
 
+	nearbyWrapper := &wrapper.Wrapper[entities.Sight, entities.NearbySights]{ServeHTTP: sightsHandler.GetNearbySights}
+	router.Get("/nearby", nearbyWrapper.HandlerWrapper)
+
+	bboxWrapper := &wrapper.Wrapper[entities.Sight, entities.NearbySights]{ServeHTTP: sightsHandler.GetSightsInBBox}
+	router.Get("/in-bbox", bboxWrapper.HandlerWrapper)
+
+	clustersWrapper := &wrapper.Wrapper[entities.Sight, entities.SightClusters]{ServeHTTP: sightsHandler.GetSightClusters}
+	router.Get("/clusters", clustersWrapper.HandlerWrapper)
+
 	return router
 }
 
 func FilteredSightRoutes() chi.Router {
 	router := chi.NewRouter()
 	sightsHandler := sight.SightsHandler{}
-	wrapperInstance := &wrapper.Wrapper[entities.Sight, entities.Sights]{ServeHTTP: sightsHandler.GetFilteredSights}
+	wrapperInstance := &wrapper.Wrapper[entities.Sight, entities.SightSearchResults]{
+		ServeHTTP: sightsHandler.GetFilteredSights,
+		Formats: geoexport.Formats(func(results entities.SightSearchResults) geoexport.Document {
+			sights := make([]entities.Sight, 0, len(results.Sight))
+			for _, result := range results.Sight {
+				sights = append(sights, result.Sight)
+			}
+			return geoexport.Document{Name: "Результаты поиска", Sights: sights}
+		}),
+	}
 	router.Get("/", wrapperInstance.HandlerWrapper)
 
 	return router
 }
 
+func SearchRoutes() chi.Router {
+	router := chi.NewRouter()
+	searchHandler := sight.SearchHandler{}
+	suggestWrapper := &wrapper.Wrapper[entities.Sight, entities.Suggestions]{ServeHTTP: searchHandler.Suggest}
+	router.Get("/suggest", suggestWrapper.HandlerWrapper)
+
+	return router
+}
+
 func SignUpRoutes() chi.Router {
 	router := chi.NewRouter()
 
@@ -90,11 +135,44 @@ func SignUpRoutes() chi.Router {
 	wrapperInstance := &wrapper.Wrapper[entities.User, user.UserResponse]{ServeHTTP: regHandler.SignUp}
 	router.Post("/", wrapperInstance.HandlerWrapper)
 
+	verifyWrapper := &wrapper.Wrapper[entities.User, user.UserResponse]{ServeHTTP: regHandler.VerifyEmail}
+	router.Get("/verify", verifyWrapper.HandlerWrapper)
+
+	resendWrapper := &wrapper.Wrapper[entities.User, user.UserResponse]{ServeHTTP: regHandler.ResendVerification}
+	router.With(middle.RequireAuth).Post("/verify/resend", resendWrapper.HandlerWrapper)
+
+	return router
+}
+
+func PasswordResetRoutes() chi.Router {
+	router := chi.NewRouter()
+
+	resetHandler := user.PasswordResetHandler{}
+	forgotWrapper := &wrapper.Wrapper[entities.User, user.UserResponse]{ServeHTTP: resetHandler.ForgotPassword}
+	router.Post("/forgot", forgotWrapper.HandlerWrapper)
+
+	resetWrapper := &wrapper.Wrapper[entities.PasswordReset, user.UserResponse]{ServeHTTP: resetHandler.ResetPassword}
+	router.Post("/reset", resetWrapper.HandlerWrapper)
+
+	return router
+}
+
+func OIDCRoutes() chi.Router {
+	router := chi.NewRouter()
+	oidcHandler := user.OIDCHandler{}
+
+	providersWrapper := &wrapper.Wrapper[entities.OIDCProviders, entities.OIDCProviders]{ServeHTTP: oidcHandler.GetProviders}
+	router.Get("/providers", providersWrapper.HandlerWrapper)
+
+	router.Get("/{provider}/login", oidcHandler.Login)
+	router.Get("/{provider}/callback", oidcHandler.Callback)
+
 	return router
 }
 
 func LogOutRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireAuth)
 
 	logOutHandler := user.AuthorizationHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.User, user.UserResponse]{ServeHTTP: logOutHandler.LogOut}
@@ -110,6 +188,21 @@ func AuthRoutes() chi.Router {
 	wrapperInstance := &wrapper.Wrapper[entities.User, user.UserResponse]{ServeHTTP: authHandler.Authorize}
 	router.Post("/", wrapperInstance.HandlerWrapper)
 
+	twoFactorHandler := user.TwoFactorHandler{}
+	twoFactorWrapper := &wrapper.Wrapper[entities.TwoFactorCode, user.UserResponse]{ServeHTTP: twoFactorHandler.Login}
+	router.Post("/2fa", twoFactorWrapper.HandlerWrapper)
+
+	return router
+}
+
+func CSRFRoutes() chi.Router {
+	router := chi.NewRouter()
+	router.Use(middle.RequireAuth)
+
+	csrfHandler := user.CSRFHandler{}
+	wrapperInstance := &wrapper.Wrapper[entities.User, user.CSRFResponse]{ServeHTTP: csrfHandler.GetToken}
+	router.Get("/", wrapperInstance.HandlerWrapper)
+
 	return router
 }
 
@@ -126,6 +219,7 @@ func SightByIDRoutes() chi.Router {
 
 func CreateCommentRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireScope(entities.ScopeWriteComments))
 
 	commHandler := sight.CommentHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.Comment, entities.Comment]{ServeHTTP: commHandler.CreateComment}
@@ -136,6 +230,7 @@ func CreateCommentRoutes() chi.Router {
 
 func EditCommentRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireScope(entities.ScopeWriteComments))
 
 	commHandler := sight.CommentHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.Comment, entities.Comment]{ServeHTTP: commHandler.EditComment}
@@ -146,6 +241,7 @@ func EditCommentRoutes() chi.Router {
 
 func DeleteCommentRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireScope(entities.ScopeWriteComments))
 
 	commHandler := sight.CommentHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.Comment, entities.Comment]{ServeHTTP: commHandler.DeleteComment}
@@ -156,6 +252,7 @@ func DeleteCommentRoutes() chi.Router {
 
 func CreateJourneyRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireScope(entities.ScopeWriteJourneys))
 
 	journeyHandler := sight.JourneyHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.Journey, entities.Journey]{ServeHTTP: journeyHandler.CreateJourney}
@@ -166,6 +263,7 @@ func CreateJourneyRoutes() chi.Router {
 
 func DeleteJourneyRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireScope(entities.ScopeWriteJourneys))
 
 	journeyHandler := sight.JourneyHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.Journey, entities.Journey]{ServeHTTP: journeyHandler.DeleteJourney}
@@ -186,6 +284,7 @@ func JourneyRoutes() chi.Router {
 
 func AddJourneySightRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireScope(entities.ScopeWriteJourneys))
 
 	journeyHandler := sight.JourneyHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.JourneySightID, entities.JourneySight]{ServeHTTP: journeyHandler.AddJourneySight}
@@ -196,6 +295,7 @@ func AddJourneySightRoutes() chi.Router {
 
 func DeleteJourneySightRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireScope(entities.ScopeWriteJourneys))
 
 	journeyHandler := sight.JourneyHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.JourneySight, entities.JourneySight]{ServeHTTP: journeyHandler.DeleteJourneySight}
@@ -208,7 +308,18 @@ func JourneySightRoutes() chi.Router {
 	router := chi.NewRouter()
 
 	journeyHandler := sight.JourneyHandler{}
-	wrapperInstance := &wrapper.Wrapper[entities.JourneySight, entities.JourneySights]{ServeHTTP: journeyHandler.GetJourneySights}
+	wrapperInstance := &wrapper.Wrapper[entities.JourneySight, entities.JourneySights]{
+		ServeHTTP: journeyHandler.GetJourneySights,
+		// маршрут строится в порядке journey_sight.priority
+		Formats: geoexport.Formats(func(trip entities.JourneySights) geoexport.Document {
+			return geoexport.Document{
+				Name:        trip.Journey.Name,
+				Description: trip.Journey.Description,
+				Sights:      trip.Sight,
+				Route:       true,
+			}
+		}),
+	}
 	router.Get("/", wrapperInstance.HandlerWrapper)
 
 	return router
@@ -226,6 +337,7 @@ func GetProfileRoutes() chi.Router {
 
 func DeleteProfileRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireAuth)
 	profileHandler := user.ProfileHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.User, user.ProfileResponse]{ServeHTTP: profileHandler.DeleteUser}
 	router.Post("/", wrapperInstance.HandlerWrapper)
@@ -235,6 +347,7 @@ func DeleteProfileRoutes() chi.Router {
 
 func EditProfileRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireAuth)
 	profileHandler := user.ProfileHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.UserProfile, entities.UserProfile]{ServeHTTP: profileHandler.EditUserProfile}
 	router.Post("/", wrapperInstance.HandlerWrapper)
@@ -244,9 +357,147 @@ func EditProfileRoutes() chi.Router {
 
 func UpdateUserPasswordRoutes() chi.Router {
 	router := chi.NewRouter()
+	router.Use(middle.RequireAuth)
 	profileHandler := user.ProfileHandler{}
 	wrapperInstance := &wrapper.Wrapper[entities.Password, user.ProfileResponse]{ServeHTTP: profileHandler.UpdateUserPassword}
 	router.Post("/", wrapperInstance.HandlerWrapper)
 
 	return router
 }
+
+func SessionsRoutes() chi.Router {
+	router := chi.NewRouter()
+	router.Use(middle.RequireAuth)
+	sessionHandler := user.SessionHandler{}
+
+	listWrapper := &wrapper.Wrapper[entities.User, user.SessionsResponse]{ServeHTTP: sessionHandler.GetSessions}
+	router.Get("/", listWrapper.HandlerWrapper)
+
+	revokeOthersWrapper := &wrapper.Wrapper[entities.User, user.SessionsResponse]{ServeHTTP: sessionHandler.RevokeOtherSessions}
+	router.Post("/delete", revokeOthersWrapper.HandlerWrapper)
+
+	revokeWrapper := &wrapper.Wrapper[entities.User, user.SessionsResponse]{ServeHTTP: sessionHandler.RevokeSession}
+	router.Post("/{sid}/delete", revokeWrapper.HandlerWrapper)
+
+	return router
+}
+
+func TwoFactorRoutes() chi.Router {
+	router := chi.NewRouter()
+	router.Use(middle.RequireAuth)
+	twoFactorHandler := user.TwoFactorHandler{}
+
+	statusWrapper := &wrapper.Wrapper[entities.TwoFactorCode, entities.TOTP]{ServeHTTP: twoFactorHandler.Status}
+	router.Get("/", statusWrapper.HandlerWrapper)
+
+	enrollWrapper := &wrapper.Wrapper[entities.TwoFactorCode, user.TOTPEnrollResponse]{ServeHTTP: twoFactorHandler.Enroll}
+	router.Post("/enroll", enrollWrapper.HandlerWrapper)
+
+	confirmWrapper := &wrapper.Wrapper[entities.TwoFactorCode, user.RecoveryCodesResponse]{ServeHTTP: twoFactorHandler.Confirm}
+	router.Post("/confirm", confirmWrapper.HandlerWrapper)
+
+	disableWrapper := &wrapper.Wrapper[entities.TwoFactorCode, entities.TOTP]{ServeHTTP: twoFactorHandler.Disable}
+	router.Post("/disable", disableWrapper.HandlerWrapper)
+
+	return router
+}
+
+func APITokenRoutes() chi.Router {
+	router := chi.NewRouter()
+	router.Use(middle.RequireAuth)
+	tokenHandler := user.APITokenHandler{}
+
+	listWrapper := &wrapper.Wrapper[entities.APIToken, entities.APITokens]{ServeHTTP: tokenHandler.GetTokens}
+	router.Get("/", listWrapper.HandlerWrapper)
+
+	createWrapper := &wrapper.Wrapper[entities.APITokenRequest, user.CreatedAPITokenResponse]{ServeHTTP: tokenHandler.CreateToken}
+	router.Post("/create", createWrapper.HandlerWrapper)
+
+	revokeWrapper := &wrapper.Wrapper[entities.APIToken, entities.APIToken]{ServeHTTP: tokenHandler.RevokeToken}
+	router.Post("/{tid}/delete", revokeWrapper.HandlerWrapper)
+
+	return router
+}
+
+func ExportRoutes() chi.Router {
+	router := chi.NewRouter()
+	// архив содержит все личные данные, включая список токенов, поэтому по токену не отдаётся
+	router.Use(middle.RequireSession)
+	exportHandler := user.ExportHandler{}
+
+	startWrapper := &wrapper.Wrapper[entities.DataExport, entities.DataExport]{ServeHTTP: exportHandler.StartExport}
+	router.Post("/", startWrapper.HandlerWrapper)
+
+	statusWrapper := &wrapper.Wrapper[entities.DataExport, entities.DataExport]{ServeHTTP: exportHandler.GetExport}
+	router.Get("/{eid}", statusWrapper.HandlerWrapper)
+
+	router.Get("/{eid}/download", exportHandler.Download)
+
+	return router
+}
+
+// admin
+func AdminRoutes() chi.Router {
+	router := chi.NewRouter()
+	router.Use(middle.RequireAuth)
+	adminHandler := user.AdminHandler{}
+
+	router.Group(func(r chi.Router) {
+		r.Use(middle.RequirePermission(entities.PermManageUsers))
+
+		usersWrapper := &wrapper.Wrapper[entities.User, user.AdminUsersResponse]{ServeHTTP: adminHandler.GetUsers}
+		r.Get("/users", usersWrapper.HandlerWrapper)
+
+		roleWrapper := &wrapper.Wrapper[entities.UserRole, user.AdminUserResponse]{ServeHTTP: adminHandler.SetUserRole}
+		r.Post("/users/{id}/role", roleWrapper.HandlerWrapper)
+
+		deleteWrapper := &wrapper.Wrapper[entities.User, user.AdminUserResponse]{ServeHTTP: adminHandler.DeleteUser}
+		r.Post("/users/{id}/delete", deleteWrapper.HandlerWrapper)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(middle.RequirePermission(entities.PermManageCities))
+
+		citiesWrapper := &wrapper.Wrapper[entities.City, entities.Cities]{ServeHTTP: adminHandler.GetCities}
+		r.Get("/cities", citiesWrapper.HandlerWrapper)
+
+		createWrapper := &wrapper.Wrapper[entities.City, entities.City]{ServeHTTP: adminHandler.CreateCity}
+		r.Post("/cities/create", createWrapper.HandlerWrapper)
+
+		deleteWrapper := &wrapper.Wrapper[entities.City, entities.City]{ServeHTTP: adminHandler.DeleteCity}
+		r.Post("/cities/{id}/delete", deleteWrapper.HandlerWrapper)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(middle.RequirePermission(entities.PermManageSights))
+
+		sightWrapper := &wrapper.Wrapper[entities.Sight, entities.Sight]{ServeHTTP: adminHandler.GetSight}
+		r.Get("/sights/{id}", sightWrapper.HandlerWrapper)
+
+		createWrapper := &wrapper.Wrapper[entities.Sight, entities.Sight]{ServeHTTP: adminHandler.CreateSight}
+		r.Post("/sights/create", createWrapper.HandlerWrapper)
+
+		updateWrapper := &wrapper.Wrapper[entities.SightPatch, entities.Sight]{ServeHTTP: adminHandler.UpdateSight}
+		r.Patch("/sights/{id}", updateWrapper.HandlerWrapper)
+
+		archiveWrapper := &wrapper.Wrapper[entities.SightPatch, entities.Sight]{ServeHTTP: adminHandler.ArchiveSight}
+		r.Post("/sights/{id}/archive", archiveWrapper.HandlerWrapper)
+
+		restoreWrapper := &wrapper.Wrapper[entities.SightPatch, entities.Sight]{ServeHTTP: adminHandler.RestoreSight}
+		r.Post("/sights/{id}/restore", restoreWrapper.HandlerWrapper)
+
+		deleteWrapper := &wrapper.Wrapper[entities.SightPatch, entities.Sight]{ServeHTTP: adminHandler.DeleteSight}
+		r.Post("/sights/{id}/delete", deleteWrapper.HandlerWrapper)
+
+		r.Post("/sights/import", adminHandler.ImportSights)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(middle.RequirePermission(entities.PermViewAudit))
+
+		auditWrapper := &wrapper.Wrapper[entities.AuditEvent, entities.AuditEvents]{ServeHTTP: adminHandler.GetAuditEvents}
+		r.Get("/audit", auditWrapper.HandlerWrapper)
+	})
+
+	return router
+}
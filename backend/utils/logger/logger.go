@@ -36,3 +36,8 @@ func init() {
 func Logger() *slog.Logger {
 	return logger
 }
+
+// Подменяет логгер, например на тихий в тестах
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
@@ -0,0 +1,38 @@
+package middle
+
+import (
+	"net/http"
+
+	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
+	"homework_ipl/utils/logger"
+)
+
+var errInvalidCSRFToken = errors.HttpError{
+	Code:    http.StatusForbidden,
+	Message: "invalid csrf token",
+}
+
+// Все изменяющие запросы с кукой сессии должны нести заголовок X-CSRF-Token,
+// полученный через GET /csrf. Запросы без сессии (вход, регистрация) и запросы
+// с API-токеном (браузер не подставляет его сам) не проверяются
+func CSRFMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if safeMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, ok := TokenScopes(r.Context()); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, ok := CurrentUser(r.Context()); ok && !usecase.ValidCSRFToken(r, r.Header.Get(usecase.CSRFHeader)) {
+			logger.Logger().Error("CSRF token mismatch", "method", r.Method, "path", r.URL.Path)
+			errors.WriteHttpError(errInvalidCSRFToken, w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
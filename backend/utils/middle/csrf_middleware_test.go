@@ -0,0 +1,64 @@
+package middle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	handler := CSRFMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	encoded, err := usecase.CookieHandler.Encode("session_id", "session")
+	require.NoError(t, err)
+
+	sessionRequest := func(method string) *http.Request {
+		req := httptest.NewRequest(method, "/trip/create", nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: encoded})
+		return req.WithContext(WithCurrentUser(req.Context(), 1))
+	}
+	token, ok := usecase.CSRFToken(sessionRequest(http.MethodGet))
+	require.True(t, ok)
+
+	cases := []struct {
+		name   string
+		method string
+		token  string
+		bearer bool
+		code   int
+	}{
+		{"safe method without token", http.MethodGet, "", false, http.StatusOK},
+		{"missing token", http.MethodPost, "", false, http.StatusForbidden},
+		{"wrong token", http.MethodPost, "deadbeef", false, http.StatusForbidden},
+		{"valid token", http.MethodPost, token, false, http.StatusOK},
+		{"api token is not checked", http.MethodPost, "", true, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := sessionRequest(c.method)
+			if c.token != "" {
+				req.Header.Set(usecase.CSRFHeader, c.token)
+			}
+			if c.bearer {
+				req = req.WithContext(WithTokenScopes(req.Context(), []string{entities.ScopeWriteJourneys}))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, c.code, w.Code)
+		})
+	}
+
+	// без сессии (вход, регистрация) токен не нужен
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
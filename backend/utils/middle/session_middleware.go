@@ -4,18 +4,187 @@ import (
 	"context"
 	"net/http"
 
+	"homework_ipl/internal/entities"
 	"homework_ipl/internal/usecase"
+	"homework_ipl/utils/errors"
 )
 
 type userIDType struct{}
+type roleType struct{}
+type tokenScopesType struct{}
 
-var userIDKey userIDType
+var (
+	userIDKey      userIDType
+	roleKey        roleType
+	tokenScopesKey tokenScopesType
+)
+
+var (
+	errUnauthorized = errors.HttpError{
+		Code:    http.StatusUnauthorized,
+		Message: "unauthorized",
+	}
+	errForbidden = errors.HttpError{
+		Code:    http.StatusForbidden,
+		Message: "permission denied",
+	}
+	errInvalidAPIToken = errors.HttpError{
+		Code:    http.StatusUnauthorized,
+		Message: "invalid api token",
+	}
+	errTokenScope = errors.HttpError{
+		Code:    http.StatusForbidden,
+		Message: "api token scope does not allow this request",
+	}
+	errSessionRequired = errors.HttpError{
+		Code:    http.StatusForbidden,
+		Message: "this request requires a browser session, api tokens are not accepted",
+	}
+)
 
+// Кладёт айди авторизованного пользователя в контекст запроса,
+// дальше его достают через CurrentUser. Пользователь определяется по
+// заголовку Authorization: Bearer (API-токен) или по куке сессии
 func SessionMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userID := usecase.GetSession(r)
+		token, hasBearer, ok := usecase.AuthenticateBearer(r)
+		if hasBearer {
+			if !ok {
+				errors.WriteHttpError(errInvalidAPIToken, w)
+				return
+			}
+			// роль не передаётся: по токену админка и модерация недоступны
+			ctx := WithCurrentUser(r.Context(), token.UserID)
+			ctx = WithTokenScopes(ctx, token.Scopes)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
 
-		ctx := context.WithValue(r.Context(), userIDKey, userID)
+		session, _ := usecase.GetSessionData(w, r)
+
+		ctx := WithCurrentUser(r.Context(), session.UserID)
+		ctx = WithCurrentRole(ctx, session.Role)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// Пропускает дальше только авторизованных пользователей. По API-токену
+// проходят только читающие запросы со scope read, изменяющие запросы
+// разрешаются отдельно через RequireScope
+func RequireAuth(next http.Handler) http.Handler {
+	return RequireScope("")(next)
+}
+
+// Только для входа по куке сессии: API-токен, даже со scope read, не пропускается.
+// Для ручек, которые отдают все личные данные (выгрузка аккаунта)
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := CurrentUser(r.Context()); !ok {
+			errors.WriteHttpError(errUnauthorized, w)
+			return
+		}
+		if _, ok := TokenScopes(r.Context()); ok {
+			errors.WriteHttpError(errSessionRequired, w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Как RequireAuth, но изменяющие запросы по API-токену пропускаются, если у токена есть scope.
+// Для запросов с кукой сессии scope не проверяется
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := CurrentUser(r.Context()); !ok {
+				errors.WriteHttpError(errUnauthorized, w)
+				return
+			}
+
+			if scopes, ok := TokenScopes(r.Context()); ok {
+				required := scope
+				if safeMethod(r.Method) {
+					required = entities.ScopeRead
+				}
+				if required == "" || !hasScope(scopes, required) {
+					errors.WriteHttpError(errTokenScope, w)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Пропускает дальше только пользователей, чья роль даёт permission
+func RequirePermission(permission entities.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := CurrentUser(r.Context()); !ok {
+				errors.WriteHttpError(errUnauthorized, w)
+				return
+			}
+			if !entities.HasPermission(CurrentRole(r.Context()), permission) {
+				errors.WriteHttpError(errForbidden, w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Айди текущего пользователя, ok == false для анонимного запроса
+func CurrentUser(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDKey).(int)
+	if !ok || userID == 0 {
+		return 0, false
+	}
+	return userID, true
+}
+
+func WithCurrentUser(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// Роль текущего пользователя, пустая строка для анонимного запроса
+func CurrentRole(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey).(string)
+	return role
+}
+
+func WithCurrentRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// Scopes API-токена, ok == false, если запрос авторизован не токеном
+func TokenScopes(ctx context.Context) ([]string, bool) {
+	scopes, ok := ctx.Value(tokenScopesKey).([]string)
+	return scopes, ok
+}
+
+func WithTokenScopes(ctx context.Context, scopes []string) context.Context {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return context.WithValue(ctx, tokenScopesKey, scopes)
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package middle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"homework_ipl/internal/entities"
+	"homework_ipl/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireAuth(t *testing.T) {
+	handler := RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := CurrentUser(r.Context())
+		assert.Equal(t, 1, userID)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	anonymous := httptest.NewRecorder()
+	handler.ServeHTTP(anonymous, httptest.NewRequest(http.MethodPost, "/trip/create", nil))
+	assert.Equal(t, http.StatusUnauthorized, anonymous.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/trip/create", nil)
+	req = req.WithContext(WithCurrentUser(req.Context(), 1))
+	authorized := httptest.NewRecorder()
+	handler.ServeHTTP(authorized, req)
+	assert.Equal(t, http.StatusOK, authorized.Code)
+}
+
+func TestSessionMiddlewareAnonymous(t *testing.T) {
+	handler := SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := CurrentUser(r.Context())
+		assert.False(t, ok)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/sights", nil))
+}
+
+func TestRequirePermission(t *testing.T) {
+	handler := RequirePermission(entities.PermManageUsers)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		name   string
+		userID int
+		role   string
+		code   int
+	}{
+		{"anonymous", 0, "", http.StatusUnauthorized},
+		{"user", 1, entities.RoleUser, http.StatusForbidden},
+		{"moderator", 1, entities.RoleModerator, http.StatusForbidden},
+		{"admin", 1, entities.RoleAdmin, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+			ctx := WithCurrentRole(WithCurrentUser(req.Context(), c.userID), c.role)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req.WithContext(ctx))
+			assert.Equal(t, c.code, w.Code)
+		})
+	}
+}
+
+func TestSessionMiddlewareBearer(t *testing.T) {
+	prev := usecase.APITokens
+	usecase.APITokens = usecase.NewMemoryAPITokenStore()
+	defer func() { usecase.APITokens = prev }()
+
+	token, _, err := usecase.CreateAPIToken(context.Background(), 7, entities.APITokenRequest{
+		Name:   "script",
+		Scopes: []string{entities.ScopeRead},
+	})
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	expiring, created, err := usecase.CreateAPIToken(context.Background(), 7, entities.APITokenRequest{
+		Name:      "expiring",
+		Scopes:    []string{entities.ScopeRead},
+		ExpiresAt: &expiresAt,
+	})
+	require.NoError(t, err)
+
+	var gotUser int
+	var gotRole string
+	handler := SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _ = CurrentUser(r.Context())
+		gotRole = CurrentRole(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(header string) int {
+		req := httptest.NewRequest(http.MethodGet, "/profile/7", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("Bearer "+token))
+	assert.Equal(t, 7, gotUser)
+	assert.Empty(t, gotRole, "api tokens never carry a role")
+
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer hipl_wrong"))
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer garbage"))
+
+	assert.Equal(t, http.StatusOK, serve("Bearer "+expiring))
+	tokens, err := usecase.ListAPITokens(context.Background(), 7)
+	require.NoError(t, err)
+	for _, tok := range tokens {
+		if tok.ID == created.ID {
+			assert.NotNil(t, tok.LastUsedAt, "last use is tracked")
+		}
+	}
+
+	require.NoError(t, usecase.RevokeAPIToken(context.Background(), 7, created.ID))
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer "+expiring))
+}
+
+func TestRequireScope(t *testing.T) {
+	handler := RequireScope(entities.ScopeWriteJourneys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	plainAuth := RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		scopes  []string
+		code    int
+	}{
+		{"cookie session ignores scopes", handler, http.MethodPost, nil, http.StatusOK},
+		{"token with scope", handler, http.MethodPost, []string{entities.ScopeWriteJourneys}, http.StatusOK},
+		{"token without scope", handler, http.MethodPost, []string{entities.ScopeRead, entities.ScopeWriteComments}, http.StatusForbidden},
+		{"token reads with read", plainAuth, http.MethodGet, []string{entities.ScopeRead}, http.StatusOK},
+		{"token reads without read", plainAuth, http.MethodGet, []string{entities.ScopeWriteJourneys}, http.StatusForbidden},
+		{"token cannot use unscoped writes", plainAuth, http.MethodPost, []string{entities.ScopeRead, entities.ScopeWriteJourneys, entities.ScopeWriteComments}, http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, "/trip/create", nil)
+			ctx := WithCurrentUser(req.Context(), 1)
+			if c.scopes != nil {
+				ctx = WithTokenScopes(ctx, c.scopes)
+			}
+			w := httptest.NewRecorder()
+			c.handler.ServeHTTP(w, req.WithContext(ctx))
+			assert.Equal(t, c.code, w.Code)
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	handler := RequireSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(ctx context.Context) int {
+		req := httptest.NewRequest(http.MethodGet, "/profile/1/export/1/download", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req.WithContext(ctx))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(context.Background()))
+	assert.Equal(t, http.StatusOK, serve(WithCurrentUser(context.Background(), 1)))
+
+	token := WithTokenScopes(WithCurrentUser(context.Background(), 1), []string{entities.ScopeRead})
+	assert.Equal(t, http.StatusForbidden, serve(token))
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strings"
 
 	"homework_ipl/utils/errors"
 	"homework_ipl/utils/httputils"
@@ -28,10 +29,25 @@ var (
 		Code:    http.StatusInternalServerError,
 		Message: "json encoding error",
 	}
+
+	notAcceptableErr = errors.HttpError{
+		Code:    http.StatusNotAcceptable,
+		Message: "unsupported response format",
+	}
 )
 
 type Wrapper[T Validator, Resp any] struct {
 	ServeHTTP func(ctx context.Context, parsedRequest T) (Resp, error)
+	// Форматы ответа кроме JSON, выбираются параметром ?format= или заголовком Accept
+	Formats []Format[Resp]
+}
+
+// Format - дополнительный формат ответа (GeoJSON, KML и т.п.)
+type Format[Resp any] struct {
+	// Значение параметра ?format=
+	Name        string
+	ContentType string
+	Encode      func(response Resp) ([]byte, error)
 }
 
 type Validator interface {
@@ -67,6 +83,12 @@ func (w *Wrapper[T, Resp]) HandlerWrapper(resWriter http.ResponseWriter, httpReq
 		}
 	}
 
+	format, ok := w.negotiateFormat(httpReq)
+	if !ok {
+		errors.WriteHttpError(notAcceptableErr, resWriter)
+		return
+	}
+
 	response, httpErr := w.ServeHTTP(ctx, requestData)
 	if httpErr != nil {
 		logger.Error("Handler error", "error", httpErr)
@@ -74,6 +96,19 @@ func (w *Wrapper[T, Resp]) HandlerWrapper(resWriter http.ResponseWriter, httpReq
 		return
 	}
 
+	if format != nil {
+		body, err := format.Encode(response)
+		if err != nil {
+			logger.Error("Error encoding response", "format", format.Name, "error", err)
+			errors.WriteHttpError(encodingErr, resWriter)
+			return
+		}
+		resWriter.Header().Set("Content-Type", format.ContentType)
+		resWriter.WriteHeader(http.StatusOK)
+		_, _ = resWriter.Write(body)
+		return
+	}
+
 	rawJSON, err := json.Marshal(response)
 	if err != nil {
 		logger.Error("Error encoding response", "error", err)
@@ -86,6 +121,40 @@ func (w *Wrapper[T, Resp]) HandlerWrapper(resWriter http.ResponseWriter, httpReq
 	_, _ = resWriter.Write(rawJSON)
 }
 
+// Выбирает формат ответа: nil - JSON. Явный ?format= важнее Accept,
+// из Accept берётся первый известный тип (q-веса не учитываются).
+// ok == false - запрошен неизвестный ?format=
+func (w *Wrapper[T, Resp]) negotiateFormat(r *http.Request) (*Format[Resp], bool) {
+	if len(w.Formats) == 0 {
+		return nil, true
+	}
+
+	if name := r.URL.Query().Get("format"); name != "" {
+		if name == "json" {
+			return nil, true
+		}
+		for i := range w.Formats {
+			if w.Formats[i].Name == name {
+				return &w.Formats[i], true
+			}
+		}
+		return nil, false
+	}
+
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(mediaRange, ";", 2)[0])
+		if mediaType == "application/json" {
+			return nil, true
+		}
+		for i := range w.Formats {
+			if strings.EqualFold(mediaType, w.Formats[i].ContentType) {
+				return &w.Formats[i], true
+			}
+		}
+	}
+	return nil, true
+}
+
 func GetPathParams(r *http.Request) map[string]string {
 	params := chi.RouteContext(r.Context()).URLParams
 	pathParams := make(map[string]string)
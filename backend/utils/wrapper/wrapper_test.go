@@ -0,0 +1,64 @@
+package wrapper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type emptyRequest struct{}
+
+func (emptyRequest) Validate() error {
+	return nil
+}
+
+func TestHandlerWrapperFormats(t *testing.T) {
+	calls := 0
+	w := &Wrapper[emptyRequest, string]{
+		ServeHTTP: func(context.Context, emptyRequest) (string, error) {
+			calls++
+			return "ok", nil
+		},
+		Formats: []Format[string]{{
+			Name:        "text",
+			ContentType: "text/plain",
+			Encode:      func(response string) ([]byte, error) { return []byte(response), nil },
+		}},
+	}
+
+	router := chi.NewRouter()
+	router.Get("/", w.HandlerWrapper)
+
+	serve := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/", "")
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `"ok"`, rec.Body.String())
+
+	rec = serve("/?format=text", "application/json")
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"), "format parameter wins over Accept")
+	assert.Equal(t, "ok", rec.Body.String())
+
+	rec = serve("/", "text/plain;q=0.9, application/json")
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+
+	rec = serve("/", "application/json, text/plain")
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	before := calls
+	rec = serve("/?format=xml", "")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.Equal(t, before, calls, "handler is not called for unknown formats")
+}
@@ -19,11 +19,30 @@ export async function get( endpoint : string, body? : unknown) : Promise<unknown
 }
 
 
+/**
+* Получение CSRF-токена текущей сессии. Без сессии возвращает пустую строку.
+* @async
+* @function csrfToken
+* @returns {Promise<string>}
+*/
+export async function csrfToken() : Promise<string> {
+  const response = await fetch(`${ENV_CONFIG.API_URL}/csrf`, {
+    credentials: 'include',
+  });
+  if (!response.ok) {
+    return '';
+  }
+  const responseData = await response.json();
+  return responseData.csrf_token ?? '';
+}
+
+
 export async function post(endpoint : string, body? : unknown): Promise<unknown> {
   const response = await fetch(`${ENV_CONFIG.API_URL}/${endpoint}`, {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json', 
+      'X-CSRF-Token': await csrfToken(),
     },
     credentials: 'include',
     body: JSON.stringify(body),
@@ -37,7 +56,7 @@ export async function post(endpoint : string, body? : unknown): Promise<unknown>
 
 
 export default {
-  get, post, 
+  get, post, csrfToken, 
 };
 
 
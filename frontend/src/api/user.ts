@@ -1,12 +1,13 @@
 import { WithResponse, UserAuthRequest, UserAuthResponseData, UserProfile } from 'src/types/api';
 import { ENV_CONFIG } from '../../envConfig';
-import { get, post } from '@api/base';
+import { get, post, csrfToken } from '@api/base';
 
 export async function authorize(endpoint : string, body? : UserAuthRequest): Promise<WithResponse<UserAuthResponseData>> {
   const response = await fetch(`${ENV_CONFIG.API_URL}/${endpoint}`, {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json', 
+      'X-CSRF-Token': await csrfToken(),
     },
     credentials: 'include',
     body: JSON.stringify(body),
@@ -30,6 +31,9 @@ export async function authorize(endpoint : string, body? : UserAuthRequest): Pro
 export async function imageUpload(endpoint : string, body? : FormData) {
   const response = await fetch(`${ENV_CONFIG.API_URL}/${endpoint}`, {
     method: 'POST',
+    headers: {
+      'X-CSRF-Token': await csrfToken(),
+    },
     credentials: 'include',
     body: body,
   });
@@ -54,6 +58,13 @@ export async function editProfile(userId: number, username: string, bio: string)
   return await post(`profile/${userId}/edit`, profileRequestBody) as WithResponse<UserProfile>;
 }
 
+export async function resetPasswordByToken(token: string, newPassword: string) {
+  return await post('password/reset', {
+    token: token,
+    new_password: newPassword,
+  }) as WithResponse<{ error?: string }>;
+}
+
 export async function resetPassword(userId: number, password: string, newPassword: string) {
   return await post(`profile/${userId}/reset_password`, {
     password: password, 
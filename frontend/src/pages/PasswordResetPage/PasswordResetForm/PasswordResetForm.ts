@@ -0,0 +1,82 @@
+import AuthorizationForm from '@components/Form/AuthorizationForm';
+import Button from '@components/Button/Button';
+import Logo from '@components/Logo/Logo';
+import { resetPasswordByToken } from '@api/user';
+import { router } from '@router/router';
+import { validate } from '@utils/validation';
+import { resetErrors } from '../../../types/errors';
+import urls from '@router/urls';
+import template from '@templates/PasswordResetForm.hbs';
+
+/**
+* Класс PasswordResetForm - форма нового пароля. Токен берётся из ссылки
+* в письме (/password/reset?token=...) и отправляется вместе с паролем.
+* @class
+*/
+class PasswordResetForm extends AuthorizationForm {
+
+  constructor(parent: HTMLElement) {
+    super(parent, template);
+  }
+
+  render() {
+    this.preRender();
+
+    const logoGroup = document.getElementById('logo-group') as HTMLDivElement;
+    new Logo(logoGroup).render();
+
+    this.enablePasswordVisibilityButtons();
+
+    const resetForm = document.getElementById('password-reset-form') as HTMLDivElement;
+    new Button(resetForm, {
+      id: 'button-submit', label: 'Сохранить пароль', type: 'submit',
+    }).render();
+    const submitButton = document.getElementById('button-submit') as HTMLButtonElement;
+    submitButton.disabled = true;
+
+    const password = document.getElementById('password') as HTMLInputElement;
+    const repeatPassword = document.getElementById('password-repeat') as HTMLInputElement;
+    const lowestInput = repeatPassword.parentElement as HTMLDivElement;
+
+    const token = new URLSearchParams(window.location.search).get('token') ?? '';
+    if (token === '') {
+      this.renderError(lowestInput, resetErrors['invalid or expired reset link']);
+    }
+
+    resetForm.addEventListener('input', (e: Event) => {
+      const input = e.target as HTMLInputElement;
+      const parent = input.parentElement as HTMLElement;
+      validate( input.value, input.type )
+        .catch((error) => { this.renderError(parent, error.message); })
+        .then(() => {
+          this.enableSubmitButton();
+        });
+      this.clearError(parent);
+    },
+    );
+
+    submitButton.addEventListener('click', (e : Event) => {
+      e.preventDefault();
+
+      if (password.value !== repeatPassword.value) {
+        this.renderError(lowestInput, 'Пароли не совпадают');
+        return;
+      } else {
+        this.clearError(lowestInput);
+      }
+
+      resetPasswordByToken(token, password.value)
+        .then((response) => {
+          if (response.status === 200) {
+            document.body.classList.remove('auth-background');
+            router.go(urls.login);
+            return;
+          }
+          const message = resetErrors[response.data?.error] ?? response.data?.error;
+          this.renderError(lowestInput, message);
+        });
+    });
+  }
+}
+
+export default PasswordResetForm;
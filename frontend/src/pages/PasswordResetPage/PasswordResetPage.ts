@@ -0,0 +1,25 @@
+import PasswordResetForm from './PasswordResetForm/PasswordResetForm';
+import Base from '@components/Base/Base';
+import template from '@templates/PasswordResetPage.hbs';
+
+/**
+* Класс PasswordResetPage представляет страницу сброса пароля по ссылке из письма.
+* @class
+*/
+class PasswordResetPage extends Base {
+
+  constructor(parent: HTMLElement) {
+    super(parent, template);
+  }
+
+  /**
+  * Рендерит страницу сброса пароля в DOM, включая форму нового пароля.
+  */
+  render() {
+    this.preRender();
+    document.body.classList.add('auth-background');
+    new PasswordResetForm(this.parent).render();
+  }
+}
+
+export default PasswordResetPage;
@@ -64,7 +64,11 @@ class SightPage extends Base {
     name.textContent = this.sight.name;
 
     const photo = document.querySelector('.sight-container img') as HTMLImageElement;
-    photo.src = `/${this.sight.url}`;
+    if (this.sight.url) {
+      photo.src = `/${this.sight.url}`;
+    } else {
+      photo.remove();
+    }
 
     const location = document.querySelector('h2') as HTMLHeadingElement;
     location.textContent = `${this.sight.city}, ${this.sight.country}`;
@@ -7,6 +7,7 @@ import ProfilePage from '@pages/ProfilePage/ProfilePage';
 import SightPage from '@pages/SightPage/SightPage';
 import JourneyPage from '@pages/JourneyPage/JourneyPage';
 import AlbumPage from '@pages/AlbumPage/AlbumPage';
+import PasswordResetPage from '@pages/PasswordResetPage/PasswordResetPage';
 
 const routesList = {
   [urls.base]: PlacesPage,
@@ -16,6 +17,7 @@ const routesList = {
   [urls.profile]: ProfilePage,
   [urls.sight]: SightPage,
   [urls.journey]: JourneyPage,
+  [urls.password]: PasswordResetPage,
   // [urls.albums]: AlbumPage,
 };
 
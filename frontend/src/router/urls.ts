@@ -8,6 +8,8 @@ const urls = {
   sight: 'sights',
   journey: 'journey',
   albums : 'albums',
+  // /password/reset?token=... - ссылка из письма сброса пароля
+  password: 'password',
 };
 
 export default urls;
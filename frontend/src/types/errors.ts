@@ -10,6 +10,11 @@ export const loginErrors: { [key: string] : string } = {
   '400': 'Неверный логин или пароль',
 };
 
+export const resetErrors: { [key: string]: string } = {
+  'invalid or expired reset link': 'Ссылка для сброса пароля недействительна или устарела',
+  'password is not complex': 'Пароль должен содержать 8-32 символа, включая специальные символы, заглавную букву и цифры',
+};
+
 export const logoutErrors : { [key: string] : string } = {
   '401': 'Выход из аккаунта уже был выполнен ранее',
 };